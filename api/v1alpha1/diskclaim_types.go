@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DiskClaimPhase is the outcome of a DiskClaim
+type DiskClaimPhase string
+
+const (
+	// DiskClaimPending is a claim that the operator has not handled yet
+	DiskClaimPending DiskClaimPhase = ""
+	// DiskClaimReserved is a claim that holds the reservation of its WWN
+	DiskClaimReserved DiskClaimPhase = "Reserved"
+	// DiskClaimConflict is a claim whose WWN is reserved by another DiskClaim
+	DiskClaimConflict DiskClaimPhase = "Conflict"
+)
+
+// DiskClaimSpec defines the desired state of DiskClaim
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type DiskClaimSpec struct {
+	// WWN of the device reserved for PurpleStorage, as reported in the LocalVolumeDiscoveryResults
+	// +kubebuilder:validation:MinLength=1
+	WWN string `json:"WWN"`
+	// NodeName is the node the device is attached to. Empty for devices shared by several nodes
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// Description tells why the device is reserved
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// DiskClaimStatus defines the observed state of DiskClaim
+type DiskClaimStatus struct {
+	// Phase tells whether the claim holds the reservation of its WWN
+	// +optional
+	Phase DiskClaimPhase `json:"phase,omitempty"`
+	// Message explains the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=diskclaims,scope=Namespaced
+// +kubebuilder:printcolumn:name="WWN",type=string,JSONPath=`.spec.WWN`
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DiskClaim is the Schema for the diskclaims API.
+// It reserves a device for PurpleStorage so that it is not used by other storage software.
+// Only one DiskClaim can reserve a WWN: the webhook rejects the claims of a WWN that is
+// already claimed, and the operator reserves the WWN for the first claim it handles.
+type DiskClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DiskClaimSpec   `json:"spec,omitempty"`
+	Status DiskClaimStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DiskClaimList contains a list of DiskClaim
+type DiskClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DiskClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DiskClaim{}, &DiskClaimList{})
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var diskclaimlog = logf.Log.WithName("diskclaim-resource")
+
+// +kubebuilder:object:generate=false
+// +k8s:deepcopy-gen=false
+// +k8s:openapi-gen=false
+// DiskClaimValidator rejects the DiskClaims of devices that are already claimed or used by other storage software
+type DiskClaimValidator struct {
+	Client client.Client
+}
+
+//nolint:lll
+// +kubebuilder:webhook:verbs=create;update,path=/validate-purple-purplestorage-com-v1alpha1-diskclaim,mutating=false,failurePolicy=fail,groups=purple.purplestorage.com,resources=diskclaims,versions=v1alpha1,name=vdiskclaim.kb.io,admissionReviewVersions=v1,sideEffects=none
+
+var _ webhook.CustomValidator = &DiskClaimValidator{}
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *DiskClaimValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&DiskClaim{}).
+		WithValidator(r).
+		Complete()
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *DiskClaimValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	claim, err := convertToDiskClaim(obj)
+	if err != nil {
+		diskclaimlog.Error(err, "validate create")
+		return nil, err
+	}
+	diskclaimlog.Info("validate create", "name", claim.Name, "WWN", claim.Spec.WWN)
+
+	return nil, r.validateClaim(ctx, claim)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// The spec of a claim is immutable, so an update that keeps it has nothing to validate: checking it
+// again would block the changes of the metadata of a claim whose device got used since its creation.
+func (r *DiskClaimValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldClaim, err := convertToDiskClaim(oldObj)
+	if err != nil {
+		diskclaimlog.Error(err, "validate update")
+		return nil, err
+	}
+	claim, err := convertToDiskClaim(newObj)
+	if err != nil {
+		diskclaimlog.Error(err, "validate update")
+		return nil, err
+	}
+	diskclaimlog.Info("validate update", "name", claim.Name, "WWN", claim.Spec.WWN)
+
+	if reflect.DeepEqual(oldClaim.Spec, claim.Spec) {
+		return nil, nil
+	}
+
+	return nil, r.validateClaim(ctx, claim)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *DiskClaimValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	claim, err := convertToDiskClaim(obj)
+	if err != nil {
+		diskclaimlog.Error(err, "validate delete")
+		return nil, err
+	}
+	diskclaimlog.Info("validate delete", "name", claim.Name)
+
+	return nil, nil
+}
+
+// validateClaim looks for the other claims and the discovered owners of the device of the claim
+func (r *DiskClaimValidator) validateClaim(ctx context.Context, claim *DiskClaim) error {
+	var claims DiskClaimList
+	if err := r.Client.List(ctx, &claims); err != nil {
+		return fmt.Errorf("failed to list DiskClaim resources: %v", err)
+	}
+	var results LocalVolumeDiscoveryResultList
+	if err := r.Client.List(ctx, &results); err != nil {
+		return fmt.Errorf("failed to list LocalVolumeDiscoveryResult resources: %v", err)
+	}
+
+	return findDiskClaimConflict(claim, claims.Items, results.Items)
+}
+
+// findDiskClaimConflict returns an error when the device of the claim is claimed by another DiskClaim, or
+// when a LocalVolumeDiscoveryResult reports it as used by storage software other than Storage Scale
+func findDiskClaimConflict(claim *DiskClaim, claims []DiskClaim, results []LocalVolumeDiscoveryResult) error {
+	for _, other := range claims {
+		if other.Namespace == claim.Namespace && other.Name == claim.Name {
+			continue
+		}
+		if strings.EqualFold(other.Spec.WWN, claim.Spec.WWN) {
+			return fmt.Errorf("WWN %q is already claimed by DiskClaim %s/%s", claim.Spec.WWN, other.Namespace, other.Name)
+		}
+	}
+
+	for _, result := range results {
+		if claim.Spec.NodeName != "" && result.Spec.NodeName != claim.Spec.NodeName {
+			continue
+		}
+		for _, device := range result.Status.DiscoveredDevices {
+			if !strings.EqualFold(device.WWN, claim.Spec.WWN) {
+				continue
+			}
+			if device.OwnedBy != "" && device.OwnedBy != StorageScaleOwner {
+				return fmt.Errorf("WWN %q is used by %s on node %q", claim.Spec.WWN, device.OwnedBy, result.Spec.NodeName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func convertToDiskClaim(obj runtime.Object) (*DiskClaim, error) {
+	c, ok := obj.(*DiskClaim)
+	if !ok {
+		return nil, fmt.Errorf("expected a DiskClaim object but got %T", obj)
+	}
+	return c, nil
+}
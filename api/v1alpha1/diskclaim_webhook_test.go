@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFindDiskClaimConflict(t *testing.T) {
+	claims := []DiskClaim{
+		{ObjectMeta: metav1.ObjectMeta{Name: "scale-data", Namespace: "ns"}, Spec: DiskClaimSpec{WWN: "0x5000c500155a3456"}},
+	}
+	results := []LocalVolumeDiscoveryResult{
+		{
+			Spec: LocalVolumeDiscoveryResultSpec{NodeName: "worker-0"},
+			Status: LocalVolumeDiscoveryResultStatus{DiscoveredDevices: []DiscoveredDevice{
+				{WWN: "0x5000c500155a3457", OwnedBy: LocalStorageOwner},
+				{WWN: "0x5000c500155a3458", OwnedBy: StorageScaleOwner},
+				{WWN: "0x5000c500155a3459"},
+			}},
+		},
+	}
+
+	testcases := []struct {
+		label       string
+		claim       DiskClaim
+		expectedErr string
+	}{
+		{
+			label: "case 1", // unclaimed and unused device
+			claim: DiskClaim{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "ns"}, Spec: DiskClaimSpec{WWN: "0x5000c500155a3459"}},
+		},
+		{
+			label:       "case 2", // device claimed by another DiskClaim
+			claim:       DiskClaim{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "other"}, Spec: DiskClaimSpec{WWN: "0x5000C500155A3456"}},
+			expectedErr: `WWN "0x5000C500155A3456" is already claimed by DiskClaim ns/scale-data`,
+		},
+		{
+			label: "case 3", // update of the existing claim
+			claim: claims[0],
+		},
+		{
+			label:       "case 4", // device used by the Local Storage Operator
+			claim:       DiskClaim{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "ns"}, Spec: DiskClaimSpec{WWN: "0x5000c500155a3457"}},
+			expectedErr: `WWN "0x5000c500155a3457" is used by local-storage on node "worker-0"`,
+		},
+		{
+			label: "case 5", // device used by the Local Storage Operator on another node
+			claim: DiskClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "ns"},
+				Spec:       DiskClaimSpec{WWN: "0x5000c500155a3457", NodeName: "worker-1"},
+			},
+		},
+		{
+			label: "case 6", // device already used by Storage Scale
+			claim: DiskClaim{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "ns"}, Spec: DiskClaimSpec{WWN: "0x5000c500155a3458"}},
+		},
+	}
+
+	for _, tc := range testcases {
+		err := findDiskClaimConflict(&tc.claim, claims, results)
+		if tc.expectedErr == "" {
+			assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		} else {
+			assert.EqualErrorf(t, err, tc.expectedErr, "[%s] invalid error", tc.label)
+		}
+	}
+}
+
+func TestValidateUpdateDiskClaim(t *testing.T) {
+	scheme, err := SchemeBuilder.Build()
+	assert.NoError(t, err)
+	claim := &DiskClaim{ObjectMeta: metav1.ObjectMeta{Name: "scale-data", Namespace: "ns"}, Spec: DiskClaimSpec{WWN: "0x5000c500155a3457"}}
+	// the device of the claim got used by the Local Storage Operator after the claim was created
+	result := &LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-worker-0", Namespace: "ns"},
+		Spec:       LocalVolumeDiscoveryResultSpec{NodeName: "worker-0"},
+		Status: LocalVolumeDiscoveryResultStatus{DiscoveredDevices: []DiscoveredDevice{
+			{WWN: "0x5000c500155a3457", OwnedBy: LocalStorageOwner},
+		}},
+	}
+	validator := &DiskClaimValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim, result).Build()}
+
+	// the claim keeps its spec, like when its labels change
+	updated := claim.DeepCopy()
+	updated.Labels = map[string]string{"team": "storage"}
+	_, err = validator.ValidateUpdate(context.TODO(), claim, updated)
+	assert.NoError(t, err)
+
+	// the claim changes its spec
+	updated.Spec.NodeName = "worker-0"
+	_, err = validator.ValidateUpdate(context.TODO(), claim, updated)
+	assert.EqualError(t, err, `WWN "0x5000c500155a3457" is used by local-storage on node "worker-0"`)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DiskPreparePhase is the outcome of a DiskPrepareRequest
+type DiskPreparePhase string
+
+const (
+	// DiskPreparePending is a request that the diskmaker of the node has not handled yet
+	DiskPreparePending DiskPreparePhase = ""
+	// DiskPrepareInProgress is a request claimed by a diskmaker of the node, which is wiping its device
+	DiskPrepareInProgress DiskPreparePhase = "InProgress"
+	// DiskPrepareSucceeded is a request whose device has been wiped
+	DiskPrepareSucceeded DiskPreparePhase = "Succeeded"
+	// DiskPrepareFailed is a request whose device could not be found or wiped
+	DiskPrepareFailed DiskPreparePhase = "Failed"
+	// DiskPrepareRejected is a request whose device is not safe to wipe
+	DiskPrepareRejected DiskPreparePhase = "Rejected"
+)
+
+// DiskPrepareRequestSpec defines the desired state of DiskPrepareRequest
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type DiskPrepareRequestSpec struct {
+	// NodeName is the name of the node the device is attached to
+	// +kubebuilder:validation:MinLength=1
+	NodeName string `json:"nodeName"`
+	// WWN of the device to wipe, as reported in the LocalVolumeDiscoveryResult of the node
+	// +kubebuilder:validation:MinLength=1
+	WWN string `json:"WWN"`
+	// ConfirmWipe must be true to acknowledge that all the data on the device is destroyed
+	// +kubebuilder:validation:XValidation:rule="self == true",message="confirmWipe must be true"
+	ConfirmWipe bool `json:"confirmWipe"`
+}
+
+// DiskPrepareClaim identifies the diskmaker that handles a DiskPrepareRequest
+type DiskPrepareClaim struct {
+	// NodeName is the node of the diskmaker
+	NodeName string `json:"nodeName"`
+	// PodName is the pod of the diskmaker
+	PodName string `json:"podName"`
+	// ClaimTime is the time the diskmaker claimed the request
+	ClaimTime metav1.Time `json:"claimTime"`
+}
+
+// DiskPrepareRequestStatus defines the observed state of DiskPrepareRequest
+type DiskPrepareRequestStatus struct {
+	// Phase is the outcome of the request, empty until the diskmaker of the node handles it
+	// +optional
+	Phase DiskPreparePhase `json:"phase,omitempty"`
+	// Message explains the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ClaimedBy is the diskmaker that handles the request. Only the diskmaker that claimed the request
+	// wipes its device.
+	// +optional
+	ClaimedBy *DiskPrepareClaim `json:"claimedBy,omitempty"`
+	// DevicePath is the path of the device that was found on the node. For eg, /dev/sdb
+	// +optional
+	DevicePath string `json:"devicePath,omitempty"`
+	// WipedSignatures lists the signatures that were erased from the device and its partitions
+	// +optional
+	WipedSignatures []string `json:"wipedSignatures,omitempty"`
+	// CompletionTime is the time the request was handled
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=dpr
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="WWN",type=string,JSONPath=`.spec.WWN`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DiskPrepareRequest is the Schema for the diskpreparerequests API.
+// It asks the diskmaker of a node to wipe the signatures of a device that is not available,
+// so that the discovery reports it as available again.
+type DiskPrepareRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DiskPrepareRequestSpec   `json:"spec,omitempty"`
+	Status DiskPrepareRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DiskPrepareRequestList contains a list of DiskPrepareRequest
+type DiskPrepareRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DiskPrepareRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DiskPrepareRequest{}, &DiskPrepareRequestList{})
+}
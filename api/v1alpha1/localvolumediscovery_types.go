@@ -20,6 +20,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DiscoveryPhase defines the observed phase of the discovery process
@@ -56,6 +57,61 @@ type LocalVolumeDiscoverySpec struct {
 	// LocalVolumeDiscovery Daemon
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// ProbeInterval is the time between two full discoveries of the devices on a node.
+	// Defaults to 5m
+	// +optional
+	ProbeInterval *metav1.Duration `json:"probeInterval,omitempty"`
+	// UdevEventPeriod is the time udev events are collapsed for before they trigger a discovery.
+	// Defaults to 5s
+	// +optional
+	UdevEventPeriod *metav1.Duration `json:"udevEventPeriod,omitempty"`
+	// UdevMonitoringEnabled controls whether udev block events trigger a discovery.
+	// When disabled, devices are only discovered every ProbeInterval. Defaults to true
+	// +optional
+	UdevMonitoringEnabled *bool `json:"udevMonitoringEnabled,omitempty"`
+	// UdevExclusionFilter is the list of regular expressions for udev events that must not trigger a discovery.
+	// Defaults to device mapper, rbd and nbd devices
+	// +optional
+	UdevExclusionFilter []string `json:"udevExclusionFilter,omitempty"`
+	// ResultTTL is the time after which the LocalVolumeDiscoveryResult of a node is deleted when its
+	// discovery daemon stopped refreshing it. A TTL that doesn't exceed 3 times the ProbeInterval is ignored.
+	// Defaults to 12 times the ProbeInterval, 1h with the default ProbeInterval
+	// +optional
+	ResultTTL *metav1.Duration `json:"resultTTL,omitempty"`
+	// Resources of the discovery daemon container.
+	// Defaults to requests of 50Mi of memory and 10m of cpu, without limits
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// PriorityClassName of the discovery daemons.
+	// Defaults to the PRIORITY_CLASS_NAME environment variable of the operator
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// ImagePullPolicy of the discovery daemon image. Defaults to Always
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// MaxUnavailable is the number or percentage of discovery daemons that can be unavailable
+	// during an update of the daemonset. Defaults to 10%
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// Annotations are added to the discovery daemon pods
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// StaleDiscoveryResult is a LocalVolumeDiscoveryResult that is not refreshed by its discovery daemon,
+// or that could not be cleaned up
+type StaleDiscoveryResult struct {
+	// Name of the LocalVolumeDiscoveryResult
+	Name string `json:"name"`
+	// NodeName of the LocalVolumeDiscoveryResult
+	NodeName string `json:"nodeName,omitempty"`
+	// DiscoveredTimeStamp is the last time the discovery daemon refreshed the result
+	// +optional
+	DiscoveredTimeStamp string `json:"discoveredTimeStamp,omitempty"`
+	// Reason the result is stale
+	Reason string `json:"reason"`
 }
 
 // LocalVolumeDiscoveryStatus defines the observed state of LocalVolumeDiscovery
@@ -69,11 +125,37 @@ type LocalVolumeDiscoveryStatus struct {
 	// observedGeneration is the last generation change the operator has dealt with
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// StaleResults are the LocalVolumeDiscoveryResults that were not refreshed for several probe intervals,
+	// or that could not be cleaned up
+	// +optional
+	StaleResults []StaleDiscoveryResult `json:"staleResults,omitempty"`
+	// Summary counts the distinct devices discovered on all the nodes. A device seen by several nodes
+	// through the same WWN is only counted once
+	// +optional
+	Summary *DiscoverySummary `json:"summary,omitempty"`
+	// Nodes shows the summary of the devices of each node
+	// +optional
+	Nodes []NodeDiscoverySummary `json:"nodes,omitempty"`
+}
+
+// NodeDiscoverySummary shows the devices discovered on a node
+type NodeDiscoverySummary struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// DiscoveredTimeStamp is the last time the devices of the node were discovered
+	// +optional
+	DiscoveredTimeStamp string `json:"discoveredTimeStamp,omitempty"`
+	// Summary counts the devices of the node
+	Summary DiscoverySummary `json:"summary"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=localvolumediscoveries,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Devices",type=integer,JSONPath=`.status.summary.totalDevices`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.summary.availableDevices`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // LocalVolumeDiscovery is the Schema for the localvolumediscoveries API
 type LocalVolumeDiscovery struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -30,12 +30,37 @@ const (
 	NotAvailable DeviceState = "NotAvailable"
 	// Unknown means that the state of the device can't be determined
 	Unknown DeviceState = "Unknown"
+	// Missing means that the device was discovered before but is no longer present on the node
+	Missing DeviceState = "Missing"
 )
 
+// StorageScaleOwner is the owner reported for devices that carry a Storage Scale NSD descriptor or a GPFS partition
+const StorageScaleOwner = "storage-scale"
+
+// LocalStorageOwner is the owner reported for devices that the Local Storage Operator links under /mnt/local-storage
+const LocalStorageOwner = "local-storage"
+
 // DeviceStatus defines the observed state of the discovered devices
 type DeviceStatus struct {
 	// State shows the availability of the device
 	State DeviceState `json:"state"`
+	// LastTransitionTime is the last time the state of the device changed
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reasons lists the filters that made the device not available or unknown, each as
+	// "<filter>: <detail>". For eg, "canOpenExclusively: device is in use"
+	// +optional
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// IgnoredDevice is a block device that is skipped by the discovery
+type IgnoredDevice struct {
+	// Name of the block device. For eg, sda
+	Name string `json:"name"`
+	// Filter that rejected the device. For eg, hasWWN
+	Filter string `json:"filter"`
+	// Reason why the device is ignored
+	Reason string `json:"reason"`
 }
 
 // DiscoveredDevice shows the list of discovered devices with their properties
@@ -62,12 +87,236 @@ type DiscoveredDevice struct {
 	Status DeviceStatus `json:"status"`
 	// WWN defines the WWN value of the device. For multipath devices, this is mandatory
 	WWN string `json:"WWN"`
+	// FirstSeen is the first time the device was discovered on the node
+	// +optional
+	FirstSeen *metav1.Time `json:"firstSeen,omitempty"`
+	// LastSeen is the last time the device was discovered on the node
+	// +optional
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+	// OwnedBy names the storage software that already uses the device. For eg, storage-scale or local-storage
+	// +optional
+	OwnedBy string `json:"ownedBy,omitempty"`
+	// Multipath shows the member paths of the device. Only set for multipath devices
+	// +optional
+	Multipath *MultipathTopology `json:"multipath,omitempty"`
+	// Slot is the persistent name of the physical slot the device is plugged in. For eg, /dev/disk/by-path/...
+	// +optional
+	Slot string `json:"slot,omitempty"`
+	// Replaced identifies the device that was in the same slot before this one
+	// +optional
+	Replaced *ReplacedDevice `json:"replaced,omitempty"`
+}
+
+// ReplacedDevice shows the identity of a device that was swapped for another one in the same slot
+type ReplacedDevice struct {
+	// DeviceID of the replaced device
+	// +optional
+	DeviceID string `json:"deviceID,omitempty"`
+	// WWN of the replaced device
+	// +optional
+	WWN string `json:"WWN,omitempty"`
+	// Serial number of the replaced device
+	// +optional
+	Serial string `json:"serial,omitempty"`
+	// OwnedBy names the storage software that used the replaced device
+	// +optional
+	OwnedBy string `json:"ownedBy,omitempty"`
+	// ReplacedAt is the time the replacement was discovered
+	ReplacedAt metav1.Time `json:"replacedAt"`
+}
+
+// MultipathPathState defines the device-mapper state of a single path
+type MultipathPathState string
+
+const (
+	// PathActive means that the path is healthy and belongs to the active path group
+	PathActive MultipathPathState = "active"
+	// PathFailed means that the path has been failed by device-mapper
+	PathFailed MultipathPathState = "failed"
+	// PathGhost means that the path is healthy but belongs to a standby path group
+	PathGhost MultipathPathState = "ghost"
+)
+
+// MultipathPath shows one of the paths backing a multipath device
+type MultipathPath struct {
+	// Name is the kernel name of the path device. For eg, sdc
+	Name string `json:"name"`
+	// HCTL is the SCSI Host:Channel:Target:LUN address of the path
+	// +optional
+	HCTL string `json:"hctl,omitempty"`
+	// HostAdapter is the SCSI host the path goes through. For eg, host7
+	// +optional
+	HostAdapter string `json:"hostAdapter,omitempty"`
+	// State of the path
+	State MultipathPathState `json:"state"`
+}
+
+// MultipathTopology shows how a multipath device is reached
+type MultipathTopology struct {
+	// PathGroupPolicy is the path grouping policy inferred from the device-mapper table: failover, multibus or group_by_prio
+	// +optional
+	PathGroupPolicy string `json:"pathGroupPolicy,omitempty"`
+	// PathSelector is the path selector of the path groups. For eg, service-time
+	// +optional
+	PathSelector string `json:"pathSelector,omitempty"`
+	// Paths lists the member paths of the device
+	// +optional
+	Paths []MultipathPath `json:"paths,omitempty"`
+	// HealthyPaths is the number of paths that are not failed
+	HealthyPaths int `json:"healthyPaths"`
+	// ExpectedPaths is the highest number of paths seen for the device by the discovery on the node,
+	// including before the restarts of the discovery daemon
+	ExpectedPaths int `json:"expectedPaths"`
+	// Degraded is true when fewer paths than expected are healthy
+	Degraded bool `json:"degraded"`
+}
+
+// NodeTopology shows the failure domain and the architecture of a node, copied from the node
+type NodeTopology struct {
+	// Region of the node, from the topology.kubernetes.io/region label
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Zone of the node, from the topology.kubernetes.io/zone label
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Rack of the node, from the purple.purplestorage.com/rack label
+	// +optional
+	Rack string `json:"rack,omitempty"`
+	// Architecture of the node. For eg, amd64
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// FibreChannelPort shows a Fibre Channel host adapter port of a node
+type FibreChannelPort struct {
+	// Host is the SCSI host of the port. For eg, host7
+	Host string `json:"host"`
+	// WWPN is the World Wide Port Name of the port. For eg, 0x10000090fa5b1c2d
+	WWPN string `json:"WWPN"`
+	// WWNN is the World Wide Node Name of the adapter
+	// +optional
+	WWNN string `json:"WWNN,omitempty"`
+	// State of the port. For eg, Online
+	// +optional
+	State string `json:"state,omitempty"`
+	// Speed of the port. For eg, 16 Gbit
+	// +optional
+	Speed string `json:"speed,omitempty"`
+}
+
+// NodeInitiators shows the SAN initiator identities of a node
+type NodeInitiators struct {
+	// FibreChannel lists the Fibre Channel host adapter ports of the node
+	// +optional
+	FibreChannel []FibreChannelPort `json:"fibreChannel,omitempty"`
+	// ISCSIInitiatorName is the iSCSI qualified name of the node. For eg, iqn.1994-05.com.redhat:5e6f7a8b9c0d
+	// +optional
+	ISCSIInitiatorName string `json:"iscsiInitiatorName,omitempty"`
 }
 
 // LocalVolumeDiscoveryResultSpec defines the desired state of LocalVolumeDiscoveryResult
 type LocalVolumeDiscoveryResultSpec struct {
 	// Node on which the devices are discovered
 	NodeName string `json:"nodeName"`
+	// Topology of the node on which the devices are discovered
+	// +optional
+	Topology *NodeTopology `json:"topology,omitempty"`
+}
+
+// DiscoverySummary shows the device counts and capacity of a node
+type DiscoverySummary struct {
+	// TotalDevices is the number of discovered devices
+	TotalDevices int `json:"totalDevices"`
+	// AvailableDevices is the number of devices in the Available state
+	AvailableDevices int `json:"availableDevices"`
+	// NotAvailableDevices is the number of devices in the NotAvailable state
+	NotAvailableDevices int `json:"notAvailableDevices"`
+	// UnknownDevices is the number of devices in the Unknown state
+	UnknownDevices int `json:"unknownDevices"`
+	// MissingDevices is the number of devices in the Missing state
+	MissingDevices int `json:"missingDevices"`
+	// TotalCapacity is the sum of the sizes of the discovered devices that are still present, in bytes
+	TotalCapacity int64 `json:"totalCapacity"`
+	// AvailableCapacity is the sum of the sizes of the Available devices, in bytes
+	AvailableCapacity int64 `json:"availableCapacity"`
+	// DiskDevices is the number of devices of the disk type
+	// +optional
+	DiskDevices int `json:"diskDevices,omitempty"`
+	// MultipathDevices is the number of devices of the mpath type
+	// +optional
+	MultipathDevices int `json:"multipathDevices,omitempty"`
+	// RotationalDevices is the number of magnetic disks
+	// +optional
+	RotationalDevices int `json:"rotationalDevices,omitempty"`
+	// RotationalCapacity is the sum of the sizes of the magnetic disks that are still present, in bytes
+	// +optional
+	RotationalCapacity int64 `json:"rotationalCapacity,omitempty"`
+	// NonRotationalDevices is the number of solid state devices
+	// +optional
+	NonRotationalDevices int `json:"nonRotationalDevices,omitempty"`
+	// NonRotationalCapacity is the sum of the sizes of the solid state devices that are still present, in bytes
+	// +optional
+	NonRotationalCapacity int64 `json:"nonRotationalCapacity,omitempty"`
+}
+
+// AddDevice counts a device in the summary. A Missing device is counted but its size is not
+// part of the capacities, the device is gone.
+func (s *DiscoverySummary) AddDevice(device DiscoveredDevice) {
+	size := device.Size
+	if device.Status.State == Missing {
+		size = 0
+	}
+	s.TotalDevices++
+	s.TotalCapacity += size
+	switch device.Status.State {
+	case Available:
+		s.AvailableDevices++
+		s.AvailableCapacity += device.Size
+	case NotAvailable:
+		s.NotAvailableDevices++
+	case Missing:
+		s.MissingDevices++
+	default:
+		s.UnknownDevices++
+	}
+	switch device.Type {
+	case DiskType:
+		s.DiskDevices++
+	case MultiPathType:
+		s.MultipathDevices++
+	}
+	switch device.Property {
+	case Rotational:
+		s.RotationalDevices++
+		s.RotationalCapacity += size
+	case NonRotational:
+		s.NonRotationalDevices++
+		s.NonRotationalCapacity += size
+	}
+}
+
+// UniqueDevicesByWWN returns the devices of a node with a single device per WWN. The paths of a
+// multipath device share its WWN, they are replaced by the multipath device when it is discovered
+// and by their first path otherwise. Devices without a WWN are all kept.
+func UniqueDevicesByWWN(devices []DiscoveredDevice) []DiscoveredDevice {
+	unique := []DiscoveredDevice{}
+	byWWN := map[string]int{}
+	for _, device := range devices {
+		if device.WWN == "" {
+			unique = append(unique, device)
+			continue
+		}
+		i, ok := byWWN[device.WWN]
+		if !ok {
+			byWWN[device.WWN] = len(unique)
+			unique = append(unique, device)
+			continue
+		}
+		if device.Type == MultiPathType && unique[i].Type != MultiPathType {
+			unique[i] = device
+		}
+	}
+	return unique
 }
 
 // LocalVolumeDiscoveryResultStatus defines the observed state of LocalVolumeDiscoveryResult
@@ -83,8 +332,25 @@ type LocalVolumeDiscoveryResultStatus struct {
 	// - it should not be a boot device
 	// - it should not have child partitions
 	// - it should have a WWN value
+	// When a node has more devices than fit in a single result, the list is split and
+	// the remaining devices are stored in the results named in Shards
 	// +optional
 	DiscoveredDevices []DiscoveredDevice `json:"discoveredDevices"`
+	// Summary shows the counts and capacity of all the devices of the node, including the ones in shards.
+	// It is only set on the primary result of a node
+	// +optional
+	Summary *DiscoverySummary `json:"summary,omitempty"`
+	// Shards lists the names of the additional results holding the devices of the node
+	// +optional
+	Shards []string `json:"shards,omitempty"`
+	// IgnoredDevices lists the block devices of the node that are not discovered and why.
+	// It is only set on the primary result of a node
+	// +optional
+	IgnoredDevices []IgnoredDevice `json:"ignoredDevices,omitempty"`
+	// Initiators shows the SAN initiator identities of the node, to zone LUNs to it.
+	// It is only set on the primary result of a node
+	// +optional
+	Initiators *NodeInitiators `json:"initiators,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NetworkCheckPhase is the progress of a NetworkCheck
+type NetworkCheckPhase string
+
+const (
+	// NetworkCheckRunning is a check whose probes have not all completed
+	NetworkCheckRunning NetworkCheckPhase = "Running"
+	// NetworkCheckPassed is a check whose nodes all reach each other on all the ports
+	NetworkCheckPassed NetworkCheckPhase = "Passed"
+	// NetworkCheckFailed is a check with a node that can't reach another one, or that timed out
+	NetworkCheckFailed NetworkCheckPhase = "Failed"
+)
+
+// NetworkProbeResult is the outcome of the probes of a node to another one
+type NetworkProbeResult string
+
+const (
+	// NetworkProbePassed is a target reached on all the ports
+	NetworkProbePassed NetworkProbeResult = "Passed"
+	// NetworkProbeFailed is a target not reached on some ports
+	NetworkProbeFailed NetworkProbeResult = "Failed"
+)
+
+// NetworkCheckSpec defines the desired state of NetworkCheck
+type NetworkCheckSpec struct {
+	// Nodes whose connectivity must be checked, all the nodes when empty
+	// +optional
+	NodeSelector *corev1.NodeSelector `json:"nodeSelector,omitempty"`
+	// If specified, a list of tolerations to pass to the check daemons.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Timeout is the time the probes have to complete before the check fails, 10m by default
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// NetworkCheckPeer is a node of the check
+type NetworkCheckPeer struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Address is the host IP the daemon of the node listens on
+	// +optional
+	Address string `json:"address,omitempty"`
+	// Ready is true once the daemon of the node listens on all the ports
+	Ready bool `json:"ready"`
+}
+
+// NetworkProbe is the outcome of the probes of a source node to a target node, a cell of the matrix
+type NetworkProbe struct {
+	// Source is the name of the node the probes are sent from
+	Source string `json:"source"`
+	// Target is the name of the node the probes are sent to
+	Target string `json:"target"`
+	// Result of the probes
+	// +kubebuilder:validation:Enum=Passed;Failed
+	Result NetworkProbeResult `json:"result"`
+	// FailedPorts lists the ports and port ranges that could not be reached
+	// +optional
+	FailedPorts []string `json:"failedPorts,omitempty"`
+	// Message explains the result
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ProbeTime is the time of the probes
+	ProbeTime metav1.Time `json:"probeTime"`
+}
+
+// NetworkCheckStatus defines the observed state of NetworkCheck
+type NetworkCheckStatus struct {
+	// Phase is the progress of the check
+	// +optional
+	Phase NetworkCheckPhase `json:"phase,omitempty"`
+	// Message summarizes the outcome of the check
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Peers are the nodes of the check
+	// +optional
+	Peers []NetworkCheckPeer `json:"peers,omitempty"`
+	// Probes is the pass/fail matrix, with one entry for each source and target node
+	// +optional
+	// +listType=map
+	// +listMapKey=source
+	// +listMapKey=target
+	Probes []NetworkProbe `json:"probes,omitempty"`
+	// FailedProbes is the number of source and target nodes that don't reach each other
+	// +optional
+	FailedProbes int `json:"failedProbes,omitempty"`
+	// CompletionTime is the time the check passed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=nc
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failedProbes`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NetworkCheck is the Schema for the networkchecks API.
+// It runs a daemon on each selected node that listens on the ports of the Storage Scale daemons
+// and probes the other nodes on them. The daemons are removed once the check completes, delete
+// the NetworkCheck to run it again.
+type NetworkCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkCheckSpec   `json:"spec,omitempty"`
+	Status NetworkCheckStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NetworkCheckList contains a list of NetworkCheck
+type NetworkCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NetworkCheck `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NetworkCheck{}, &NetworkCheckList{})
+}
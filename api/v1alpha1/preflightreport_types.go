@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreflightPhase is the outcome of the preflight checks of a node
+type PreflightPhase string
+
+const (
+	// PreflightPending is a report whose checks have not run yet
+	PreflightPending PreflightPhase = ""
+	// PreflightPassed is a report without failed check
+	PreflightPassed PreflightPhase = "Passed"
+	// PreflightFailed is a report with at least one failed check
+	PreflightFailed PreflightPhase = "Failed"
+)
+
+// PreflightCheckResult is the outcome of a single preflight check
+type PreflightCheckResult string
+
+const (
+	// PreflightCheckPassed is a check whose requirement is met
+	PreflightCheckPassed PreflightCheckResult = "Passed"
+	// PreflightCheckWarning is a check that could not be verified or whose requirement is recommended only
+	PreflightCheckWarning PreflightCheckResult = "Warning"
+	// PreflightCheckFailed is a check whose requirement is not met
+	PreflightCheckFailed PreflightCheckResult = "Failed"
+)
+
+// PreflightReportSpec defines the desired state of PreflightReport
+type PreflightReportSpec struct {
+	// NodeName is the name of the node the checks run on
+	// +kubebuilder:validation:MinLength=1
+	NodeName string `json:"nodeName"`
+	// IbmCnsaVersion is the Storage Scale Container Native release the node is checked against
+	// +kubebuilder:validation:MinLength=1
+	IbmCnsaVersion string `json:"ibmCnsaVersion"`
+	// PagepoolSize is the memory that must be available on the node for the pagepool
+	// +optional
+	PagepoolSize *resource.Quantity `json:"pagepoolSize,omitempty"`
+}
+
+// PreflightCheck is the outcome of a single check on the node
+type PreflightCheck struct {
+	// Name of the check. For eg, kernel or ports
+	Name string `json:"name"`
+	// Result of the check
+	// +kubebuilder:validation:Enum=Passed;Warning;Failed
+	Result PreflightCheckResult `json:"result"`
+	// Message explains the result
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PreflightReportStatus defines the observed state of PreflightReport
+type PreflightReportStatus struct {
+	// Phase is Failed when any check failed, empty until the checks have run
+	// +optional
+	Phase PreflightPhase `json:"phase,omitempty"`
+	// Checks lists the outcome of each check
+	// +optional
+	Checks []PreflightCheck `json:"checks,omitempty"`
+	// CompletionTime is the time the checks completed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=pfr
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.ibmCnsaVersion`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PreflightReport is the Schema for the preflightreports API.
+// It holds the outcome of the checks that a node must pass before Storage Scale is installed on it.
+type PreflightReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreflightReportSpec   `json:"spec,omitempty"`
+	Status PreflightReportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PreflightReportList contains a list of PreflightReport
+type PreflightReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreflightReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreflightReport{}, &PreflightReportList{})
+}
@@ -17,11 +17,19 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strconv"
+
 	operatorv1 "github.com/openshift/api/operator/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1helper "k8s.io/component-helpers/scheduling/corev1"
 )
 
+// AllowUnsupportedAnnotation set to "true" allows a PurpleStorage outside of the support matrix of its IBM CNSA version
+const AllowUnsupportedAnnotation = "purple.purplestorage.com/allow-unsupported"
+
 // PurpleStorageSpec defines the desired state of PurpleStorage
 type PurpleStorageSpec struct {
 	// MachineConfig labeling for the installation of kernel-devel package
@@ -35,9 +43,36 @@ type PurpleStorageSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=3
 	Cluster IBMSpectrumCluster `json:"ibm_cnsa_cluster,omitempty"`
 
-	// Inherited from LVSet to provide control over node selector and device filtering capabilities
+	// Inherited from LVSet to provide control over node selector and device filtering capabilities.
+	// It is used for the auto-discover-devices LocalVolumeDiscovery and, unless daemon_nodeSelector is set,
+	// for the nodeSelector of the IBM daemons
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=4
 	NodeSpec NodeSpec `json:"node_spec,omitempty"`
+
+	// Checks run on the nodes of the daemons before the CNSA cluster object is created
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=8
+	Preflight PreflightSpec `json:"preflight,omitempty"`
+
+	// Integration with the cloud provider of the cluster
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=11
+	CloudIntegration CloudIntegration `json:"cloud_integration,omitempty"`
+}
+
+type CloudIntegration struct {
+	// Boolean to open the Storage Scale ports between the nodes of the daemons in the security groups of the cloud provider.
+	// The credentials are requested from the Cloud Credential Operator
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=12,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	OpenPorts bool `json:"open_ports,omitempty"`
+}
+
+type PreflightSpec struct {
+	// Boolean to create the CNSA cluster object without running the checks on the nodes
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=9,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	Skip bool `json:"skip,omitempty"`
+	// Memory that must be available on each node for the pagepool of the daemons
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=10
+	// +kubebuilder:default:="1Gi"
+	PagepoolSize *resource.Quantity `json:"pagepoolSize,omitempty"`
 }
 
 type NodeSpec struct {
@@ -65,7 +100,8 @@ type IBMSpectrumCluster struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=6,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
 	// +kubebuilder:default:=true
 	Create bool `json:"create,omitempty"`
-	// Nodes with this label will be part of the cluster, must have at least 3 nodes with this
+	// Nodes with this label will be part of the cluster, must have at least 3 nodes with this.
+	// Defaults to the selector of node_spec when it only uses the In operator with a single value
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,order=7,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:fieldDependency:ibm_cnsa_cluster.create:true"}
 	Daemon_nodeSelector map[string]string `json:"daemon_nodeSelector,omitempty"`
 }
@@ -95,6 +131,24 @@ type PurpleStorage struct {
 	Status PurpleStorageStatus `json:"status,omitempty"`
 }
 
+// IsUnsupportedAllowed returns true if the PurpleStorage carries the AllowUnsupportedAnnotation
+func (p *PurpleStorage) IsUnsupportedAllowed() bool {
+	allowed, err := strconv.ParseBool(p.Annotations[AllowUnsupportedAnnotation])
+	return err == nil && allowed
+}
+
+// IsDaemonNode returns true if the IBM daemons run on the node: it matches daemon_nodeSelector when
+// set, otherwise the selector of the NodeSpec. All nodes match when neither is set.
+func (p *PurpleStorage) IsDaemonNode(node *corev1.Node) (bool, error) {
+	if len(p.Spec.Cluster.Daemon_nodeSelector) > 0 {
+		return labels.SelectorFromSet(p.Spec.Cluster.Daemon_nodeSelector).Matches(labels.Set(node.Labels)), nil
+	}
+	if p.Spec.NodeSpec.Selector == nil || len(p.Spec.NodeSpec.Selector.NodeSelectorTerms) == 0 {
+		return true, nil
+	}
+	return v1helper.MatchNodeSelectorTerms(node, p.Spec.NodeSpec.Selector)
+}
+
 //+kubebuilder:object:root=true
 
 // PurpleStorageList contains a list of PurpleStorage
@@ -19,11 +19,15 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	configclient "github.com/openshift/client-go/config/clientset/versioned"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -86,24 +90,13 @@ func (r *PurpleStorageValidator) ValidateCreate(ctx context.Context, obj runtime
 		return nil, fmt.Errorf("only one PurpleStorage resource is allowed")
 	}
 
-	clusterVersions, err := r.configClient.ConfigV1().ClusterVersions().Get(context.Background(), "version", metav1.GetOptions{})
+	// Check the support matrix of the IBM CNSA version we are running
+	issues, err := r.getSupportIssues(ctx, p)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list ClusterVersions: %v", err)
-	}
-
-	// Check if the IBM version we are running is an allowed one
-	ocpVersion, err := utils.GetCurrentClusterVersion(clusterVersions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current cluster version: %v", err)
-	}
-	if !utils.IsOpenShiftSupported(p.Spec.IbmCnsaVersion, *ocpVersion) {
-		// FIXME(bandini): we currently only log this so QE can test on upcoming versions that are not yet supported by IBM
-		purplestoragelog.Info("IBM CNSA version not supported", "OCP Version", ocpVersion, "IBM CNSA Version", p.Spec.IbmCnsaVersion)
-		// FIXME(bandini): return nil, fmt.Errorf("IBM CNSA version %s is not supported", ocpVersion)
-	} else {
-		purplestoragelog.Info("validate create", "name", p.Name, "OCP Version", ocpVersion, "IBM CNSA Version", p.Spec.IbmCnsaVersion)
+		return nil, err
 	}
-	return nil, nil
+	purplestoragelog.Info("validate create", "name", p.Name, "IBM CNSA Version", p.Spec.IbmCnsaVersion, "issues", issues)
+	return validateSupport(p, issues)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -140,6 +133,75 @@ func (r *PurpleStorageValidator) ValidateDelete(_ context.Context, obj runtime.O
 	return nil, nil
 }
 
+// getSupportIssues returns why the PurpleStorage is outside of the support matrix of its IBM CNSA version,
+// given the OpenShift version of the cluster and the architecture of the nodes of the daemons
+func (r *PurpleStorageValidator) getSupportIssues(ctx context.Context, p *PurpleStorage) ([]string, error) {
+	clusterVersions, err := r.configClient.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterVersions: %v", err)
+	}
+	ocpVersion, err := utils.GetCurrentClusterVersion(clusterVersions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current cluster version: %v", err)
+	}
+
+	// the support matrix can be overridden in the namespace of the PurpleStorage
+	configMap := &corev1.ConfigMap{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: utils.SupportMatrixConfigMap, Namespace: p.Namespace}, configMap)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %v", utils.SupportMatrixConfigMap, err)
+		}
+		configMap = nil
+	}
+	matrix, err := utils.GetSupportMatrix(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes corev1.NodeList
+	if err = r.Client.List(ctx, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to list Node resources: %v", err)
+	}
+	architectures, err := GetDaemonNodeArchitectures(p, nodes.Items)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetSupportIssues(matrix, p.Spec.IbmCnsaVersion, ocpVersion, architectures), nil
+}
+
+// GetDaemonNodeArchitectures returns the architecture of each node of the daemons of the PurpleStorage
+func GetDaemonNodeArchitectures(p *PurpleStorage, nodes []corev1.Node) (map[string]string, error) {
+	architectures := map[string]string{}
+	for i := range nodes {
+		matches, err := p.IsDaemonNode(&nodes[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to match node %s: %v", nodes[i].Name, err)
+		}
+		if matches {
+			architectures[nodes[i].Name] = nodes[i].Status.NodeInfo.Architecture
+		}
+	}
+	return architectures, nil
+}
+
+// validateSupport rejects a PurpleStorage outside of the support matrix unless it carries the
+// AllowUnsupportedAnnotation, in which case the issues are returned as warnings
+func validateSupport(p *PurpleStorage, issues []string) (admission.Warnings, error) {
+	if len(issues) == 0 {
+		return nil, nil
+	}
+	if !p.IsUnsupportedAllowed() {
+		return nil, fmt.Errorf("unsupported configuration: %s; set the %s: \"true\" annotation to allow it",
+			strings.Join(issues, "; "), AllowUnsupportedAnnotation)
+	}
+	warnings := admission.Warnings{}
+	for _, issue := range issues {
+		warnings = append(warnings, fmt.Sprintf("allowed by the %s annotation: %s", AllowUnsupportedAnnotation, issue))
+	}
+	return warnings, nil
+}
+
 func convertToPurpleStorage(obj runtime.Object) (*PurpleStorage, error) {
 	p, ok := obj.(*PurpleStorage)
 	if !ok {
@@ -17,7 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"testing"
+
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("PurpleStorage Webhook", func() {
@@ -45,3 +50,93 @@ var _ = Describe("PurpleStorage Webhook", func() {
 	})
 
 })
+
+func TestValidateSupport(t *testing.T) {
+	issues := []string{"IBM CNSA version v5.2.2.0 does not support OpenShift 4.18.1, only 4.15, 4.16, 4.17"}
+
+	testcases := []struct {
+		label            string
+		annotations      map[string]string
+		issues           []string
+		expectedWarnings []string
+		expectedErr      string
+	}{
+		{
+			label: "case 1", // supported
+		},
+		{
+			label:       "case 2", // unsupported without annotation
+			issues:      issues,
+			expectedErr: `unsupported configuration: ` + issues[0] + `; set the purple.purplestorage.com/allow-unsupported: "true" annotation to allow it`,
+		},
+		{
+			label:       "case 3", // the annotation must be true
+			annotations: map[string]string{AllowUnsupportedAnnotation: "no"},
+			issues:      issues,
+			expectedErr: `unsupported configuration: ` + issues[0] + `; set the purple.purplestorage.com/allow-unsupported: "true" annotation to allow it`,
+		},
+		{
+			label:            "case 4", // override
+			annotations:      map[string]string{AllowUnsupportedAnnotation: "true"},
+			issues:           issues,
+			expectedWarnings: []string{"allowed by the purple.purplestorage.com/allow-unsupported annotation: " + issues[0]},
+		},
+	}
+	for _, tc := range testcases {
+		p := &PurpleStorage{ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Annotations: tc.annotations}}
+		warnings, err := validateSupport(p, tc.issues)
+		if tc.expectedErr != "" {
+			assert.EqualErrorf(t, err, tc.expectedErr, tc.label)
+			continue
+		}
+		assert.NoErrorf(t, err, tc.label)
+		assert.Equalf(t, tc.expectedWarnings, []string(warnings), tc.label)
+	}
+}
+
+func TestGetDaemonNodeArchitectures(t *testing.T) {
+	newNode := func(name, architecture string, labels map[string]string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: architecture}},
+		}
+	}
+	nodes := []corev1.Node{
+		newNode("worker-0", "amd64", map[string]string{"storage": "true", "scale": "true"}),
+		newNode("worker-1", "arm64", map[string]string{"storage": "true"}),
+		newNode("master-0", "amd64", nil),
+	}
+
+	testcases := []struct {
+		label    string
+		spec     PurpleStorageSpec
+		expected map[string]string
+	}{
+		{
+			label:    "case 1", // all nodes without selector
+			expected: map[string]string{"worker-0": "amd64", "worker-1": "arm64", "master-0": "amd64"},
+		},
+		{
+			label: "case 2", // selector of the NodeSpec
+			spec: PurpleStorageSpec{NodeSpec: NodeSpec{Selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "storage", Operator: corev1.NodeSelectorOpExists}},
+			}}}}},
+			expected: map[string]string{"worker-0": "amd64", "worker-1": "arm64"},
+		},
+		{
+			label: "case 3", // daemon_nodeSelector takes precedence
+			spec: PurpleStorageSpec{
+				Cluster: IBMSpectrumCluster{Daemon_nodeSelector: map[string]string{"scale": "true"}},
+				NodeSpec: NodeSpec{Selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "storage", Operator: corev1.NodeSelectorOpExists}},
+				}}}},
+			},
+			expected: map[string]string{"worker-0": "amd64"},
+		},
+	}
+	for _, tc := range testcases {
+		architectures, err := GetDaemonNodeArchitectures(&PurpleStorage{Spec: tc.spec}, nodes)
+		assert.NoErrorf(t, err, tc.label)
+		assert.Equalf(t, tc.expected, architectures, tc.label)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SharedDeviceInventoryName is the name of the singleton SharedDeviceInventory computed by the operator
+const SharedDeviceInventoryName = "cluster"
+
+// SharedDeviceNode shows how a single node sees a device
+type SharedDeviceNode struct {
+	// NodeName is the name of the node that reported the device
+	NodeName string `json:"nodeName"`
+	// Path is the device path on the node. For eg, /dev/sdb
+	Path string `json:"path"`
+	// DeviceID is the persistent name of the device on the node. For eg, /dev/disk/by-id/...
+	DeviceID string `json:"deviceID"`
+	// Size of the device as seen by the node
+	Size int64 `json:"size"`
+	// State of the device on the node
+	State DeviceState `json:"state"`
+}
+
+// SharedDevice is a LUN identified by its WWN together with the nodes that see it
+type SharedDevice struct {
+	// WWN of the device
+	WWN string `json:"WWN"`
+	// Size of the device as reported by the first node
+	Size int64 `json:"size"`
+	// Nodes that see the device
+	Nodes []SharedDeviceNode `json:"nodes"`
+	// Shared is true when more than one node sees the device
+	Shared bool `json:"shared"`
+	// SizesMatch is true when all the nodes report the same size
+	SizesMatch bool `json:"sizesMatch"`
+	// PathsMatch is true when all the nodes report the same persistent device ID
+	PathsMatch bool `json:"pathsMatch"`
+	// Unavailable is true when at least one node reports the device as not available
+	Unavailable bool `json:"unavailable"`
+}
+
+// FailureDomain groups the devices of the nodes that share a region, zone and rack
+type FailureDomain struct {
+	// Region of the nodes
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Zone of the nodes
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Rack of the nodes
+	// +optional
+	Rack string `json:"rack,omitempty"`
+	// Nodes in the failure domain
+	Nodes []string `json:"nodes"`
+	// Architectures of the nodes in the failure domain
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+	// Summary counts the distinct devices seen by the nodes of the failure domain
+	Summary DiscoverySummary `json:"summary"`
+}
+
+// SharedDeviceInventorySpec defines the desired state of SharedDeviceInventory
+type SharedDeviceInventorySpec struct {
+}
+
+// SharedDeviceInventoryStatus defines the observed state of SharedDeviceInventory
+type SharedDeviceInventoryStatus struct {
+	// Devices lists every discovered LUN, sorted by WWN
+	// +optional
+	Devices []SharedDevice `json:"devices,omitempty"`
+	// TotalDevices is the number of distinct WWNs discovered in the cluster
+	TotalDevices int `json:"totalDevices"`
+	// SharedDevices is the number of devices seen by more than one node
+	SharedDevices int `json:"sharedDevices"`
+	// FailureDomains groups the devices by the topology of the nodes, sorted by region, zone and rack
+	// +optional
+	FailureDomains []FailureDomain `json:"failureDomains,omitempty"`
+	// LastUpdated is the last time the inventory changed
+	// +optional
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=shareddeviceinventories,scope=Cluster
+// +kubebuilder:printcolumn:name="Devices",type=integer,JSONPath=`.status.totalDevices`
+// +kubebuilder:printcolumn:name="Shared",type=integer,JSONPath=`.status.sharedDevices`
+
+// SharedDeviceInventory is the Schema for the shareddeviceinventories API.
+// It joins the LocalVolumeDiscoveryResults of all the nodes on the device WWN.
+type SharedDeviceInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SharedDeviceInventorySpec   `json:"spec,omitempty"`
+	Status SharedDeviceInventoryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SharedDeviceInventoryList contains a list of SharedDeviceInventory
+type SharedDeviceInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SharedDeviceInventory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SharedDeviceInventory{}, &SharedDeviceInventoryList{})
+}
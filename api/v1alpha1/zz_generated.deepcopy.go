@@ -23,12 +23,38 @@ package v1alpha1
 import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudIntegration) DeepCopyInto(out *CloudIntegration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudIntegration.
+func (in *CloudIntegration) DeepCopy() *CloudIntegration {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudIntegration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeviceStatus) DeepCopyInto(out *DeviceStatus) {
 	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceStatus.
@@ -44,7 +70,25 @@ func (in *DeviceStatus) DeepCopy() *DeviceStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiscoveredDevice) DeepCopyInto(out *DiscoveredDevice) {
 	*out = *in
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
+	if in.FirstSeen != nil {
+		in, out := &in.FirstSeen, &out.FirstSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.Multipath != nil {
+		in, out := &in.Multipath, &out.Multipath
+		*out = new(MultipathTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replaced != nil {
+		in, out := &in.Replaced, &out.Replaced
+		*out = new(ReplacedDevice)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveredDevice.
@@ -58,48 +102,41 @@ func (in *DiscoveredDevice) DeepCopy() *DiscoveredDevice {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *IBMSpectrumCluster) DeepCopyInto(out *IBMSpectrumCluster) {
+func (in *DiscoverySummary) DeepCopyInto(out *DiscoverySummary) {
 	*out = *in
-	if in.Daemon_nodeSelector != nil {
-		in, out := &in.Daemon_nodeSelector, &out.Daemon_nodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IBMSpectrumCluster.
-func (in *IBMSpectrumCluster) DeepCopy() *IBMSpectrumCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoverySummary.
+func (in *DiscoverySummary) DeepCopy() *DiscoverySummary {
 	if in == nil {
 		return nil
 	}
-	out := new(IBMSpectrumCluster)
+	out := new(DiscoverySummary)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscovery) DeepCopyInto(out *LocalVolumeDiscovery) {
+func (in *DiskClaim) DeepCopyInto(out *DiskClaim) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.Spec = in.Spec
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscovery.
-func (in *LocalVolumeDiscovery) DeepCopy() *LocalVolumeDiscovery {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskClaim.
+func (in *DiskClaim) DeepCopy() *DiskClaim {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscovery)
+	out := new(DiskClaim)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LocalVolumeDiscovery) DeepCopyObject() runtime.Object {
+func (in *DiskClaim) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -107,31 +144,31 @@ func (in *LocalVolumeDiscovery) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryList) DeepCopyInto(out *LocalVolumeDiscoveryList) {
+func (in *DiskClaimList) DeepCopyInto(out *DiskClaimList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]LocalVolumeDiscovery, len(*in))
+		*out = make([]DiskClaim, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryList.
-func (in *LocalVolumeDiscoveryList) DeepCopy() *LocalVolumeDiscoveryList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskClaimList.
+func (in *DiskClaimList) DeepCopy() *DiskClaimList {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryList)
+	out := new(DiskClaimList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LocalVolumeDiscoveryList) DeepCopyObject() runtime.Object {
+func (in *DiskClaimList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -139,7 +176,53 @@ func (in *LocalVolumeDiscoveryList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryResult) DeepCopyInto(out *LocalVolumeDiscoveryResult) {
+func (in *DiskClaimSpec) DeepCopyInto(out *DiskClaimSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskClaimSpec.
+func (in *DiskClaimSpec) DeepCopy() *DiskClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskClaimStatus) DeepCopyInto(out *DiskClaimStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskClaimStatus.
+func (in *DiskClaimStatus) DeepCopy() *DiskClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskPrepareClaim) DeepCopyInto(out *DiskPrepareClaim) {
+	*out = *in
+	in.ClaimTime.DeepCopyInto(&out.ClaimTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPrepareClaim.
+func (in *DiskPrepareClaim) DeepCopy() *DiskPrepareClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskPrepareClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskPrepareRequest) DeepCopyInto(out *DiskPrepareRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -147,18 +230,18 @@ func (in *LocalVolumeDiscoveryResult) DeepCopyInto(out *LocalVolumeDiscoveryResu
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResult.
-func (in *LocalVolumeDiscoveryResult) DeepCopy() *LocalVolumeDiscoveryResult {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPrepareRequest.
+func (in *DiskPrepareRequest) DeepCopy() *DiskPrepareRequest {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryResult)
+	out := new(DiskPrepareRequest)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LocalVolumeDiscoveryResult) DeepCopyObject() runtime.Object {
+func (in *DiskPrepareRequest) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -166,31 +249,31 @@ func (in *LocalVolumeDiscoveryResult) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryResultList) DeepCopyInto(out *LocalVolumeDiscoveryResultList) {
+func (in *DiskPrepareRequestList) DeepCopyInto(out *DiskPrepareRequestList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]LocalVolumeDiscoveryResult, len(*in))
+		*out = make([]DiskPrepareRequest, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultList.
-func (in *LocalVolumeDiscoveryResultList) DeepCopy() *LocalVolumeDiscoveryResultList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPrepareRequestList.
+func (in *DiskPrepareRequestList) DeepCopy() *DiskPrepareRequestList {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryResultList)
+	out := new(DiskPrepareRequestList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *LocalVolumeDiscoveryResultList) DeepCopyObject() runtime.Object {
+func (in *DiskPrepareRequestList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -198,94 +281,95 @@ func (in *LocalVolumeDiscoveryResultList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryResultSpec) DeepCopyInto(out *LocalVolumeDiscoveryResultSpec) {
+func (in *DiskPrepareRequestSpec) DeepCopyInto(out *DiskPrepareRequestSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultSpec.
-func (in *LocalVolumeDiscoveryResultSpec) DeepCopy() *LocalVolumeDiscoveryResultSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPrepareRequestSpec.
+func (in *DiskPrepareRequestSpec) DeepCopy() *DiskPrepareRequestSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryResultSpec)
+	out := new(DiskPrepareRequestSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryResultStatus) DeepCopyInto(out *LocalVolumeDiscoveryResultStatus) {
+func (in *DiskPrepareRequestStatus) DeepCopyInto(out *DiskPrepareRequestStatus) {
 	*out = *in
-	if in.DiscoveredDevices != nil {
-		in, out := &in.DiscoveredDevices, &out.DiscoveredDevices
-		*out = make([]DiscoveredDevice, len(*in))
+	if in.ClaimedBy != nil {
+		in, out := &in.ClaimedBy, &out.ClaimedBy
+		*out = new(DiskPrepareClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WipedSignatures != nil {
+		in, out := &in.WipedSignatures, &out.WipedSignatures
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultStatus.
-func (in *LocalVolumeDiscoveryResultStatus) DeepCopy() *LocalVolumeDiscoveryResultStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPrepareRequestStatus.
+func (in *DiskPrepareRequestStatus) DeepCopy() *DiskPrepareRequestStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryResultStatus)
+	out := new(DiskPrepareRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoverySpec) DeepCopyInto(out *LocalVolumeDiscoverySpec) {
+func (in *FailureDomain) DeepCopyInto(out *FailureDomain) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = new(v1.NodeSelector)
-		(*in).DeepCopyInto(*out)
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
+	out.Summary = in.Summary
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoverySpec.
-func (in *LocalVolumeDiscoverySpec) DeepCopy() *LocalVolumeDiscoverySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureDomain.
+func (in *FailureDomain) DeepCopy() *FailureDomain {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoverySpec)
+	out := new(FailureDomain)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalVolumeDiscoveryStatus) DeepCopyInto(out *LocalVolumeDiscoveryStatus) {
+func (in *FibreChannelPort) DeepCopyInto(out *FibreChannelPort) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]operatorv1.OperatorCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryStatus.
-func (in *LocalVolumeDiscoveryStatus) DeepCopy() *LocalVolumeDiscoveryStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FibreChannelPort.
+func (in *FibreChannelPort) DeepCopy() *FibreChannelPort {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalVolumeDiscoveryStatus)
+	out := new(FibreChannelPort)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+func (in *IBMSpectrumCluster) DeepCopyInto(out *IBMSpectrumCluster) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
+	if in.Daemon_nodeSelector != nil {
+		in, out := &in.Daemon_nodeSelector, &out.Daemon_nodeSelector
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
@@ -293,45 +377,33 @@ func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
-func (in *MachineConfig) DeepCopy() *MachineConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IBMSpectrumCluster.
+func (in *IBMSpectrumCluster) DeepCopy() *IBMSpectrumCluster {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfig)
+	out := new(IBMSpectrumCluster)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeSpec) DeepCopyInto(out *NodeSpec) {
+func (in *IgnoredDevice) DeepCopyInto(out *IgnoredDevice) {
 	*out = *in
-	if in.Selector != nil {
-		in, out := &in.Selector, &out.Selector
-		*out = new(v1.NodeSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSpec.
-func (in *NodeSpec) DeepCopy() *NodeSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnoredDevice.
+func (in *IgnoredDevice) DeepCopy() *IgnoredDevice {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeSpec)
+	out := new(IgnoredDevice)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PurpleStorage) DeepCopyInto(out *PurpleStorage) {
+func (in *LocalVolumeDiscovery) DeepCopyInto(out *LocalVolumeDiscovery) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -339,18 +411,18 @@ func (in *PurpleStorage) DeepCopyInto(out *PurpleStorage) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorage.
-func (in *PurpleStorage) DeepCopy() *PurpleStorage {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscovery.
+func (in *LocalVolumeDiscovery) DeepCopy() *LocalVolumeDiscovery {
 	if in == nil {
 		return nil
 	}
-	out := new(PurpleStorage)
+	out := new(LocalVolumeDiscovery)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PurpleStorage) DeepCopyObject() runtime.Object {
+func (in *LocalVolumeDiscovery) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -358,31 +430,31 @@ func (in *PurpleStorage) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PurpleStorageList) DeepCopyInto(out *PurpleStorageList) {
+func (in *LocalVolumeDiscoveryList) DeepCopyInto(out *LocalVolumeDiscoveryList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]PurpleStorage, len(*in))
+		*out = make([]LocalVolumeDiscovery, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageList.
-func (in *PurpleStorageList) DeepCopy() *PurpleStorageList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryList.
+func (in *LocalVolumeDiscoveryList) DeepCopy() *LocalVolumeDiscoveryList {
 	if in == nil {
 		return nil
 	}
-	out := new(PurpleStorageList)
+	out := new(LocalVolumeDiscoveryList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PurpleStorageList) DeepCopyObject() runtime.Object {
+func (in *LocalVolumeDiscoveryList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -390,46 +462,935 @@ func (in *PurpleStorageList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PurpleStorageSpec) DeepCopyInto(out *PurpleStorageSpec) {
+func (in *LocalVolumeDiscoveryResult) DeepCopyInto(out *LocalVolumeDiscoveryResult) {
 	*out = *in
-	in.MachineConfig.DeepCopyInto(&out.MachineConfig)
-	in.Cluster.DeepCopyInto(&out.Cluster)
-	in.NodeSpec.DeepCopyInto(&out.NodeSpec)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageSpec.
-func (in *PurpleStorageSpec) DeepCopy() *PurpleStorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResult.
+func (in *LocalVolumeDiscoveryResult) DeepCopy() *LocalVolumeDiscoveryResult {
 	if in == nil {
 		return nil
 	}
-	out := new(PurpleStorageSpec)
+	out := new(LocalVolumeDiscoveryResult)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LocalVolumeDiscoveryResult) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PurpleStorageStatus) DeepCopyInto(out *PurpleStorageStatus) {
+func (in *LocalVolumeDiscoveryResultList) DeepCopyInto(out *LocalVolumeDiscoveryResultList) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]operatorv1.OperatorCondition, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LocalVolumeDiscoveryResult, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.TotalProvisionedDeviceCount != nil {
-		in, out := &in.TotalProvisionedDeviceCount, &out.TotalProvisionedDeviceCount
-		*out = new(int32)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultList.
+func (in *LocalVolumeDiscoveryResultList) DeepCopy() *LocalVolumeDiscoveryResultList {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalVolumeDiscoveryResultList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LocalVolumeDiscoveryResultList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalVolumeDiscoveryResultSpec) DeepCopyInto(out *LocalVolumeDiscoveryResultSpec) {
+	*out = *in
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(NodeTopology)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageStatus.
-func (in *PurpleStorageStatus) DeepCopy() *PurpleStorageStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultSpec.
+func (in *LocalVolumeDiscoveryResultSpec) DeepCopy() *LocalVolumeDiscoveryResultSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PurpleStorageStatus)
+	out := new(LocalVolumeDiscoveryResultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalVolumeDiscoveryResultStatus) DeepCopyInto(out *LocalVolumeDiscoveryResultStatus) {
+	*out = *in
+	if in.DiscoveredDevices != nil {
+		in, out := &in.DiscoveredDevices, &out.DiscoveredDevices
+		*out = make([]DiscoveredDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(DiscoverySummary)
+		**out = **in
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoredDevices != nil {
+		in, out := &in.IgnoredDevices, &out.IgnoredDevices
+		*out = make([]IgnoredDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.Initiators != nil {
+		in, out := &in.Initiators, &out.Initiators
+		*out = new(NodeInitiators)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryResultStatus.
+func (in *LocalVolumeDiscoveryResultStatus) DeepCopy() *LocalVolumeDiscoveryResultStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalVolumeDiscoveryResultStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalVolumeDiscoverySpec) DeepCopyInto(out *LocalVolumeDiscoverySpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.NodeSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ProbeInterval != nil {
+		in, out := &in.ProbeInterval, &out.ProbeInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UdevEventPeriod != nil {
+		in, out := &in.UdevEventPeriod, &out.UdevEventPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UdevMonitoringEnabled != nil {
+		in, out := &in.UdevMonitoringEnabled, &out.UdevMonitoringEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UdevExclusionFilter != nil {
+		in, out := &in.UdevExclusionFilter, &out.UdevExclusionFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResultTTL != nil {
+		in, out := &in.ResultTTL, &out.ResultTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoverySpec.
+func (in *LocalVolumeDiscoverySpec) DeepCopy() *LocalVolumeDiscoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalVolumeDiscoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalVolumeDiscoveryStatus) DeepCopyInto(out *LocalVolumeDiscoveryStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]operatorv1.OperatorCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StaleResults != nil {
+		in, out := &in.StaleResults, &out.StaleResults
+		*out = make([]StaleDiscoveryResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(DiscoverySummary)
+		**out = **in
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeDiscoverySummary, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalVolumeDiscoveryStatus.
+func (in *LocalVolumeDiscoveryStatus) DeepCopy() *LocalVolumeDiscoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalVolumeDiscoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
+func (in *MachineConfig) DeepCopy() *MachineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultipathPath) DeepCopyInto(out *MultipathPath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultipathPath.
+func (in *MultipathPath) DeepCopy() *MultipathPath {
+	if in == nil {
+		return nil
+	}
+	out := new(MultipathPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultipathTopology) DeepCopyInto(out *MultipathTopology) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]MultipathPath, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultipathTopology.
+func (in *MultipathTopology) DeepCopy() *MultipathTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(MultipathTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkCheck) DeepCopyInto(out *NetworkCheck) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkCheck.
+func (in *NetworkCheck) DeepCopy() *NetworkCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkCheck) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkCheckList) DeepCopyInto(out *NetworkCheckList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NetworkCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkCheckList.
+func (in *NetworkCheckList) DeepCopy() *NetworkCheckList {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkCheckList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkCheckList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkCheckPeer) DeepCopyInto(out *NetworkCheckPeer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkCheckPeer.
+func (in *NetworkCheckPeer) DeepCopy() *NetworkCheckPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkCheckPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkCheckSpec) DeepCopyInto(out *NetworkCheckSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.NodeSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkCheckSpec.
+func (in *NetworkCheckSpec) DeepCopy() *NetworkCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkCheckStatus) DeepCopyInto(out *NetworkCheckStatus) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]NetworkCheckPeer, len(*in))
+		copy(*out, *in)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]NetworkProbe, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkCheckStatus.
+func (in *NetworkCheckStatus) DeepCopy() *NetworkCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkProbe) DeepCopyInto(out *NetworkProbe) {
+	*out = *in
+	if in.FailedPorts != nil {
+		in, out := &in.FailedPorts, &out.FailedPorts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.ProbeTime.DeepCopyInto(&out.ProbeTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkProbe.
+func (in *NetworkProbe) DeepCopy() *NetworkProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDiscoverySummary) DeepCopyInto(out *NodeDiscoverySummary) {
+	*out = *in
+	out.Summary = in.Summary
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDiscoverySummary.
+func (in *NodeDiscoverySummary) DeepCopy() *NodeDiscoverySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDiscoverySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeInitiators) DeepCopyInto(out *NodeInitiators) {
+	*out = *in
+	if in.FibreChannel != nil {
+		in, out := &in.FibreChannel, &out.FibreChannel
+		*out = make([]FibreChannelPort, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeInitiators.
+func (in *NodeInitiators) DeepCopy() *NodeInitiators {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeInitiators)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSpec) DeepCopyInto(out *NodeSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.NodeSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSpec.
+func (in *NodeSpec) DeepCopy() *NodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTopology) DeepCopyInto(out *NodeTopology) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTopology.
+func (in *NodeTopology) DeepCopy() *NodeTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightCheck) DeepCopyInto(out *PreflightCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightCheck.
+func (in *PreflightCheck) DeepCopy() *PreflightCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReport) DeepCopyInto(out *PreflightReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReport.
+func (in *PreflightReport) DeepCopy() *PreflightReport {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreflightReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReportList) DeepCopyInto(out *PreflightReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PreflightReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReportList.
+func (in *PreflightReportList) DeepCopy() *PreflightReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreflightReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReportSpec) DeepCopyInto(out *PreflightReportSpec) {
+	*out = *in
+	if in.PagepoolSize != nil {
+		in, out := &in.PagepoolSize, &out.PagepoolSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReportSpec.
+func (in *PreflightReportSpec) DeepCopy() *PreflightReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReportStatus) DeepCopyInto(out *PreflightReportStatus) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]PreflightCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReportStatus.
+func (in *PreflightReportStatus) DeepCopy() *PreflightReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightSpec) DeepCopyInto(out *PreflightSpec) {
+	*out = *in
+	if in.PagepoolSize != nil {
+		in, out := &in.PagepoolSize, &out.PagepoolSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightSpec.
+func (in *PreflightSpec) DeepCopy() *PreflightSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurpleStorage) DeepCopyInto(out *PurpleStorage) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorage.
+func (in *PurpleStorage) DeepCopy() *PurpleStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(PurpleStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PurpleStorage) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurpleStorageList) DeepCopyInto(out *PurpleStorageList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PurpleStorage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageList.
+func (in *PurpleStorageList) DeepCopy() *PurpleStorageList {
+	if in == nil {
+		return nil
+	}
+	out := new(PurpleStorageList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PurpleStorageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurpleStorageSpec) DeepCopyInto(out *PurpleStorageSpec) {
+	*out = *in
+	in.MachineConfig.DeepCopyInto(&out.MachineConfig)
+	in.Cluster.DeepCopyInto(&out.Cluster)
+	in.NodeSpec.DeepCopyInto(&out.NodeSpec)
+	in.Preflight.DeepCopyInto(&out.Preflight)
+	out.CloudIntegration = in.CloudIntegration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageSpec.
+func (in *PurpleStorageSpec) DeepCopy() *PurpleStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PurpleStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurpleStorageStatus) DeepCopyInto(out *PurpleStorageStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]operatorv1.OperatorCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TotalProvisionedDeviceCount != nil {
+		in, out := &in.TotalProvisionedDeviceCount, &out.TotalProvisionedDeviceCount
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurpleStorageStatus.
+func (in *PurpleStorageStatus) DeepCopy() *PurpleStorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PurpleStorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplacedDevice) DeepCopyInto(out *ReplacedDevice) {
+	*out = *in
+	in.ReplacedAt.DeepCopyInto(&out.ReplacedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplacedDevice.
+func (in *ReplacedDevice) DeepCopy() *ReplacedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplacedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDevice) DeepCopyInto(out *SharedDevice) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]SharedDeviceNode, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDevice.
+func (in *SharedDevice) DeepCopy() *SharedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceInventory) DeepCopyInto(out *SharedDeviceInventory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceInventory.
+func (in *SharedDeviceInventory) DeepCopy() *SharedDeviceInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedDeviceInventory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceInventoryList) DeepCopyInto(out *SharedDeviceInventoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SharedDeviceInventory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceInventoryList.
+func (in *SharedDeviceInventoryList) DeepCopy() *SharedDeviceInventoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceInventoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedDeviceInventoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceInventorySpec) DeepCopyInto(out *SharedDeviceInventorySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceInventorySpec.
+func (in *SharedDeviceInventorySpec) DeepCopy() *SharedDeviceInventorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceInventorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceInventoryStatus) DeepCopyInto(out *SharedDeviceInventoryStatus) {
+	*out = *in
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]SharedDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make([]FailureDomain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceInventoryStatus.
+func (in *SharedDeviceInventoryStatus) DeepCopy() *SharedDeviceInventoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceInventoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceNode) DeepCopyInto(out *SharedDeviceNode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceNode.
+func (in *SharedDeviceNode) DeepCopy() *SharedDeviceNode {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaleDiscoveryResult) DeepCopyInto(out *StaleDiscoveryResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaleDiscoveryResult.
+func (in *StaleDiscoveryResult) DeepCopy() *StaleDiscoveryResult {
+	if in == nil {
+		return nil
+	}
+	out := new(StaleDiscoveryResult)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker/discovery"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"k8s.io/klog/v2"
+)
+
+var captureOptions struct {
+	dir     string
+	verbose bool
+}
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Record everything the discovery reads on this node into a tarball that can be replayed with inventory --snapshot",
+	RunE:  runCapture,
+}
+
+func init() {
+	captureCmd.Flags().StringVar(&captureOptions.dir, "dir", "", "directory the snapshot tarball is written to")
+	captureCmd.Flags().BoolVarP(&captureOptions.verbose, "verbose", "v", false, "print the discovery logs on stderr")
+	_ = captureCmd.MarkFlagRequired("dir")
+}
+
+func runCapture(cmd *cobra.Command, args []string) error {
+	if !captureOptions.verbose {
+		klog.LogToStderr(false)
+		klog.SetOutput(io.Discard)
+	}
+
+	snapshot, err := discovery.CaptureSnapshot(diskutils.NewLiveHost())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(captureOptions.dir, 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", captureOptions.dir)
+	}
+	path := filepath.Join(captureOptions.dir, fmt.Sprintf("snapshot-%s-%s.tar.gz", getNodeName(), time.Now().UTC().Format("20060102T150405Z")))
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", path)
+	}
+	defer f.Close()
+	if err := snapshot.WriteTarball(f); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), path)
+	return err
+}
+
+// getNodeName returns the name of the node the diskmaker runs on
+func getNodeName() string {
+	if nodeName := os.Getenv("MY_NODE_NAME"); nodeName != "" {
+		return nodeName
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return "unknown"
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker/discovery"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+var inventoryOptions struct {
+	once     bool
+	output   string
+	interval time.Duration
+	verbose  bool
+	snapshot string
+}
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Print the devices discovered on this node without using the API server",
+	RunE:  runInventory,
+}
+
+func init() {
+	inventoryCmd.Flags().BoolVar(&inventoryOptions.once, "once", false, "print the inventory once and exit")
+	inventoryCmd.Flags().StringVarP(&inventoryOptions.output, "output", "o", outputTable, "output format, one of json, yaml or table")
+	inventoryCmd.Flags().DurationVar(&inventoryOptions.interval, "interval", 30*time.Second, "time between two inventories when --once is not set")
+	inventoryCmd.Flags().BoolVarP(&inventoryOptions.verbose, "verbose", "v", false, "print the discovery logs on stderr")
+	inventoryCmd.Flags().StringVar(&inventoryOptions.snapshot, "snapshot", "", "replay the discovery on a snapshot directory or tarball written by capture instead of this node")
+}
+
+func runInventory(cmd *cobra.Command, args []string) error {
+	switch inventoryOptions.output {
+	case outputJSON, outputYAML, outputTable:
+	default:
+		return fmt.Errorf("unsupported output format %q, use one of json, yaml or table", inventoryOptions.output)
+	}
+
+	if !inventoryOptions.verbose {
+		klog.LogToStderr(false)
+		klog.SetOutput(io.Discard)
+	}
+
+	var host diskutils.Host = diskutils.NewLiveHost()
+	if inventoryOptions.snapshot != "" {
+		replayHost, err := diskutils.NewReplayHostFromPath(inventoryOptions.snapshot)
+		if err != nil {
+			return err
+		}
+		host = replayHost
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, syscall.SIGINT)
+	for {
+		inventory, err := discovery.GetInventory(host)
+		if err != nil {
+			return err
+		}
+		err = printInventory(cmd.OutOrStdout(), inventory, inventoryOptions.output)
+		if err != nil {
+			return err
+		}
+		// a snapshot doesn't change
+		if inventoryOptions.once || inventoryOptions.snapshot != "" {
+			return nil
+		}
+
+		select {
+		case <-sigc:
+			return nil
+		case <-time.After(inventoryOptions.interval):
+		}
+	}
+}
+
+func printInventory(w io.Writer, inventory *discovery.Inventory, output string) error {
+	switch output {
+	case outputJSON:
+		data, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal inventory")
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case outputYAML:
+		data, err := yaml.Marshal(inventory)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal inventory")
+		}
+		_, err = fmt.Fprint(w, "---\n"+string(data))
+		return err
+	default:
+		return printInventoryTable(w, inventory)
+	}
+}
+
+func printInventoryTable(w io.Writer, inventory *discovery.Inventory) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tTYPE\tSIZE\tWWN\tSTATE\tOWNER\tMODEL\tDEVICE ID\tREASONS")
+	for _, device := range inventory.Devices {
+		owner := device.OwnedBy
+		if owner == "" {
+			owner = "-"
+		}
+		reasons := strings.Join(device.Status.Reasons, "; ")
+		if reasons == "" {
+			reasons = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			device.Path, device.Type, resource.NewQuantity(device.Size, resource.BinarySI).String(),
+			device.WWN, device.Status.State, owner, device.Model, device.DeviceID, reasons)
+	}
+	if len(inventory.IgnoredDevices) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "IGNORED\tFILTER\tREASON")
+		for _, ignored := range inventory.IgnoredDevices {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", ignored.Name, ignored.Filter, ignored.Reason)
+		}
+	}
+	if inventory.Initiators != nil && len(inventory.Initiators.FibreChannel) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "FC HOST\tWWPN\tWWNN\tSTATE\tSPEED")
+		for _, port := range inventory.Initiators.FibreChannel {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", port.Host, port.WWPN, port.WWNN, port.State, port.Speed)
+		}
+	}
+	if inventory.Initiators != nil && inventory.Initiators.ISCSIInitiatorName != "" {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "ISCSI INITIATOR\t%s\n", inventory.Initiators.ISCSIInitiatorName)
+	}
+	return tw.Flush()
+}
@@ -20,6 +20,10 @@ var discoveryDaemonCmd = &cobra.Command{
 
 func main() {
 	rootCmd.AddCommand(discoveryDaemonCmd)
+	rootCmd.AddCommand(inventoryCmd)
+	rootCmd.AddCommand(captureCmd)
+	rootCmd.AddCommand(preflightCmd)
+	rootCmd.AddCommand(netcheckCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
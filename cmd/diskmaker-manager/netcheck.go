@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker/netcheck"
+)
+
+var netcheckOptions struct {
+	name string
+}
+
+var netcheckCmd = &cobra.Command{
+	Use:   "netcheck",
+	Short: "Listen on the Storage Scale daemon ports and probe the other nodes of a NetworkCheck",
+	RunE:  runNetcheck,
+}
+
+func init() {
+	netcheckCmd.Flags().StringVar(&netcheckOptions.name, "name", "", "name of the NetworkCheck, in the namespace of WATCH_NAMESPACE")
+}
+
+func runNetcheck(cmd *cobra.Command, args []string) error {
+	printVersion()
+
+	if netcheckOptions.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+	checker, err := netcheck.NewNetworkChecker(netcheckOptions.name, os.Getenv("WATCH_NAMESPACE"), os.Getenv("MY_NODE_NAME"))
+	if err != nil {
+		return errors.Wrap(err, "failed to check the network")
+	}
+	err = checker.Start()
+	if err != nil {
+		return errors.Wrap(err, "failed to check the network")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker/preflight"
+)
+
+var preflightOptions struct {
+	report string
+}
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Run the Storage Scale preflight checks on this node and store them in a PreflightReport",
+	RunE:  runPreflight,
+}
+
+func init() {
+	preflightCmd.Flags().StringVar(&preflightOptions.report, "report", "", "name of the PreflightReport of this node, in the namespace of WATCH_NAMESPACE")
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	printVersion()
+
+	if preflightOptions.report == "" {
+		return fmt.Errorf("--report is required")
+	}
+	err := preflight.Run(preflightOptions.report, os.Getenv("WATCH_NAMESPACE"))
+	if err != nil {
+		return errors.Wrap(err, "failed to run the preflight checks")
+	}
+	return nil
+}
@@ -36,15 +36,20 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	configv1 "github.com/openshift/api/config/v1"
 	machineconfigv1 "github.com/openshift/api/machineconfiguration/v1"
 
 	consolev1 "github.com/openshift/api/console/v1"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/controller/initializer"
 
+	dccontroller "github.com/validatedpatterns/purple-storage-rh-operator/internal/controller/diskclaim"
 	lvdcontroller "github.com/validatedpatterns/purple-storage-rh-operator/internal/controller/localvolumediscovery"
+	nccontroller "github.com/validatedpatterns/purple-storage-rh-operator/internal/controller/networkcheck"
+	sdicontroller "github.com/validatedpatterns/purple-storage-rh-operator/internal/controller/shareddeviceinventory"
 
 	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/controller"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
 	"github.com/validatedpatterns/purple-storage-rh-operator/version"
 	//+kubebuilder:scaffold:imports
 )
@@ -60,6 +65,7 @@ func init() {
 	utilruntime.Must(purplev1alpha1.AddToScheme(scheme))
 
 	utilruntime.Must(machineconfigv1.AddToScheme(scheme))
+	utilruntime.Must(configv1.AddToScheme(scheme))
 
 	utilruntime.Must(consolev1.AddToScheme(scheme))
 
@@ -146,6 +152,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&sdicontroller.SharedDeviceInventoryReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create SharedDeviceInventory controller")
+		os.Exit(1)
+	}
+
+	namespace, err := utils.GetDeploymentNamespace()
+	if err != nil {
+		setupLog.Error(err, "unable to get the namespace of the operator")
+		os.Exit(1)
+	}
+	if err = (&dccontroller.DiskClaimReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Namespace: namespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create DiskClaim controller")
+		os.Exit(1)
+	}
+
+	if err = (&nccontroller.NetworkCheckReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("networkcheck-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create NetworkCheck controller")
+		os.Exit(1)
+	}
+
 	if err = (&controller.PurpleStorageReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -158,6 +195,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "PurpleStorage")
 			os.Exit(1)
 		}
+		if err = (&purplev1alpha1.DiskClaimValidator{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DiskClaim")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
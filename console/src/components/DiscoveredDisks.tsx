@@ -7,11 +7,36 @@ import {
   PageSection,
   Title,
 } from '@patternfly/react-core';
-import { LocalVolumeDiscoveryResultSpec, LocalVolumeDiscoveryResultKind } from '../data/model';
+import {
+  DiscoveryShardLabel,
+  LocalVolumeDiscoveryResultSpec,
+  LocalVolumeDiscoveryResultKind,
+} from '../data/model';
 import { DisksModal } from './DisksModal';
 import { CatalogTile } from '@patternfly/react-catalog-view-extension';
 import { useK8sWatchResource } from '@openshift-console/dynamic-plugin-sdk';
 
+// mergeShards returns the primary results of the nodes with the devices of their shards
+const mergeShards = (results: LocalVolumeDiscoveryResultSpec[]): LocalVolumeDiscoveryResultSpec[] => {
+  const byName = new Map<string, LocalVolumeDiscoveryResultSpec>();
+  results.forEach((result) => byName.set(`${result.metadata.namespace}/${result.metadata.name}`, result));
+
+  return results
+    .filter((result) => !(DiscoveryShardLabel in (result.metadata.labels ?? {})))
+    .map((result) => {
+      const shards = result.status?.shards ?? [];
+      if (shards.length === 0) {
+        return result;
+      }
+      const devices = [...(result.status?.discoveredDevices ?? [])];
+      shards.forEach((name) => {
+        const shard = byName.get(`${result.metadata.namespace}/${name}`);
+        devices.push(...(shard?.status?.discoveredDevices ?? []));
+      });
+      return { ...result, status: { ...result.status, discoveredDevices: devices } };
+    });
+};
+
 export const DiscoveredDisks: React.FC = () => {
   const [discoveryresults, loaded, loadError] = useK8sWatchResource<LocalVolumeDiscoveryResultSpec[]>({
     groupVersionKind: LocalVolumeDiscoveryResultKind,
@@ -22,6 +47,8 @@ export const DiscoveredDisks: React.FC = () => {
 
   const [modalVisible, setModalVisible] = React.useState(false);
   const [modalData, setModalData] = React.useState<LocalVolumeDiscoveryResultSpec>();
+  // the devices of the nodes with many devices are split in shards, one card is shown per node
+  const nodeResults = React.useMemo(() => mergeShards(discoveryresults ?? []), [discoveryresults]);
 
   if (loaded === false) {
     return (
@@ -39,7 +66,7 @@ export const DiscoveredDisks: React.FC = () => {
     );
   }
 
-  if (loaded === true && discoveryresults.length === 0) {
+  if (loaded === true && nodeResults.length === 0) {
     return (
       <>
         <PageSection variant="light">
@@ -60,7 +87,7 @@ export const DiscoveredDisks: React.FC = () => {
     <>
       <PageSection variant="light">
         <Flex>
-          {discoveryresults.map((item, index) => {
+          {nodeResults.map((item, index) => {
             return (
               <FlexItem key={index}>
                 <CatalogTile
@@ -10,6 +10,9 @@ export const LocalVolumeDiscoveryResultKind: K8sGroupVersionKind = {
     kind: 'LocalVolumeDiscoveryResult',
 };
 
+// DiscoveryShardLabel is set on the results that hold the devices that don't fit in the primary result of a node
+export const DiscoveryShardLabel = 'discovery-result-shard';
+
 export enum DeviceType {
     disk,
     mpath
@@ -37,5 +40,6 @@ export type LocalVolumeDiscoveryResultSpec = {
     }
     status?: {
         discoveredDevices?: Device[]
+        shards?: string[]
     };
 } & K8sResourceCommon;
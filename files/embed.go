@@ -0,0 +1,9 @@
+// Package files ships the install manifests and the support matrix of the IBM Storage Scale Container Native versions
+package files
+
+import "embed"
+
+// SupportMatrix holds the support.yaml of each version directory
+//
+//go:embed */support.yaml
+var SupportMatrix embed.FS
@@ -0,0 +1,193 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+const (
+	// awsAccessKeyIDKey and awsSecretAccessKeyKey are the keys of the secrets of the Cloud Credential Operator
+	awsAccessKeyIDKey     = "aws_access_key_id"
+	awsSecretAccessKeyKey = "aws_secret_access_key" //nolint:gosec
+	ec2ServiceName        = "ec2"
+)
+
+var awsPlatform = Platform{
+	ProviderSpec: map[string]interface{}{
+		"apiVersion": "cloudcredential.openshift.io/v1",
+		"kind":       "AWSProviderSpec",
+		"statementEntries": []interface{}{
+			map[string]interface{}{
+				"effect": "Allow",
+				"action": []interface{}{
+					"ec2:DescribeInstances",
+					"ec2:DescribeSecurityGroups",
+					"ec2:AuthorizeSecurityGroupIngress",
+				},
+				"resource": "*",
+			},
+		},
+	},
+	New: newAWSProvider,
+}
+
+// awsProvider opens the ports in the security groups of the EC2 instances of the nodes
+type awsProvider struct {
+	client *ec2Client
+}
+
+// newAWSProvider returns the provider of the region of the cluster. The EC2 endpoint of the
+// serviceEndpoints of the Infrastructure is used when it is set.
+func newAWSProvider(infrastructure *configv1.Infrastructure, credentials map[string][]byte) (Provider, error) {
+	platformStatus := infrastructure.Status.PlatformStatus
+	if platformStatus == nil || platformStatus.AWS == nil || platformStatus.AWS.Region == "" {
+		return nil, fmt.Errorf("the Infrastructure %s has no AWS region", infrastructure.Name)
+	}
+	region := platformStatus.AWS.Region
+
+	accessKeyID := string(credentials[awsAccessKeyIDKey])
+	secretAccessKey := string(credentials[awsSecretAccessKeyKey])
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("the credentials have no %s or %s", awsAccessKeyIDKey, awsSecretAccessKeyKey)
+	}
+
+	endpoint := fmt.Sprintf("https://ec2.%s.amazonaws.com", region)
+	if strings.HasPrefix(region, "cn-") {
+		endpoint += ".cn"
+	}
+	for _, serviceEndpoint := range platformStatus.AWS.ServiceEndpoints {
+		if serviceEndpoint.Name == ec2ServiceName && serviceEndpoint.URL != "" {
+			endpoint = serviceEndpoint.URL
+		}
+	}
+
+	return &awsProvider{
+		client: newEC2Client(endpoint, region, awsCredentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey}),
+	}, nil
+}
+
+// OpenPorts allows the ports in a security group shared by the instances of all the nodes, from the group itself.
+// A port is considered open when one of the shared groups already allows it from itself, otherwise it is
+// added to the first shared group of the first instance.
+func (p *awsProvider) OpenPorts(ctx context.Context, nodes []corev1.Node, ports []common.PortRange) ([]string, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes to open the ports between")
+	}
+	instanceIDs := []string{}
+	for i := range nodes {
+		instanceID, err := getAWSInstanceID(nodes[i].Spec.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the instance of node %s: %w", nodes[i].Name, err)
+		}
+		instanceIDs = append(instanceIDs, instanceID)
+	}
+
+	instances, err := p.client.describeInstances(ctx, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs, err := getSharedSecurityGroups(instanceIDs, instances)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := p.client.describeSecurityGroups(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	missing := []ec2IPPermission{}
+	for _, port := range ports {
+		if !isPortAllowed(groups, port) {
+			missing = append(missing, ec2IPPermission{
+				IPProtocol: "tcp",
+				FromPort:   port.First,
+				ToPort:     port.Last,
+				Groups:     []ec2GroupIdentifier{{GroupID: groupIDs[0]}},
+			})
+		}
+	}
+	if len(missing) == 0 {
+		return []string{}, nil
+	}
+
+	if err := p.client.authorizeSecurityGroupIngress(ctx, groupIDs[0], missing); err != nil {
+		return nil, err
+	}
+	rules := []string{}
+	for _, permission := range missing {
+		port := common.PortRange{First: permission.FromPort, Last: permission.ToPort}
+		rules = append(rules, fmt.Sprintf("%s tcp/%s from %s", groupIDs[0], port, groupIDs[0]))
+	}
+	return rules, nil
+}
+
+// getAWSInstanceID returns the instance id of a providerID like aws:///us-east-1a/i-0123456789abcdef0
+func getAWSInstanceID(providerID string) (string, error) {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return "", fmt.Errorf("providerID %q is not an AWS instance", providerID)
+	}
+	instanceID := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(instanceID, "i-") {
+		return "", fmt.Errorf("providerID %q has no instance id", providerID)
+	}
+	return instanceID, nil
+}
+
+// getSharedSecurityGroups returns the security groups of all the instances, in the order of the first instance
+func getSharedSecurityGroups(instanceIDs []string, instances []ec2Instance) ([]string, error) {
+	groupsByInstance := map[string][]string{}
+	for _, instance := range instances {
+		for _, group := range instance.Groups {
+			groupsByInstance[instance.InstanceID] = append(groupsByInstance[instance.InstanceID], group.GroupID)
+		}
+	}
+	shared := groupsByInstance[instanceIDs[0]]
+	for _, instanceID := range instanceIDs {
+		groups, ok := groupsByInstance[instanceID]
+		if !ok {
+			return nil, fmt.Errorf("instance %s has no security groups", instanceID)
+		}
+		shared = intersect(shared, groups)
+	}
+	if len(shared) == 0 {
+		return nil, fmt.Errorf("the instances %s do not share a security group", strings.Join(instanceIDs, ", "))
+	}
+	return shared, nil
+}
+
+// isPortAllowed returns true if one of the groups allows the TCP ports from itself
+func isPortAllowed(groups []ec2SecurityGroup, port common.PortRange) bool {
+	for _, group := range groups {
+		for _, permission := range group.IPPermissions {
+			allPorts := permission.IPProtocol == "-1"
+			if !allPorts && (permission.IPProtocol != "tcp" || permission.FromPort > port.First || permission.ToPort < port.Last) {
+				continue
+			}
+			for _, source := range permission.Groups {
+				if source.GroupID == group.GroupID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func intersect(a, b []string) []string {
+	result := []string{}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				result = append(result, x)
+				break
+			}
+		}
+	}
+	return result
+}
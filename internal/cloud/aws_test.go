@@ -0,0 +1,250 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+// fakeEC2 is a stand-in of the EC2 Query API serving the instances and security groups it holds
+type fakeEC2 struct {
+	lock sync.Mutex
+	// instances are the security groups of each instance
+	instances map[string][]string
+	groups    map[string][]ec2IPPermission
+	actions   []string
+}
+
+func (f *fakeEC2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(r.Header.Get("Authorization"), "/us-east-1/ec2/aws4_request") {
+		f.writeError(w, http.StatusUnauthorized, "AuthFailure", "unsigned request")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		f.writeError(w, http.StatusBadRequest, "MalformedQueryString", err.Error())
+		return
+	}
+	action := r.PostForm.Get("Action")
+	f.actions = append(f.actions, action)
+
+	switch action {
+	case "DescribeInstances":
+		out := "<DescribeInstancesResponse><reservationSet><item><instancesSet>"
+		for i := 1; r.PostForm.Has(fmt.Sprintf("InstanceId.%d", i)); i++ {
+			instanceID := r.PostForm.Get(fmt.Sprintf("InstanceId.%d", i))
+			groups, ok := f.instances[instanceID]
+			if !ok {
+				f.writeError(w, http.StatusBadRequest, "InvalidInstanceID.NotFound", "The instance ID '"+instanceID+"' does not exist")
+				return
+			}
+			out += "<item><instanceId>" + instanceID + "</instanceId><groupSet>"
+			for _, group := range groups {
+				out += "<item><groupId>" + group + "</groupId></item>"
+			}
+			out += "</groupSet></item>"
+		}
+		out += "</instancesSet></item></reservationSet></DescribeInstancesResponse>"
+		_, _ = w.Write([]byte(out))
+	case "DescribeSecurityGroups":
+		out := "<DescribeSecurityGroupsResponse><securityGroupInfo>"
+		for i := 1; r.PostForm.Has(fmt.Sprintf("GroupId.%d", i)); i++ {
+			groupID := r.PostForm.Get(fmt.Sprintf("GroupId.%d", i))
+			out += "<item><groupId>" + groupID + "</groupId><ipPermissions>"
+			for _, permission := range f.groups[groupID] {
+				out += fmt.Sprintf("<item><ipProtocol>%s</ipProtocol><fromPort>%d</fromPort><toPort>%d</toPort><groups>",
+					permission.IPProtocol, permission.FromPort, permission.ToPort)
+				for _, group := range permission.Groups {
+					out += "<item><groupId>" + group.GroupID + "</groupId></item>"
+				}
+				out += "</groups></item>"
+			}
+			out += "</ipPermissions></item>"
+		}
+		out += "</securityGroupInfo></DescribeSecurityGroupsResponse>"
+		_, _ = w.Write([]byte(out))
+	case "AuthorizeSecurityGroupIngress":
+		groupID := r.PostForm.Get("GroupId")
+		for i := 1; r.PostForm.Has(fmt.Sprintf("IpPermissions.%d.IpProtocol", i)); i++ {
+			prefix := fmt.Sprintf("IpPermissions.%d.", i)
+			fromPort, _ := strconv.Atoi(r.PostForm.Get(prefix + "FromPort"))
+			toPort, _ := strconv.Atoi(r.PostForm.Get(prefix + "ToPort"))
+			f.groups[groupID] = append(f.groups[groupID], ec2IPPermission{
+				IPProtocol: r.PostForm.Get(prefix + "IpProtocol"),
+				FromPort:   fromPort,
+				ToPort:     toPort,
+				Groups:     []ec2GroupIdentifier{{GroupID: r.PostForm.Get(prefix + "Groups.1.GroupId")}},
+			})
+		}
+		_, _ = w.Write([]byte("<AuthorizeSecurityGroupIngressResponse><return>true</return></AuthorizeSecurityGroupIngressResponse>"))
+	default:
+		f.writeError(w, http.StatusBadRequest, "InvalidAction", "unknown action "+action)
+	}
+}
+
+func (f *fakeEC2) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors></Response>", code, message)
+}
+
+func newAWSInfrastructure(endpoint string) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.AWSPlatformType,
+				AWS: &configv1.AWSPlatformStatus{
+					Region:           "us-east-1",
+					ServiceEndpoints: []configv1.AWSServiceEndpoint{{Name: "ec2", URL: endpoint}},
+				},
+			},
+		},
+	}
+}
+
+func newAWSNode(name, instanceID string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/" + instanceID},
+	}
+}
+
+var testAWSCredentials = map[string][]byte{
+	"aws_access_key_id":     []byte("AKIDEXAMPLE"),
+	"aws_secret_access_key": []byte("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"),
+}
+
+func TestAWSOpenPorts(t *testing.T) {
+	ec2 := &fakeEC2{
+		instances: map[string][]string{
+			"i-0": {"sg-lb", "sg-node"},
+			"i-1": {"sg-node"},
+			"i-2": {"sg-other"},
+		},
+		groups: map[string][]ec2IPPermission{
+			"sg-node": {
+				{IPProtocol: "tcp", FromPort: 22, ToPort: 22, Groups: []ec2GroupIdentifier{{GroupID: "sg-node"}}},
+				{IPProtocol: "tcp", FromPort: 1191, ToPort: 1191, Groups: []ec2GroupIdentifier{{GroupID: "sg-node"}}},
+				{IPProtocol: "tcp", FromPort: 12345, ToPort: 12345, Groups: []ec2GroupIdentifier{{GroupID: "sg-lb"}}},
+			},
+		},
+	}
+	server := httptest.NewServer(ec2)
+	defer server.Close()
+
+	_, platform, ok := GetPlatform(newAWSInfrastructure(server.URL))
+	assert.True(t, ok)
+	provider, err := platform.New(newAWSInfrastructure(server.URL), testAWSCredentials)
+	assert.NoError(t, err)
+	ctx := context.TODO()
+	nodes := []corev1.Node{newAWSNode("worker-0", "i-0"), newAWSNode("worker-1", "i-1")}
+
+	// the ports that are not open from the shared group itself are added to it
+	rules, err := provider.OpenPorts(ctx, nodes, common.ScalePorts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sg-node tcp/12345 from sg-node", "sg-node tcp/60000-61000 from sg-node"}, rules)
+	assert.Equal(t, []string{"DescribeInstances", "DescribeSecurityGroups", "AuthorizeSecurityGroupIngress"}, ec2.actions)
+	assert.Len(t, ec2.groups["sg-node"], 5)
+
+	// nothing is added once the ports are open
+	ec2.actions = nil
+	rules, err = provider.OpenPorts(ctx, nodes, common.ScalePorts)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+	assert.Equal(t, []string{"DescribeInstances", "DescribeSecurityGroups"}, ec2.actions)
+
+	// a group that allows all the traffic from itself has the ports open
+	ec2.groups["sg-other"] = []ec2IPPermission{{IPProtocol: "-1", Groups: []ec2GroupIdentifier{{GroupID: "sg-other"}}}}
+	rules, err = provider.OpenPorts(ctx, []corev1.Node{newAWSNode("worker-2", "i-2")}, common.ScalePorts)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestAWSOpenPortsErrors(t *testing.T) {
+	ec2 := &fakeEC2{
+		instances: map[string][]string{"i-0": {"sg-a"}, "i-1": {"sg-b"}},
+		groups:    map[string][]ec2IPPermission{},
+	}
+	server := httptest.NewServer(ec2)
+	defer server.Close()
+	provider, err := newAWSProvider(newAWSInfrastructure(server.URL), testAWSCredentials)
+	assert.NoError(t, err)
+	ctx := context.TODO()
+
+	tests := []struct {
+		nodes []corev1.Node
+		err   string
+	}{
+		{ // case 1: the instances have no group in common
+			nodes: []corev1.Node{newAWSNode("worker-0", "i-0"), newAWSNode("worker-1", "i-1")},
+			err:   "the instances i-0, i-1 do not share a security group",
+		},
+		{ // case 2: the EC2 API error is returned
+			nodes: []corev1.Node{newAWSNode("worker-0", "i-0"), newAWSNode("worker-9", "i-9")},
+			err:   "DescribeInstances failed: InvalidInstanceID.NotFound: The instance ID 'i-9' does not exist",
+		},
+		{ // case 3: the node is not an EC2 instance
+			nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}, Spec: corev1.NodeSpec{ProviderID: "gce://project/zone/worker-0"}}},
+			err:   `failed to get the instance of node worker-0: providerID "gce://project/zone/worker-0" is not an AWS instance`,
+		},
+		{ // case 4: no nodes
+			nodes: []corev1.Node{},
+			err:   "no nodes to open the ports between",
+		},
+	}
+	for i, test := range tests {
+		_, err := provider.OpenPorts(ctx, test.nodes, common.ScalePorts)
+		assert.EqualErrorf(t, err, test.err, "case %d", i+1)
+	}
+}
+
+func TestNewAWSProvider(t *testing.T) {
+	infrastructure := newAWSInfrastructure("")
+	infrastructure.Status.PlatformStatus.AWS.ServiceEndpoints = nil
+	provider, err := newAWSProvider(infrastructure, testAWSCredentials)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ec2.us-east-1.amazonaws.com", provider.(*awsProvider).client.endpoint)
+
+	_, err = newAWSProvider(infrastructure, map[string][]byte{"aws_access_key_id": []byte("AKIDEXAMPLE")})
+	assert.EqualError(t, err, "the credentials have no aws_access_key_id or aws_secret_access_key")
+
+	infrastructure.Status.PlatformStatus.AWS = nil
+	_, err = newAWSProvider(infrastructure, testAWSCredentials)
+	assert.EqualError(t, err, "the Infrastructure cluster has no AWS region")
+}
+
+func TestSignV4(t *testing.T) {
+	// the post-x-www-form-urlencoded request of the AWS Signature Version 4 test suite
+	body := []byte("Param1=value1")
+	req, err := http.NewRequest(http.MethodPost, "https://example.amazonaws.com/", bytes.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	credentials := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, body, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		req.Header.Get("Authorization"))
+
+	// the signing key of the AWS documentation
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d",
+		hex.EncodeToString(getSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")))
+}
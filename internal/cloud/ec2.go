@@ -0,0 +1,177 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	ec2APIVersion = "2016-11-15"
+	// maxEC2ResponseSize bounds the responses read from the EC2 API
+	maxEC2ResponseSize = 10 << 20
+	ec2RequestTimeout  = 30 * time.Second
+)
+
+// ec2Client is a minimal client of the EC2 Query API, limited to the calls that open the ports
+type ec2Client struct {
+	endpoint    string
+	region      string
+	credentials awsCredentials
+	httpClient  *http.Client
+	now         func() time.Time
+}
+
+// ec2Error is an error returned by the EC2 API
+type ec2Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *ec2Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+type ec2ErrorResponse struct {
+	Errors []ec2Error `xml:"Errors>Error"`
+}
+
+type ec2GroupIdentifier struct {
+	GroupID string `xml:"groupId"`
+}
+
+type ec2Instance struct {
+	InstanceID string               `xml:"instanceId"`
+	Groups     []ec2GroupIdentifier `xml:"groupSet>item"`
+}
+
+type ec2DescribeInstancesResponse struct {
+	Reservations []struct {
+		Instances []ec2Instance `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+}
+
+type ec2IPPermission struct {
+	IPProtocol string               `xml:"ipProtocol"`
+	FromPort   int                  `xml:"fromPort"`
+	ToPort     int                  `xml:"toPort"`
+	Groups     []ec2GroupIdentifier `xml:"groups>item"`
+}
+
+type ec2SecurityGroup struct {
+	GroupID       string            `xml:"groupId"`
+	IPPermissions []ec2IPPermission `xml:"ipPermissions>item"`
+}
+
+type ec2DescribeSecurityGroupsResponse struct {
+	SecurityGroups []ec2SecurityGroup `xml:"securityGroupInfo>item"`
+}
+
+type ec2AuthorizeSecurityGroupIngressResponse struct {
+	Return bool `xml:"return"`
+}
+
+func newEC2Client(endpoint, region string, credentials awsCredentials) *ec2Client {
+	return &ec2Client{
+		endpoint:    endpoint,
+		region:      region,
+		credentials: credentials,
+		httpClient:  &http.Client{Timeout: ec2RequestTimeout},
+		now:         time.Now,
+	}
+}
+
+// describeInstances returns the instances with the given ids
+func (c *ec2Client) describeInstances(ctx context.Context, instanceIDs []string) ([]ec2Instance, error) {
+	params := url.Values{}
+	for i, instanceID := range instanceIDs {
+		params.Set(fmt.Sprintf("InstanceId.%d", i+1), instanceID)
+	}
+	response := &ec2DescribeInstancesResponse{}
+	if err := c.do(ctx, "DescribeInstances", params, response); err != nil {
+		return nil, err
+	}
+	instances := []ec2Instance{}
+	for _, reservation := range response.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	return instances, nil
+}
+
+// describeSecurityGroups returns the security groups with the given ids
+func (c *ec2Client) describeSecurityGroups(ctx context.Context, groupIDs []string) ([]ec2SecurityGroup, error) {
+	params := url.Values{}
+	for i, groupID := range groupIDs {
+		params.Set(fmt.Sprintf("GroupId.%d", i+1), groupID)
+	}
+	response := &ec2DescribeSecurityGroupsResponse{}
+	if err := c.do(ctx, "DescribeSecurityGroups", params, response); err != nil {
+		return nil, err
+	}
+	return response.SecurityGroups, nil
+}
+
+// authorizeSecurityGroupIngress adds the inbound rules to the security group
+func (c *ec2Client) authorizeSecurityGroupIngress(ctx context.Context, groupID string, permissions []ec2IPPermission) error {
+	params := url.Values{}
+	params.Set("GroupId", groupID)
+	for i, permission := range permissions {
+		prefix := fmt.Sprintf("IpPermissions.%d.", i+1)
+		params.Set(prefix+"IpProtocol", permission.IPProtocol)
+		params.Set(prefix+"FromPort", strconv.Itoa(permission.FromPort))
+		params.Set(prefix+"ToPort", strconv.Itoa(permission.ToPort))
+		for j, group := range permission.Groups {
+			params.Set(fmt.Sprintf("%sGroups.%d.GroupId", prefix, j+1), group.GroupID)
+		}
+	}
+	response := &ec2AuthorizeSecurityGroupIngressResponse{}
+	if err := c.do(ctx, "AuthorizeSecurityGroupIngress", params, response); err != nil {
+		return err
+	}
+	if !response.Return {
+		return fmt.Errorf("AuthorizeSecurityGroupIngress of %s was not accepted", groupID)
+	}
+	return nil
+}
+
+// do sends a signed request of the action and decodes its XML response
+func (c *ec2Client) do(ctx context.Context, action string, params url.Values, out interface{}) error {
+	params.Set("Action", action)
+	params.Set("Version", ec2APIVersion)
+	body := []byte(params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, body, c.credentials, c.region, ec2ServiceName, c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxEC2ResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read the %s response: %w", action, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errorResponse := &ec2ErrorResponse{}
+		if err := xml.Unmarshal(data, errorResponse); err == nil && len(errorResponse.Errors) > 0 {
+			return fmt.Errorf("%s failed: %w", action, &errorResponse.Errors[0])
+		}
+		return fmt.Errorf("%s failed with status %d", action, resp.StatusCode)
+	}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode the %s response: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cloud
+
+import (
+	"context"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+const (
+	// CredentialsRequestNamespace is where the Cloud Credential Operator watches the CredentialsRequests
+	CredentialsRequestNamespace = "openshift-cloud-credential-operator"
+	// CredentialsRequestName is the CredentialsRequest of the permissions of the cloud provider
+	CredentialsRequestName = "purple-storage-cloud"
+	// CredentialsSecretName is the secret the Cloud Credential Operator creates with the credentials
+	CredentialsSecretName = "purple-storage-cloud-credentials" //nolint:gosec
+)
+
+// CredentialsRequestGVK is the kind of the CredentialsRequests of the Cloud Credential Operator
+var CredentialsRequestGVK = schema.GroupVersionKind{
+	Group:   "cloudcredential.openshift.io",
+	Version: "v1",
+	Kind:    "CredentialsRequest",
+}
+
+// Provider opens the Storage Scale ports between the nodes of the daemons in the firewall of a cloud provider
+type Provider interface {
+	// OpenPorts allows the TCP ports between the nodes and returns a description of the rules that were added.
+	// Ports that are already open are left untouched.
+	OpenPorts(ctx context.Context, nodes []corev1.Node, ports []common.PortRange) ([]string, error)
+}
+
+// Platform plugs the provider of a platform type of the Infrastructure
+type Platform struct {
+	// ProviderSpec is the providerSpec of the CredentialsRequest with the permissions of the provider
+	ProviderSpec map[string]interface{}
+	// New returns the provider of the cluster from its Infrastructure and the data of the credentials secret
+	New func(infrastructure *configv1.Infrastructure, credentials map[string][]byte) (Provider, error)
+}
+
+var platforms = map[configv1.PlatformType]Platform{
+	configv1.AWSPlatformType: awsPlatform,
+}
+
+// Register adds the provider of a platform type, replacing the current one
+func Register(platformType configv1.PlatformType, platform Platform) {
+	platforms[platformType] = platform
+}
+
+// GetPlatform returns the platform type of the Infrastructure and its provider, false if there is no provider for it
+func GetPlatform(infrastructure *configv1.Infrastructure) (configv1.PlatformType, Platform, bool) {
+	platformType := configv1.NonePlatformType
+	if infrastructure.Status.PlatformStatus != nil && infrastructure.Status.PlatformStatus.Type != "" {
+		platformType = infrastructure.Status.PlatformStatus.Type
+	}
+	platform, ok := platforms[platformType]
+	return platformType, platform, ok
+}
+
+// NewCredentialsRequest returns the CredentialsRequest of the platform, whose secret is created in the given namespace
+func NewCredentialsRequest(platform Platform, secretNamespace string) *unstructured.Unstructured {
+	credentialsRequest := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretRef": map[string]interface{}{
+					"name":      CredentialsSecretName,
+					"namespace": secretNamespace,
+				},
+				"providerSpec": runtime.DeepCopyJSON(platform.ProviderSpec),
+			},
+		},
+	}
+	credentialsRequest.SetGroupVersionKind(CredentialsRequestGVK)
+	credentialsRequest.SetName(CredentialsRequestName)
+	credentialsRequest.SetNamespace(CredentialsRequestNamespace)
+	return credentialsRequest
+}
@@ -0,0 +1,52 @@
+package cloud
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetPlatform(t *testing.T) {
+	tests := []struct {
+		platformStatus *configv1.PlatformStatus
+		platformType   configv1.PlatformType
+		supported      bool
+	}{
+		{ // case 1: AWS has a provider
+			platformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+			platformType:   configv1.AWSPlatformType,
+			supported:      true,
+		},
+		{ // case 2: no provider for bare metal
+			platformStatus: &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType},
+			platformType:   configv1.BareMetalPlatformType,
+		},
+		{ // case 3: no platform status
+			platformType: configv1.NonePlatformType,
+		},
+	}
+	for i, test := range tests {
+		infrastructure := &configv1.Infrastructure{Status: configv1.InfrastructureStatus{PlatformStatus: test.platformStatus}}
+		platformType, _, supported := GetPlatform(infrastructure)
+		assert.Equalf(t, test.platformType, platformType, "case %d", i+1)
+		assert.Equalf(t, test.supported, supported, "case %d", i+1)
+	}
+}
+
+func TestNewCredentialsRequest(t *testing.T) {
+	credentialsRequest := NewCredentialsRequest(awsPlatform, "purple-storage")
+	assert.Equal(t, CredentialsRequestGVK, credentialsRequest.GroupVersionKind())
+	assert.Equal(t, "openshift-cloud-credential-operator", credentialsRequest.GetNamespace())
+
+	secretNamespace, _, _ := unstructured.NestedString(credentialsRequest.Object, "spec", "secretRef", "namespace")
+	assert.Equal(t, "purple-storage", secretNamespace)
+	kind, _, _ := unstructured.NestedString(credentialsRequest.Object, "spec", "providerSpec", "kind")
+	assert.Equal(t, "AWSProviderSpec", kind)
+
+	// the providerSpec of the platform is not shared with the CredentialsRequest
+	err := unstructured.SetNestedField(credentialsRequest.Object, "GCPProviderSpec", "spec", "providerSpec", "kind")
+	assert.NoError(t, err)
+	assert.Equal(t, "AWSProviderSpec", awsPlatform.ProviderSpec["kind"])
+}
@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4DateFormat = "20060102T150405Z"
+)
+
+// awsCredentials are the static credentials of an AWS user
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signV4 adds the AWS Signature Version 4 of the request and its body to its headers
+func signV4(req *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4DateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + strings.TrimSpace(headers[name]) + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders,
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(getSigningKey(credentials.SecretAccessKey, date, region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// getSigningKey derives the key of the signatures of a day, region and service from the secret key
+func getSigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
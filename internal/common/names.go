@@ -21,8 +21,32 @@ const (
 	// DiscoveryNodeLabelKey is the label key on the discovery result CR used to identify the node it belongs to.
 	// the value is the node's name
 	DiscoveryNodeLabel = "discovery-result-node"
+	// DiscoveryNameLabel is the label key on the discovery result CR with the name of the LocalVolumeDiscovery
+	// whose daemon created it
+	DiscoveryNameLabel = "discovery-result-discovery"
+	// DiscoveryShardLabel is set on the discovery result CRs that hold the devices that don't fit in the
+	// primary result of a node. The value is the index of the shard
+	DiscoveryShardLabel = "discovery-result-shard"
+	// DiskClaimLockLabel is set on the leases that reserve the WWNs of the DiskClaims. The value is a hash of
+	// the namespace and name of the DiskClaim that holds the lease, as names may not fit in a label value
+	DiskClaimLockLabel = "purple.purplestorage.com/diskclaim"
+	// RackLabel is the node label with the rack of the node. Together with the zone and region
+	// labels it defines the failure domain of the devices of the node
+	RackLabel = "purple.purplestorage.com/rack"
+
+	// LocalVolumeDiscoveryName is the name of the LocalVolumeDiscovery created from the PurpleStorage NodeSpec
+	LocalVolumeDiscoveryName = "auto-discover-devices"
 
 	DiskMakerDiscoveryDaemonSetTemplate = "templates/diskmaker-discovery-daemonset.yaml"
+
+	// ProbeIntervalEnv is the env variable with the time between two full discoveries
+	ProbeIntervalEnv = "DISCOVERY_PROBE_INTERVAL"
+	// UdevEventPeriodEnv is the env variable with the time udev events are collapsed for
+	UdevEventPeriodEnv = "DISCOVERY_UDEV_EVENT_PERIOD"
+	// UdevMonitoringEnabledEnv is the env variable that turns the udev monitoring on or off
+	UdevMonitoringEnabledEnv = "DISCOVERY_UDEV_MONITORING_ENABLED"
+	// UdevExclusionFilterEnv is the env variable with the JSON list of udev exclusion regexes
+	UdevExclusionFilterEnv = "DISCOVERY_UDEV_EXCLUSION_FILTER"
 )
 
 // GetDiskMakerImage returns the image to be used for diskmaker daemonset
@@ -0,0 +1,28 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ScalePorts are the TCP ports the Storage Scale daemons listen on: the daemon port, the admin port
+// and the ephemeral range of the data transfers
+var ScalePorts = []PortRange{{1191, 1191}, {12345, 12345}, {60000, 61000}}
+
+// PortRange is an inclusive range of TCP ports
+type PortRange struct {
+	First int
+	Last  int
+}
+
+func (p PortRange) String() string {
+	if p.First == p.Last {
+		return strconv.Itoa(p.First)
+	}
+	return fmt.Sprintf("%d-%d", p.First, p.Last)
+}
+
+// Contains returns true if the port is in the range
+func (p PortRange) Contains(port int) bool {
+	return port >= p.First && port <= p.Last
+}
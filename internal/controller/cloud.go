@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/cloud"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+const (
+	// CloudPortsCondition is set on the PurpleStorage with the outcome of opening the ports in the cloud provider
+	CloudPortsCondition = "CloudPortsOpened"
+	// infrastructureName is the Infrastructure of the cluster
+	infrastructureName = "cluster"
+)
+
+// reconcileCloudPorts opens the Storage Scale ports between the nodes of the daemons in the cloud provider of
+// the cluster and returns false while its credentials are not available. On platforms without a provider the
+// ports are left to the administrator. The outcome is stored in the CloudPortsOpened condition of the PurpleStorage.
+func (r *PurpleStorageReconciler) reconcileCloudPorts(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage,
+	daemonNodeSelector map[string]string) (bool, error) {
+	infrastructure := &configv1.Infrastructure{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: infrastructureName}, infrastructure); err != nil {
+		return false, fmt.Errorf("failed to get the Infrastructure of the cluster: %w", err)
+	}
+
+	condition := operatorv1.OperatorCondition{Type: CloudPortsCondition, Status: operatorv1.ConditionFalse}
+	platformType, platform, ok := cloud.GetPlatform(infrastructure)
+	if !ok {
+		condition.Reason = "UnsupportedPlatform"
+		condition.Message = fmt.Sprintf("the ports cannot be opened on platform %s, they must be opened by the administrator", platformType)
+		return true, r.setCondition(ctx, purplestorage, condition)
+	}
+
+	if err := r.reconcileCredentialsRequest(ctx, cloud.NewCredentialsRequest(platform, purplestorage.Namespace)); err != nil {
+		return false, err
+	}
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: cloud.CredentialsSecretName, Namespace: purplestorage.Namespace}, secret)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get the cloud credentials: %w", err)
+		}
+		condition.Reason = "WaitingForCredentials"
+		condition.Message = fmt.Sprintf("waiting for secret %s of CredentialsRequest %s/%s", cloud.CredentialsSecretName,
+			cloud.CredentialsRequestNamespace, cloud.CredentialsRequestName)
+		return false, r.setCondition(ctx, purplestorage, condition)
+	}
+
+	nodes := &corev1.NodeList{}
+	err = r.Client.List(ctx, nodes, client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(daemonNodeSelector)})
+	if err != nil {
+		return false, fmt.Errorf("failed to list the nodes of the daemons: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		condition.Reason = "NoNodes"
+		condition.Message = "no node matches the nodeSelector of the daemons"
+		return true, r.setCondition(ctx, purplestorage, condition)
+	}
+
+	var rules []string
+	provider, err := platform.New(infrastructure, secret.Data)
+	if err == nil {
+		rules, err = provider.OpenPorts(ctx, nodes.Items, common.ScalePorts)
+	}
+	if err != nil {
+		condition.Reason = "OpenPortsFailed"
+		condition.Message = fmt.Sprintf("failed to open the ports on platform %s: %v", platformType, err)
+		if err := r.setCondition(ctx, purplestorage, condition); err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("failed to open the ports on platform %s: %w", platformType, err)
+	}
+	if len(rules) > 0 {
+		log.Log.Info(fmt.Sprintf("Opened the ports on platform %s: %s", platformType, strings.Join(rules, ", ")))
+	}
+
+	condition.Status = operatorv1.ConditionTrue
+	condition.Reason = "PortsOpened"
+	condition.Message = fmt.Sprintf("the Storage Scale ports are open between %d nodes on platform %s", len(nodes.Items), platformType)
+	return true, r.setCondition(ctx, purplestorage, condition)
+}
+
+// reconcileCredentialsRequest creates the CredentialsRequest or updates its spec
+func (r *PurpleStorageReconciler) reconcileCredentialsRequest(ctx context.Context, expected *unstructured.Unstructured) error {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(expected.GroupVersionKind())
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(expected), current)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get CredentialsRequest %s: %w", expected.GetName(), err)
+		}
+		if err := r.Client.Create(ctx, expected); err != nil {
+			return fmt.Errorf("failed to create CredentialsRequest %s: %w", expected.GetName(), err)
+		}
+		log.Log.Info(fmt.Sprintf("Created CredentialsRequest %s", expected.GetName()))
+		return nil
+	}
+	if equality.Semantic.DeepEqual(current.Object["spec"], expected.Object["spec"]) {
+		return nil
+	}
+	current.Object["spec"] = expected.Object["spec"]
+	if err := r.Client.Update(ctx, current); err != nil {
+		return fmt.Errorf("failed to update CredentialsRequest %s: %w", expected.GetName(), err)
+	}
+	return nil
+}
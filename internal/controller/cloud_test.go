@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/cloud"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+// fakeCloudProvider records the nodes it opened the ports between
+type fakeCloudProvider struct {
+	nodes []string
+	err   error
+}
+
+func (p *fakeCloudProvider) OpenPorts(_ context.Context, nodes []corev1.Node, ports []common.PortRange) ([]string, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.nodes = []string{}
+	for _, node := range nodes {
+		p.nodes = append(p.nodes, node.Name)
+	}
+	return []string{"rule"}, nil
+}
+
+func newInfrastructure(platformType configv1.PlatformType) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{Type: platformType},
+		},
+	}
+}
+
+func TestReconcileCloudPorts(t *testing.T) {
+	ctx := context.TODO()
+	provider := &fakeCloudProvider{}
+	cloud.Register(configv1.ExternalPlatformType, cloud.Platform{
+		ProviderSpec: map[string]interface{}{"kind": "FakeProviderSpec"},
+		New: func(_ *configv1.Infrastructure, credentials map[string][]byte) (cloud.Provider, error) {
+			if string(credentials["key"]) != "secret" {
+				return nil, errors.New("invalid credentials")
+			}
+			return provider, nil
+		},
+	})
+	storageLabels := map[string]string{"storage": "true"}
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage"},
+		Spec: purplev1alpha1.PurpleStorageSpec{
+			CloudIntegration: purplev1alpha1.CloudIntegration{OpenPorts: true},
+		},
+	}
+	r := newFakePurpleStorageReconciler(t, purplestorage, newInfrastructure(configv1.ExternalPlatformType),
+		newPreflightNode("worker-0", storageLabels),
+		newPreflightNode("worker-1", storageLabels),
+		newPreflightNode("master-0", nil))
+
+	// the CredentialsRequest is created and the ports wait for its secret
+	opened, err := r.reconcileCloudPorts(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, opened)
+	condition := findPurpleStorageCondition(purplestorage, CloudPortsCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "WaitingForCredentials", condition.Reason)
+	}
+	credentialsRequest := &unstructured.Unstructured{}
+	credentialsRequest.SetGroupVersionKind(cloud.CredentialsRequestGVK)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cloud.CredentialsRequestName, Namespace: cloud.CredentialsRequestNamespace}, credentialsRequest)
+	assert.NoError(t, err)
+	secretNamespace, _, _ := unstructured.NestedString(credentialsRequest.Object, "spec", "secretRef", "namespace")
+	assert.Equal(t, "purple-storage", secretNamespace)
+
+	// the provider fails with invalid credentials
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: cloud.CredentialsSecretName, Namespace: "purple-storage"},
+		Data:       map[string][]byte{"key": []byte("invalid")},
+	}
+	assert.NoError(t, r.Client.Create(ctx, secret))
+	opened, err = r.reconcileCloudPorts(ctx, purplestorage, storageLabels)
+	assert.EqualError(t, err, "failed to open the ports on platform External: invalid credentials")
+	assert.False(t, opened)
+	condition = findPurpleStorageCondition(purplestorage, CloudPortsCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "OpenPortsFailed", condition.Reason)
+	}
+
+	// the ports are opened between the nodes of the daemons
+	secret.Data = map[string][]byte{"key": []byte("secret")}
+	assert.NoError(t, r.Client.Update(ctx, secret))
+	opened, err = r.reconcileCloudPorts(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.True(t, opened)
+	assert.Equal(t, []string{"worker-0", "worker-1"}, provider.nodes)
+	condition = findPurpleStorageCondition(purplestorage, CloudPortsCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+		assert.Equal(t, "the Storage Scale ports are open between 2 nodes on platform External", condition.Message)
+	}
+}
+
+func TestReconcileCloudPortsUnsupportedPlatform(t *testing.T) {
+	ctx := context.TODO()
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage"},
+	}
+	r := newFakePurpleStorageReconciler(t, purplestorage, newInfrastructure(configv1.BareMetalPlatformType))
+
+	// the cluster is not blocked on platforms without a provider
+	opened, err := r.reconcileCloudPorts(ctx, purplestorage, map[string]string{"storage": "true"})
+	assert.NoError(t, err)
+	assert.True(t, opened)
+	condition := findPurpleStorageCondition(purplestorage, CloudPortsCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+		assert.Equal(t, "UnsupportedPlatform", condition.Reason)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diskclaim
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// lockPrefix prefixes the name of the leases that reserve the WWNs
+const lockPrefix = "diskclaim-"
+
+// DiskClaimReconciler reserves the WWN of each DiskClaim with a lease named after the WWN.
+// The webhook rejects most duplicate claims, but two claims admitted at the same time both pass it:
+// creating the lease is atomic, so only one of them gets the reservation.
+type DiskClaimReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+	// Namespace is the namespace of the leases, the one the operator is deployed on
+	Namespace string
+}
+
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=diskclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=diskclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;delete
+
+// Reconcile takes the lease of the WWN of the DiskClaim, or releases the leases of a deleted DiskClaim
+func (r *DiskClaimReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	klog.InfoS("Reconciling DiskClaim", "namespace", request.Namespace, "name", request.Name)
+
+	claim := &localv1alpha1.DiskClaim{}
+	err := r.Client.Get(ctx, request.NamespacedName, claim)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, r.releaseLocks(ctx, request.NamespacedName)
+		}
+		return ctrl.Result{}, err
+	}
+
+	phase, message, err := r.acquireLock(ctx, claim)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if claim.Status.Phase == phase && claim.Status.Message == message {
+		return ctrl.Result{}, nil
+	}
+	claim.Status.Phase = phase
+	claim.Status.Message = message
+	if err := r.Client.Status().Update(ctx, claim); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update DiskClaim status: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// acquireLock creates the lease of the WWN of the claim. When the lease exists, the claim keeps it if it
+// already holds it, and takes it over if its holder has been deleted.
+func (r *DiskClaimReconciler) acquireLock(ctx context.Context, claim *localv1alpha1.DiskClaim) (localv1alpha1.DiskClaimPhase, string, error) {
+	holder := getHolderIdentity(claim)
+	claimKey := hash(client.ObjectKeyFromObject(claim).String())
+	reserved := fmt.Sprintf("WWN %q is reserved", claim.Spec.WWN)
+
+	lock := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getLockName(claim.Spec.WWN),
+			Namespace: r.Namespace,
+			Labels:    map[string]string{common.DiskClaimLockLabel: claimKey},
+		},
+		Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	}
+	err := r.Client.Create(ctx, lock)
+	if err == nil {
+		return localv1alpha1.DiskClaimReserved, reserved, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return "", "", fmt.Errorf("failed to create the lease of WWN %q: %w", claim.Spec.WWN, err)
+	}
+
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(lock), lock); err != nil {
+		return "", "", fmt.Errorf("failed to get the lease of WWN %q: %w", claim.Spec.WWN, err)
+	}
+	current := ""
+	if lock.Spec.HolderIdentity != nil {
+		current = *lock.Spec.HolderIdentity
+	}
+	if current == holder {
+		return localv1alpha1.DiskClaimReserved, reserved, nil
+	}
+
+	held, err := r.isHeld(ctx, current)
+	if err != nil {
+		return "", "", err
+	}
+	if held {
+		namespace, name, _, _ := parseHolderIdentity(current)
+		return localv1alpha1.DiskClaimConflict,
+			fmt.Sprintf("WWN %q is reserved by DiskClaim %s/%s", claim.Spec.WWN, namespace, name), nil
+	}
+
+	// the update fails on a conflict when another claim takes the lease over at the same time
+	lock.Labels = map[string]string{common.DiskClaimLockLabel: claimKey}
+	lock.Spec.HolderIdentity = &holder
+	if err := r.Client.Update(ctx, lock); err != nil {
+		return "", "", fmt.Errorf("failed to take over the lease of WWN %q: %w", claim.Spec.WWN, err)
+	}
+	klog.InfoS("took over the lease of a deleted DiskClaim", "WWN", claim.Spec.WWN, "previous", current, "holder", holder)
+
+	return localv1alpha1.DiskClaimReserved, reserved, nil
+}
+
+// isHeld tells whether the DiskClaim of the holder identity of a lease still exists. A DiskClaim
+// recreated with the same name doesn't hold the lease of its predecessor.
+func (r *DiskClaimReconciler) isHeld(ctx context.Context, holder string) (bool, error) {
+	namespace, name, uid, ok := parseHolderIdentity(holder)
+	if !ok {
+		return false, nil
+	}
+	claim := &localv1alpha1.DiskClaim{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, claim)
+	if err == nil {
+		return claim.UID == uid, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to get DiskClaim %s/%s: %w", namespace, name, err)
+}
+
+// releaseLocks deletes the leases held by a deleted DiskClaim
+func (r *DiskClaimReconciler) releaseLocks(ctx context.Context, claim types.NamespacedName) error {
+	locks := &coordinationv1.LeaseList{}
+	if err := r.Client.List(ctx, locks, client.InNamespace(r.Namespace),
+		client.MatchingLabels{common.DiskClaimLockLabel: hash(claim.String())}); err != nil {
+		return fmt.Errorf("failed to list the leases of DiskClaim %s: %w", claim, err)
+	}
+	for i := range locks.Items {
+		lock := &locks.Items[i]
+		if lock.Spec.HolderIdentity == nil {
+			continue
+		}
+		namespace, name, _, ok := parseHolderIdentity(*lock.Spec.HolderIdentity)
+		if !ok || namespace != claim.Namespace || name != claim.Name {
+			continue
+		}
+		err := r.Client.Delete(ctx, lock, client.Preconditions{ResourceVersion: &lock.ResourceVersion})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete lease %s: %w", lock.Name, err)
+		}
+	}
+	return nil
+}
+
+// getLockName returns the name of the lease of a WWN. WWNs are compared case-insensitively
+func getLockName(wwn string) string {
+	return lockPrefix + hash(strings.ToLower(wwn))
+}
+
+// getHolderIdentity returns the holder identity of the leases of a DiskClaim: its namespace, name and UID
+func getHolderIdentity(claim *localv1alpha1.DiskClaim) string {
+	return fmt.Sprintf("%s/%s/%s", claim.Namespace, claim.Name, claim.UID)
+}
+
+func parseHolderIdentity(holder string) (string, string, types.UID, bool) {
+	parts := strings.Split(holder, "/")
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], types.UID(parts[2]), true
+}
+
+// hash returns a string usable in an object name or a label value
+func hash(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:16])
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DiskClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// a deleted claim releases its WWN to the other claims of the WWN
+	enqueueSameWWN := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		deleted, ok := obj.(*localv1alpha1.DiskClaim)
+		if !ok {
+			return nil
+		}
+		claims := &localv1alpha1.DiskClaimList{}
+		if err := r.Client.List(ctx, claims); err != nil {
+			klog.ErrorS(err, "failed to list DiskClaims")
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, claim := range claims.Items {
+			if claim.UID != deleted.UID && strings.EqualFold(claim.Spec.WWN, deleted.Spec.WWN) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&claim)})
+			}
+		}
+		return requests
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&localv1alpha1.DiskClaim{}).
+		Watches(&localv1alpha1.DiskClaim{}, enqueueSameWWN, builder.WithPredicates(predicate.Funcs{
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		})).
+		Complete(r)
+}
@@ -0,0 +1,162 @@
+package diskclaim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const namespace = "purple-storage"
+
+func newDiskClaim(namespace, name, uid, wwn string) *localv1alpha1.DiskClaim {
+	return &localv1alpha1.DiskClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uid),
+		},
+		Spec: localv1alpha1.DiskClaimSpec{WWN: wwn},
+	}
+}
+
+func newFakeDiskClaimReconciler(t *testing.T, objs ...runtime.Object) *DiskClaimReconciler {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+	err = coordinationv1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding coordination to scheme")
+
+	client := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&localv1alpha1.DiskClaim{}).
+		WithRuntimeObjects(objs...).Build()
+
+	return &DiskClaimReconciler{
+		Client:    client,
+		Scheme:    scheme,
+		Namespace: namespace,
+	}
+}
+
+func reconcileDiskClaim(t *testing.T, r *DiskClaimReconciler, claim *localv1alpha1.DiskClaim) *localv1alpha1.DiskClaim {
+	key := client.ObjectKeyFromObject(claim)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+
+	updated := &localv1alpha1.DiskClaim{}
+	err = r.Client.Get(context.TODO(), key, updated)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return updated
+}
+
+func getLock(t *testing.T, r *DiskClaimReconciler, wwn string) *coordinationv1.Lease {
+	lock := &coordinationv1.Lease{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: getLockName(wwn)}, lock)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return lock
+}
+
+func TestDiskClaimReconciler(t *testing.T) {
+	const wwn = "0x5000c500a1b2c3d4"
+	first := newDiskClaim("team-a", "claim-sdb", "uid-1", wwn)
+	// both claims passed the webhook before either was stored
+	second := newDiskClaim("team-b", "claim-sdb", "uid-2", "0x5000C500A1B2C3D4")
+	other := newDiskClaim("team-b", "claim-sdc", "uid-3", "0x5000c500a1b2c3d5")
+	r := newFakeDiskClaimReconciler(t, first, second, other)
+
+	updated := reconcileDiskClaim(t, r, first)
+	assert.Equal(t, localv1alpha1.DiskClaimReserved, updated.Status.Phase)
+	lock := getLock(t, r, wwn)
+	assert.NotNil(t, lock)
+	assert.Equal(t, "team-a/claim-sdb/uid-1", *lock.Spec.HolderIdentity)
+	assert.Equal(t, hash("team-a/claim-sdb"), lock.Labels[common.DiskClaimLockLabel])
+
+	// the lease of the WWN is shared whatever the case of the WWN
+	updated = reconcileDiskClaim(t, r, second)
+	assert.Equal(t, localv1alpha1.DiskClaimConflict, updated.Status.Phase)
+	assert.Equal(t, `WWN "0x5000C500A1B2C3D4" is reserved by DiskClaim team-a/claim-sdb`, updated.Status.Message)
+
+	updated = reconcileDiskClaim(t, r, other)
+	assert.Equal(t, localv1alpha1.DiskClaimReserved, updated.Status.Phase)
+
+	// reconciling again keeps the reservation
+	updated = reconcileDiskClaim(t, r, first)
+	assert.Equal(t, localv1alpha1.DiskClaimReserved, updated.Status.Phase)
+
+	// the deleted claim releases the WWN to the other claim
+	err := r.Client.Delete(context.TODO(), first)
+	assert.NoError(t, err)
+	assert.Nil(t, reconcileDiskClaim(t, r, first))
+	assert.Nil(t, getLock(t, r, wwn))
+	assert.NotNil(t, getLock(t, r, other.Spec.WWN))
+
+	updated = reconcileDiskClaim(t, r, second)
+	assert.Equal(t, localv1alpha1.DiskClaimReserved, updated.Status.Phase)
+	lock = getLock(t, r, wwn)
+	assert.Equal(t, "team-b/claim-sdb/uid-2", *lock.Spec.HolderIdentity)
+}
+
+func TestDiskClaimReconcilerStaleLock(t *testing.T) {
+	const wwn = "0x5000c500a1b2c3d4"
+	holder := func(identity string) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: getLockName(wwn), Namespace: namespace},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &identity},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		lock     *coordinationv1.Lease
+		objs     []runtime.Object
+		expected localv1alpha1.DiskClaimPhase
+		holder   string
+	}{
+		{
+			name:     "case 1: the lease of a claim deleted while the operator was down is taken over",
+			lock:     holder("team-a/claim-sdb/uid-1"),
+			expected: localv1alpha1.DiskClaimReserved,
+			holder:   "team-b/claim-sdb/uid-2",
+		},
+		{
+			name:     "case 2: a claim recreated with the same name doesn't hold the lease of its predecessor",
+			lock:     holder("team-a/claim-sdb/uid-0"),
+			objs:     []runtime.Object{newDiskClaim("team-a", "claim-sdb", "uid-1", "0x5000c500a1b2c3d5")},
+			expected: localv1alpha1.DiskClaimReserved,
+			holder:   "team-b/claim-sdb/uid-2",
+		},
+		{
+			name:     "case 3: the lease of an existing claim is kept",
+			lock:     holder("team-a/claim-sdb/uid-1"),
+			objs:     []runtime.Object{newDiskClaim("team-a", "claim-sdb", "uid-1", wwn)},
+			expected: localv1alpha1.DiskClaimConflict,
+			holder:   "team-a/claim-sdb/uid-1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claim := newDiskClaim("team-b", "claim-sdb", "uid-2", wwn)
+			r := newFakeDiskClaimReconciler(t, append(tc.objs, claim, tc.lock)...)
+
+			updated := reconcileDiskClaim(t, r, claim)
+			assert.Equal(t, tc.expected, updated.Status.Phase)
+			assert.Equal(t, tc.holder, *getLock(t, r, wwn).Spec.HolderIdentity)
+		})
+	}
+}
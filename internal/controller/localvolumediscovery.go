@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+// NewLocalVolumeDiscovery returns the LocalVolumeDiscovery of the PurpleStorage, in its namespace
+func NewLocalVolumeDiscovery(purplestorage *purplev1alpha1.PurpleStorage) *purplev1alpha1.LocalVolumeDiscovery {
+	return &purplev1alpha1.LocalVolumeDiscovery{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.LocalVolumeDiscoveryName,
+			Namespace: purplestorage.Namespace,
+		},
+	}
+}
+
+// isLocalVolumeDiscoveryManaged returns true if the NodeSpec of the PurpleStorage must be copied to the
+// LocalVolumeDiscovery: the discovery is created or owned by the PurpleStorage, or the NodeSpec is set.
+// A discovery created by the user before is left alone while the NodeSpec is empty.
+func isLocalVolumeDiscoveryManaged(lvd *purplev1alpha1.LocalVolumeDiscovery, purplestorage *purplev1alpha1.PurpleStorage) bool {
+	if lvd.ResourceVersion == "" || metav1.IsControlledBy(lvd, purplestorage) {
+		return true
+	}
+	nodeSpec := purplestorage.Spec.NodeSpec
+	return (nodeSpec.Selector != nil && len(nodeSpec.Selector.NodeSelectorTerms) > 0) || len(nodeSpec.Tolerations) > 0
+}
+
+// setLocalVolumeDiscoverySpec copies the NodeSpec of the PurpleStorage to the LocalVolumeDiscovery.
+// The other settings of the discovery are left to the user.
+func setLocalVolumeDiscoverySpec(lvd *purplev1alpha1.LocalVolumeDiscovery, nodeSpec purplev1alpha1.NodeSpec) {
+	lvd.Spec.NodeSelector = nodeSpec.Selector.DeepCopy()
+	lvd.Spec.Tolerations = nil
+	for _, toleration := range nodeSpec.Tolerations {
+		lvd.Spec.Tolerations = append(lvd.Spec.Tolerations, *toleration.DeepCopy())
+	}
+}
+
+// getDaemonNodeSelector returns the nodeSelector of the IBM daemons. An explicit daemon_nodeSelector
+// wins, otherwise the selector of the NodeSpec is used so that the nodes are only declared once.
+func getDaemonNodeSelector(purplestorage *purplev1alpha1.PurpleStorage) (map[string]string, error) {
+	if len(purplestorage.Spec.Cluster.Daemon_nodeSelector) > 0 {
+		return maps.Clone(purplestorage.Spec.Cluster.Daemon_nodeSelector), nil
+	}
+	return nodeSelectorToLabels(purplestorage.Spec.NodeSpec.Selector)
+}
+
+// nodeSelectorToLabels translates a NodeSelector to the equivalent label map. Only a single term whose
+// expressions all use the In operator with one value can be expressed as labels.
+func nodeSelectorToLabels(selector *corev1.NodeSelector) (map[string]string, error) {
+	if selector == nil || len(selector.NodeSelectorTerms) == 0 {
+		return nil, nil
+	}
+	if len(selector.NodeSelectorTerms) > 1 {
+		return nil, fmt.Errorf("node selector with %d terms can not be expressed as labels", len(selector.NodeSelectorTerms))
+	}
+
+	term := selector.NodeSelectorTerms[0]
+	if len(term.MatchFields) > 0 {
+		return nil, fmt.Errorf("node selector with matchFields can not be expressed as labels")
+	}
+	labels := map[string]string{}
+	for _, expression := range term.MatchExpressions {
+		if expression.Operator != corev1.NodeSelectorOpIn || len(expression.Values) != 1 {
+			return nil, fmt.Errorf("node selector expression on %q can not be expressed as a label, only the In operator with a single value can",
+				expression.Key)
+		}
+		if value, ok := labels[expression.Key]; ok && value != expression.Values[0] {
+			return nil, fmt.Errorf("node selector requires label %q to be both %q and %q", expression.Key, value, expression.Values[0])
+		}
+		labels[expression.Key] = expression.Values[0]
+	}
+	return labels, nil
+}
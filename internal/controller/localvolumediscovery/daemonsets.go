@@ -2,7 +2,9 @@ package localvolumediscovery
 
 import (
 	"context"
-	"os"
+	"maps"
+	"slices"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -11,6 +13,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// lastAppliedPodAnnotations is the pod template annotation with the comma-separated keys of the other pod
+// annotations set by the operator
+const lastAppliedPodAnnotations = "purple.purplestorage.com/last-applied-pod-annotations"
+
 // daemonsets are defined as: daemonSetMutateFn func(*appsv1.DaemonSet) error
 // the function mutates whichever part of the daemonset it needs.
 // if the daemonset does not exist, the mutate func will be run on an empty DaemonSet which will be created
@@ -80,11 +86,8 @@ func MutateAggregatedSpec(
 	for key, value := range dsTemplate.Spec.Template.ObjectMeta.Labels {
 		ds.Spec.Template.ObjectMeta.Labels[key] = value
 	}
-	// add management workload annotations
-	initMapIfNil(&ds.Spec.Template.ObjectMeta.Annotations)
-	for key, value := range dsTemplate.Spec.Template.ObjectMeta.Annotations {
-		ds.Spec.Template.ObjectMeta.Annotations[key] = value
-	}
+	// management workload and user annotations
+	mergePodAnnotations(ds, dsTemplate.Spec.Template.ObjectMeta.Annotations)
 
 	// ownerRefs
 	ds.ObjectMeta.OwnerReferences = ownerRefs
@@ -93,10 +96,7 @@ func MutateAggregatedSpec(
 	ds.Spec.Template.Spec.ServiceAccountName = dsTemplate.Spec.Template.Spec.ServiceAccountName
 
 	// priority class
-
-	if os.Getenv("PRIORITY_CLASS_NAME") != "" {
-		ds.Spec.Template.Spec.PriorityClassName = os.Getenv("PRIORITY_CLASS_NAME")
-	}
+	ds.Spec.Template.Spec.PriorityClassName = dsTemplate.Spec.Template.Spec.PriorityClassName
 
 	// tolerations
 	ds.Spec.Template.Spec.Tolerations = tolerations
@@ -124,6 +124,26 @@ func MutateAggregatedSpec(
 	ds.Spec.Template.Spec.HostPID = dsTemplate.Spec.Template.Spec.HostPID
 }
 
+// mergePodAnnotations sets the annotations on the pod template of the daemonset. The other annotations, like the
+// restartedAt of kubectl rollout restart, are kept, only the annotations previously set by the operator and no
+// longer wanted are removed.
+func mergePodAnnotations(ds *appsv1.DaemonSet, annotations map[string]string) {
+	podAnnotations := ds.Spec.Template.ObjectMeta.Annotations
+	if podAnnotations == nil {
+		podAnnotations = map[string]string{}
+	}
+	for _, key := range strings.Split(podAnnotations[lastAppliedPodAnnotations], ",") {
+		if _, ok := annotations[key]; !ok {
+			delete(podAnnotations, key)
+		}
+	}
+	for key, value := range annotations {
+		podAnnotations[key] = value
+	}
+	podAnnotations[lastAppliedPodAnnotations] = strings.Join(slices.Sorted(maps.Keys(annotations)), ",")
+	ds.Spec.Template.ObjectMeta.Annotations = podAnnotations
+}
+
 func initMapIfNil(m *map[string]string) {
 	if len(*m) > 1 {
 		return
@@ -18,7 +18,13 @@ package localvolumediscovery
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -32,9 +38,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	v1helper "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -47,6 +54,8 @@ var (
 
 const (
 	DiskMakerDiscovery = "diskmaker-discovery"
+	// maxDaemonSetNameLength keeps the name of the daemonset usable as the value of its app label
+	maxDaemonSetNameLength = validation.DNS1123LabelMaxLength
 )
 
 // LocalVolumeDiscoveryReconciler reconciles a LocalVolumeDiscovery object
@@ -62,6 +71,8 @@ type LocalVolumeDiscoveryReconciler struct {
 // This is needed for the binary running in the containers (daemonset) to sync the results
 //+kubebuilder:rbac:groups=purple.purplestorage.com,resources=localvolumediscoveryresults,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=purple.purplestorage.com,resources=localvolumediscoveryresults/status,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=diskpreparerequests,verbs=get;list;watch
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=diskpreparerequests/status,verbs=get;update;patch
 
 // Reconcile reads that state of the cluster for a LocalVolumeDiscovery object and makes changes based on the state read
 // and what is in the LocalVolumeDiscovery.Spec
@@ -84,10 +95,17 @@ func (r *LocalVolumeDiscoveryReconciler) Reconcile(ctx context.Context, request
 		return ctrl.Result{}, err
 	}
 
-	diskMakerDSMutateFn := getDiskMakerDiscoveryDSMutateFn(request, instance.Spec.Tolerations,
-		getEnvVars(instance.Name, string(instance.UID)),
-		getOwnerRefs(instance),
-		instance.Spec.NodeSelector)
+	// report the nodes that are also selected by other discoveries, their daemons would share the results
+	err = r.updateOverlapCondition(ctx, instance)
+	if err != nil {
+		klog.ErrorS(err, "failed to check the overlap of the node selectors")
+		return ctrl.Result{}, err
+	}
+
+	dsName := getDiscoveryDaemonSetName(instance.Name)
+	diskMakerDSMutateFn := getDiskMakerDiscoveryDSMutateFn(request, dsName, instance.Spec,
+		getEnvVars(instance.Name, string(instance.UID), instance.Spec),
+		getOwnerRefs(instance))
 	ds, opResult, err := CreateOrUpdateDaemonset(ctx, r.Client, diskMakerDSMutateFn)
 	if err != nil {
 		message := fmt.Sprintf("failed to create discovery daemonset. Error %+v", err)
@@ -100,7 +118,20 @@ func (r *LocalVolumeDiscoveryReconciler) Reconcile(ctx context.Context, request
 		klog.InfoS("daemonset changed", "daemonset.Name", ds.GetName(), "op.Result", opResult)
 	}
 
-	desiredDaemons, readyDaemons, err := r.getDaemonSetStatus(ctx, instance.Namespace)
+	// the results are cleaned up before the daemons are checked, the daemons of lost nodes are never ready
+	klog.Info("deleting orphan discovery result instances")
+	err = r.deleteOrphanDiscoveryResults(ctx, instance, time.Now())
+	if err != nil {
+		klog.ErrorS(err, "failed to delete orphan discovery results")
+		return ctrl.Result{}, err
+	}
+	err = r.updateDiscoverySummary(ctx, instance)
+	if err != nil {
+		klog.ErrorS(err, "failed to update the discovery summary")
+		return ctrl.Result{}, err
+	}
+
+	desiredDaemons, readyDaemons, err := r.getDaemonSetStatus(ctx, instance.Namespace, dsName)
 	if err != nil {
 		klog.ErrorS(err, "failed to get discovery daemonset")
 		return ctrl.Result{}, err
@@ -132,41 +163,64 @@ func (r *LocalVolumeDiscoveryReconciler) Reconcile(ctx context.Context, request
 		return ctrl.Result{}, err
 	}
 
-	klog.Info("deleting orphan discovery result instances")
-	err = r.deleteOrphanDiscoveryResults(ctx, instance)
-	if err != nil {
-		klog.ErrorS(err, "failed to delete orphan discovery results")
-		return ctrl.Result{}, err
-	}
+	// come back to delete the results that reach their TTL
+	return ctrl.Result{RequeueAfter: resultCleanupInterval}, nil
+}
 
-	return ctrl.Result{}, nil
+// getDiscoveryDaemonSetName returns the name of the discovery daemonset of a LocalVolumeDiscovery.
+// The daemonset of the default discovery keeps its historical name.
+func getDiscoveryDaemonSetName(lvdName string) string {
+	if lvdName == common.LocalVolumeDiscoveryName {
+		return DiskMakerDiscovery
+	}
+	name := fmt.Sprintf("%s-%s", DiskMakerDiscovery, lvdName)
+	if len(name) > maxDaemonSetNameLength {
+		h := sha256.Sum256([]byte(lvdName))
+		suffix := hex.EncodeToString(h[:4])
+		name = strings.TrimRight(name[:maxDaemonSetNameLength-len(suffix)-1], "-.") + "-" + suffix
+	}
+	return name
 }
 
 func getDiskMakerDiscoveryDSMutateFn(request reconcile.Request,
-	tolerations []corev1.Toleration,
+	dsName string,
+	spec localv1alpha1.LocalVolumeDiscoverySpec,
 	envVars []corev1.EnvVar,
-	ownerRefs []metav1.OwnerReference,
-	nodeSelector *corev1.NodeSelector) func(*appsv1.DaemonSet) error {
+	ownerRefs []metav1.OwnerReference) func(*appsv1.DaemonSet) error {
 	return func(ds *appsv1.DaemonSet) error {
+		// the priority class of the discovery wins over the one of the operator
+		priorityClassName := spec.PriorityClassName
+		if priorityClassName == "" {
+			priorityClassName = os.Getenv("PRIORITY_CLASS_NAME")
+		}
+		imagePullPolicy := spec.ImagePullPolicy
+		if imagePullPolicy == "" {
+			imagePullPolicy = corev1.PullAlways
+		}
+
 		// read template for default values
 		dsBytes, err := assets.ReadFileAndReplace(
 			common.DiskMakerDiscoveryDaemonSetTemplate,
 			[]string{
 				"${OBJECT_NAMESPACE}", request.Namespace,
+				"${DAEMONSET_NAME}", dsName,
 				"${CONTAINER_IMAGE}", common.GetDiskMakerImage(),
 				"${RBAC_PROXY_IMAGE}", common.GetKubeRBACProxyImage(),
+				"${PRIORITY_CLASS_NAME}", priorityClassName,
+				"${IMAGE_PULL_POLICY}", string(imagePullPolicy),
 			},
 		)
 		if err != nil {
 			return err
 		}
 		dsTemplate := resourceread.ReadDaemonSetV1OrDie(dsBytes)
+		applyDaemonSetSettings(dsTemplate, spec)
 
 		MutateAggregatedSpec(
 			ds,
-			tolerations,
+			spec.Tolerations,
 			ownerRefs,
-			nodeSelector,
+			spec.NodeSelector,
 			dsTemplate,
 		)
 
@@ -176,6 +230,28 @@ func getDiskMakerDiscoveryDSMutateFn(request reconcile.Request,
 	}
 }
 
+// applyDaemonSetSettings overrides the defaults of the template with the settings of the discovery
+func applyDaemonSetSettings(dsTemplate *appsv1.DaemonSet, spec localv1alpha1.LocalVolumeDiscoverySpec) {
+	if spec.Resources != nil {
+		dsTemplate.Spec.Template.Spec.Containers[0].Resources = *spec.Resources.DeepCopy()
+	}
+	if spec.MaxUnavailable != nil && dsTemplate.Spec.UpdateStrategy.RollingUpdate != nil {
+		maxUnavailable := *spec.MaxUnavailable
+		dsTemplate.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+	}
+	if len(spec.Annotations) > 0 {
+		if dsTemplate.Spec.Template.Annotations == nil {
+			dsTemplate.Spec.Template.Annotations = map[string]string{}
+		}
+		// the annotations of the template are needed by the platform and are kept
+		for key, value := range spec.Annotations {
+			if _, ok := dsTemplate.Spec.Template.Annotations[key]; !ok {
+				dsTemplate.Spec.Template.Annotations[key] = value
+			}
+		}
+	}
+}
+
 // updateDiscoveryStatus updates the discovery state with conditions and phase
 func (r *LocalVolumeDiscoveryReconciler) updateDiscoveryStatus(ctx context.Context, instance *localv1alpha1.LocalVolumeDiscovery,
 	conditionType, message string,
@@ -190,6 +266,10 @@ func (r *LocalVolumeDiscoveryReconciler) updateDiscoveryStatus(ctx context.Conte
 			LastTransitionTime: metav1.Now(),
 		}
 		newConditions := []operatorv1.OperatorCondition{condition}
+		// the overlap of the node selectors is reported independently of the daemons
+		if overlap := findCondition(instance.Status.Conditions, NodeSelectorOverlapCondition); overlap != nil {
+			newConditions = append(newConditions, *overlap)
+		}
 		instance.Status.Conditions = newConditions
 		instance.Status.Phase = phase
 		instance.Status.ObservedGeneration = instance.Generation
@@ -202,41 +282,6 @@ func (r *LocalVolumeDiscoveryReconciler) updateDiscoveryStatus(ctx context.Conte
 	return nil
 }
 
-func (r *LocalVolumeDiscoveryReconciler) deleteOrphanDiscoveryResults(ctx context.Context, instance *localv1alpha1.LocalVolumeDiscovery) error {
-	if instance.Spec.NodeSelector == nil || len(instance.Spec.NodeSelector.NodeSelectorTerms) == 0 {
-		klog.Info("skip deleting orphan discovery results as no NodeSelectors are provided")
-		return nil
-	}
-
-	discoveryResultList := &localv1alpha1.LocalVolumeDiscoveryResultList{}
-	err := r.Client.List(ctx, discoveryResultList, client.InNamespace(instance.Namespace))
-	if err != nil {
-		return fmt.Errorf("failed to list LocalVolumeDiscoveryResult instances in namespace %q", instance.Namespace)
-	}
-
-	for _, discoveryResult := range discoveryResultList.Items {
-		nodeName := discoveryResult.Spec.NodeName
-		node := &corev1.Node{}
-		err = r.Client.Get(ctx, types.NamespacedName{Name: nodeName}, node)
-		if err != nil {
-			return fmt.Errorf("failed to get instance of node %q", nodeName)
-		}
-
-		matches, err := v1helper.MatchNodeSelectorTerms(node, instance.Spec.NodeSelector)
-		if err != nil {
-			return err
-		}
-		if !matches {
-			err := r.Client.Delete(ctx, discoveryResult.DeepCopy())
-			if err != nil {
-				return fmt.Errorf("failed to delete orphan discovery result %q in node %q", discoveryResult.Name, nodeName)
-			}
-		}
-	}
-
-	return nil
-}
-
 func (r *LocalVolumeDiscoveryReconciler) updateStatus(ctx context.Context, lvd *localv1alpha1.LocalVolumeDiscovery) error {
 	err := r.Client.Status().Update(ctx, lvd)
 	if err != nil {
@@ -246,9 +291,9 @@ func (r *LocalVolumeDiscoveryReconciler) updateStatus(ctx context.Context, lvd *
 	return nil
 }
 
-func (r *LocalVolumeDiscoveryReconciler) getDaemonSetStatus(ctx context.Context, namespace string) (int32, int32, error) {
+func (r *LocalVolumeDiscoveryReconciler) getDaemonSetStatus(ctx context.Context, namespace, name string) (int32, int32, error) {
 	existingDS := &appsv1.DaemonSet{}
-	err := r.Client.Get(ctx, types.NamespacedName{Name: DiskMakerDiscovery, Namespace: namespace}, existingDS)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, existingDS)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -269,8 +314,8 @@ func getOwnerRefs(cr *localv1alpha1.LocalVolumeDiscovery) []metav1.OwnerReferenc
 	}
 }
 
-func getEnvVars(objName, uid string) []corev1.EnvVar {
-	return []corev1.EnvVar{
+func getEnvVars(objName, uid string, spec localv1alpha1.LocalVolumeDiscoverySpec) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
 		{
 			Name:  "DISCOVERY_OBJECT_UID",
 			Value: uid,
@@ -280,6 +325,26 @@ func getEnvVars(objName, uid string) []corev1.EnvVar {
 			Value: objName,
 		},
 	}
+
+	// discovery settings, the daemon uses its defaults for the unset ones
+	if spec.ProbeInterval != nil {
+		envVars = append(envVars, corev1.EnvVar{Name: common.ProbeIntervalEnv, Value: spec.ProbeInterval.Duration.String()})
+	}
+	if spec.UdevEventPeriod != nil {
+		envVars = append(envVars, corev1.EnvVar{Name: common.UdevEventPeriodEnv, Value: spec.UdevEventPeriod.Duration.String()})
+	}
+	if spec.UdevMonitoringEnabled != nil {
+		envVars = append(envVars, corev1.EnvVar{Name: common.UdevMonitoringEnabledEnv, Value: strconv.FormatBool(*spec.UdevMonitoringEnabled)})
+	}
+	if spec.UdevExclusionFilter != nil {
+		// the regexes may contain any character, so they are passed as a JSON list
+		filter, err := json.Marshal(spec.UdevExclusionFilter)
+		if err == nil {
+			envVars = append(envVars, corev1.EnvVar{Name: common.UdevExclusionFilterEnv, Value: string(filter)})
+		}
+	}
+
+	return envVars
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -287,5 +352,11 @@ func (r *LocalVolumeDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) erro
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&localv1alpha1.LocalVolumeDiscovery{}).
 		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &localv1alpha1.LocalVolumeDiscovery{})).
+		// the devices of the results are aggregated in the status of their discovery
+		Watches(&localv1alpha1.LocalVolumeDiscoveryResult{},
+			handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &localv1alpha1.LocalVolumeDiscovery{}),
+			builder.WithPredicates(discoveryResultChanged())).
+		// a change of the node selector of a discovery can start or end an overlap with the others
+		Watches(&localv1alpha1.LocalVolumeDiscovery{}, handler.EnqueueRequestsFromMapFunc(r.enqueueOtherDiscoveries)).
 		Complete(r)
 }
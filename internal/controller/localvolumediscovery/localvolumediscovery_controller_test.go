@@ -3,16 +3,20 @@ package localvolumediscovery
 import (
 	"context"
 	"testing"
+	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/stretchr/testify/assert"
 	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -70,6 +74,7 @@ var localVolumeDiscoveryCR = localv1alpha1.LocalVolumeDiscovery{
 	ObjectMeta: metav1.ObjectMeta{
 		Name:      name,
 		Namespace: namespace,
+		UID:       "discovery-uid",
 	},
 	TypeMeta: metav1.TypeMeta{
 		Kind: "LocalVolumeDiscovery",
@@ -98,6 +103,9 @@ var localVolumeDiscoveryResultList = localv1alpha1.LocalVolumeDiscoveryResultLis
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "discovery-result-node1",
 				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "LocalVolumeDiscovery", Name: name, UID: "discovery-uid"},
+				},
 			},
 			Spec: localv1alpha1.LocalVolumeDiscoveryResultSpec{
 				NodeName: "Node1",
@@ -107,6 +115,9 @@ var localVolumeDiscoveryResultList = localv1alpha1.LocalVolumeDiscoveryResultLis
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "discovery-result-node2",
 				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "LocalVolumeDiscovery", Name: name, UID: "discovery-uid"},
+				},
 			},
 			Spec: localv1alpha1.LocalVolumeDiscoveryResultSpec{
 				NodeName: "Node2",
@@ -271,7 +282,7 @@ func TestDeleteOrphanDiscoveryResults(t *testing.T) {
 	// update discovery CR to remove "Node2"
 	discoveryObj.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node1"}
 	fakeReconciler = newFakeLocalVolumeDiscoveryReconciler(t, objects...)
-	err = fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj)
+	err = fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj, time.Now())
 	assert.NoError(t, err)
 	// assert that discovery result object on "Node2" is deleted
 	results = &localv1alpha1.LocalVolumeDiscoveryResultList{}
@@ -280,10 +291,351 @@ func TestDeleteOrphanDiscoveryResults(t *testing.T) {
 	assert.Equal(t, 1, len(results.Items))
 	assert.Equal(t, "Node1", results.Items[0].Spec.NodeName)
 
-	// skip deletion of orphan results when no NodeSelector is provided
+	// a discovery without NodeSelector runs on all the nodes
 	discoveryObj.Spec = localv1alpha1.LocalVolumeDiscoverySpec{}
-	err = fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj)
+	err = fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj, time.Now())
+	assert.NoError(t, err)
+	results = &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err = fakeReconciler.Client.List(context.TODO(), results, client.InNamespace(namespace))
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(results.Items))
 	assert.Equal(t, "Node1", results.Items[0].Spec.NodeName)
 }
+
+func TestDeleteStaleDiscoveryResults(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	nodeList := &corev1.NodeList{}
+	mockNodeList.DeepCopyInto(nodeList)
+	discoveryObj := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(discoveryObj)
+	discoveryObj.Spec.NodeSelector = nil
+	discoveryObj.Spec.ResultTTL = &metav1.Duration{Duration: 2 * time.Hour}
+
+	newResult := func(nodeName string, age time.Duration) *localv1alpha1.LocalVolumeDiscoveryResult {
+		return &localv1alpha1.LocalVolumeDiscoveryResult{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "discovery-result-" + nodeName,
+				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "LocalVolumeDiscovery", Name: name, UID: "discovery-uid"},
+				},
+			},
+			Spec: localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: nodeName},
+			Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+				DiscoveredTimeStamp: now.Add(-age).Format(time.RFC3339),
+			},
+		}
+	}
+	objects := []runtime.Object{
+		nodeList, discoveryObj,
+		newResult("Node1", time.Minute),     // refreshed by its daemon
+		newResult("Node2", 30*time.Minute),  // late, reported
+		newResult("Node3", time.Minute),     // the node was deleted
+		newResult("Node1-old", 3*time.Hour), // past the TTL
+	}
+	// the node of the result past the TTL still exists, its daemon stopped
+	nodeList.Items = append(nodeList.Items, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node1-old"}})
+
+	fakeReconciler := newFakeLocalVolumeDiscoveryReconciler(t, objects...)
+	err := fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj, now)
+	assert.NoError(t, err)
+
+	results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err = fakeReconciler.Client.List(context.TODO(), results, client.InNamespace(namespace))
+	assert.NoError(t, err)
+	nodeNames := []string{}
+	for _, result := range results.Items {
+		nodeNames = append(nodeNames, result.Spec.NodeName)
+	}
+	assert.ElementsMatch(t, []string{"Node1", "Node2"}, nodeNames)
+
+	err = fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, discoveryObj)
+	assert.NoError(t, err)
+	assert.Equal(t, []localv1alpha1.StaleDiscoveryResult{{
+		Name:                "discovery-result-Node2",
+		NodeName:            "Node2",
+		DiscoveredTimeStamp: now.Add(-30 * time.Minute).Format(time.RFC3339),
+		Reason:              "not refreshed for 30m0s",
+	}}, discoveryObj.Status.StaleResults)
+
+	// the late result is refreshed again
+	result := &localv1alpha1.LocalVolumeDiscoveryResult{}
+	err = fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "discovery-result-Node2", Namespace: namespace}, result)
+	assert.NoError(t, err)
+	result.Status.DiscoveredTimeStamp = now.Format(time.RFC3339)
+	err = fakeReconciler.Client.Update(context.TODO(), result)
+	assert.NoError(t, err)
+	err = fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj, now)
+	assert.NoError(t, err)
+	assert.Nil(t, discoveryObj.Status.StaleResults)
+}
+
+func TestGetResultTimeouts(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+	testcases := []struct {
+		label         string
+		spec          localv1alpha1.LocalVolumeDiscoverySpec
+		probeInterval time.Duration
+		ttl           time.Duration
+	}{
+		{
+			label:         "case 1", // defaults
+			probeInterval: 5 * time.Minute,
+			ttl:           time.Hour,
+		},
+		{
+			label:         "case 2", // the default TTL follows the probe interval
+			spec:          localv1alpha1.LocalVolumeDiscoverySpec{ProbeInterval: duration(30 * time.Minute)},
+			probeInterval: 30 * time.Minute,
+			ttl:           6 * time.Hour,
+		},
+		{
+			label: "case 3", // TTL set by the user
+			spec: localv1alpha1.LocalVolumeDiscoverySpec{
+				ProbeInterval: duration(30 * time.Minute), ResultTTL: duration(2 * time.Hour)},
+			probeInterval: 30 * time.Minute,
+			ttl:           2 * time.Hour,
+		},
+		{
+			label: "case 4", // TTL shorter than the probe interval would delete the results of running daemons
+			spec: localv1alpha1.LocalVolumeDiscoverySpec{
+				ProbeInterval: duration(2 * time.Hour), ResultTTL: duration(time.Hour)},
+			probeInterval: 2 * time.Hour,
+			ttl:           24 * time.Hour,
+		},
+		{
+			label: "case 5", // TTL equal to the stale threshold
+			spec: localv1alpha1.LocalVolumeDiscoverySpec{
+				ProbeInterval: duration(10 * time.Minute), ResultTTL: duration(30 * time.Minute)},
+			probeInterval: 10 * time.Minute,
+			ttl:           2 * time.Hour,
+		},
+		{
+			label:         "case 6", // invalid probe interval
+			spec:          localv1alpha1.LocalVolumeDiscoverySpec{ProbeInterval: duration(0)},
+			probeInterval: 5 * time.Minute,
+			ttl:           time.Hour,
+		},
+	}
+
+	for _, tc := range testcases {
+		discoveryObj := &localv1alpha1.LocalVolumeDiscovery{Spec: tc.spec}
+		probeInterval, ttl := getResultTimeouts(discoveryObj)
+		assert.Equalf(t, tc.probeInterval, probeInterval, "[%s] invalid probe interval", tc.label)
+		assert.Equalf(t, tc.ttl, ttl, "[%s] invalid TTL", tc.label)
+	}
+}
+
+func TestGetEnvVars(t *testing.T) {
+	// only the discovery object is passed when no settings are provided
+	envVars := getEnvVars(name, "uid", localv1alpha1.LocalVolumeDiscoverySpec{})
+	assert.Len(t, envVars, 2)
+
+	disabled := false
+	spec := localv1alpha1.LocalVolumeDiscoverySpec{
+		ProbeInterval:         &metav1.Duration{Duration: 30 * time.Minute},
+		UdevEventPeriod:       &metav1.Duration{Duration: 10 * time.Second},
+		UdevMonitoringEnabled: &disabled,
+		UdevExclusionFilter:   []string{"(?i)dm-[0-9]+", "(?i)loop[0-9]+"},
+	}
+	envVars = getEnvVars(name, "uid", spec)
+	values := map[string]string{}
+	for _, env := range envVars {
+		values[env.Name] = env.Value
+	}
+	assert.Equal(t, "30m0s", values[common.ProbeIntervalEnv])
+	assert.Equal(t, "10s", values[common.UdevEventPeriodEnv])
+	assert.Equal(t, "false", values[common.UdevMonitoringEnabledEnv])
+	assert.Equal(t, `["(?i)dm-[0-9]+","(?i)loop[0-9]+"]`, values[common.UdevExclusionFilterEnv])
+}
+
+func TestGetDiscoveryDaemonSetName(t *testing.T) {
+	// the default discovery keeps the historical daemonset
+	assert.Equal(t, DiskMakerDiscovery, getDiscoveryDaemonSetName(common.LocalVolumeDiscoveryName))
+	assert.Equal(t, "diskmaker-discovery-san-nodes", getDiscoveryDaemonSetName("san-nodes"))
+
+	// long names are hashed to remain a valid label value
+	long := "nvme-nodes-of-the-second-datacenter-with-a-very-long-descriptive-name"
+	dsName := getDiscoveryDaemonSetName(long)
+	assert.LessOrEqual(t, len(dsName), 63)
+	assert.NotEqual(t, dsName, getDiscoveryDaemonSetName(long+"-2"))
+}
+
+func TestMultipleDiscoveries(t *testing.T) {
+	nodeList := &corev1.NodeList{}
+	mockNodeList.DeepCopyInto(nodeList)
+	sanDiscovery := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(sanDiscovery)
+	sanDiscovery.Name = "san-nodes"
+	sanDiscovery.UID = "san-uid"
+	sanDiscovery.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node1"}
+	nvmeDiscovery := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(nvmeDiscovery)
+	nvmeDiscovery.Name = "nvme-nodes"
+	nvmeDiscovery.UID = "nvme-uid"
+	nvmeDiscovery.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node2"}
+
+	fakeReconciler := newFakeLocalVolumeDiscoveryReconciler(t, nodeList, sanDiscovery, nvmeDiscovery)
+	for _, lvd := range []*localv1alpha1.LocalVolumeDiscovery{sanDiscovery, nvmeDiscovery} {
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: lvd.Name, Namespace: lvd.Namespace}}
+		_, _ = fakeReconciler.Reconcile(context.TODO(), req)
+	}
+
+	// each discovery has its own daemonset, running on its own nodes
+	for _, lvd := range []*localv1alpha1.LocalVolumeDiscovery{sanDiscovery, nvmeDiscovery} {
+		ds := &appsv1.DaemonSet{}
+		err := fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "diskmaker-discovery-" + lvd.Name, Namespace: namespace}, ds)
+		assert.NoError(t, err)
+		assert.Equal(t, "diskmaker-discovery-"+lvd.Name, ds.Spec.Selector.MatchLabels["app"])
+		assert.Equal(t, lvd.Spec.NodeSelector, ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		assert.Equal(t, lvd.UID, ds.OwnerReferences[0].UID)
+
+		// the selectors don't overlap
+		err = fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: lvd.Name, Namespace: namespace}, lvd)
+		assert.NoError(t, err)
+		assert.Nil(t, findCondition(lvd.Status.Conditions, NodeSelectorOverlapCondition))
+	}
+
+	// the nvme discovery now also selects Node1
+	nvmeDiscovery.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node1", "Node2"}
+	err := fakeReconciler.Client.Update(context.TODO(), nvmeDiscovery)
+	assert.NoError(t, err)
+	for _, lvd := range []*localv1alpha1.LocalVolumeDiscovery{sanDiscovery, nvmeDiscovery} {
+		err = fakeReconciler.updateOverlapCondition(context.TODO(), lvd)
+		assert.NoError(t, err)
+	}
+	condition := findCondition(sanDiscovery.Status.Conditions, NodeSelectorOverlapCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Equal(t, "nodes are also selected by other LocalVolumeDiscoveries: local-storage/nvme-nodes (Node1)", condition.Message)
+	condition = findCondition(nvmeDiscovery.Status.Conditions, NodeSelectorOverlapCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, "nodes are also selected by other LocalVolumeDiscoveries: local-storage/san-nodes (Node1)", condition.Message)
+
+	// the overlap is resolved
+	nvmeDiscovery.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node2"}
+	err = fakeReconciler.Client.Update(context.TODO(), nvmeDiscovery)
+	assert.NoError(t, err)
+	err = fakeReconciler.updateOverlapCondition(context.TODO(), sanDiscovery)
+	assert.NoError(t, err)
+	assert.Nil(t, findCondition(sanDiscovery.Status.Conditions, NodeSelectorOverlapCondition))
+}
+
+func TestDeleteOrphanDiscoveryResultsOfOtherDiscoveries(t *testing.T) {
+	nodeList := &corev1.NodeList{}
+	mockNodeList.DeepCopyInto(nodeList)
+	discoveryObj := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(discoveryObj)
+	discoveryObj.Spec.NodeSelector.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"Node1"}
+
+	// the result of Node2 belongs to another discovery
+	discoveryResults := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	localVolumeDiscoveryResultList.DeepCopyInto(discoveryResults)
+	discoveryResults.Items[1].OwnerReferences[0].Name = "nvme-nodes"
+	discoveryResults.Items[1].OwnerReferences[0].UID = "nvme-uid"
+
+	fakeReconciler := newFakeLocalVolumeDiscoveryReconciler(t, nodeList, discoveryObj, discoveryResults)
+	err := fakeReconciler.deleteOrphanDiscoveryResults(context.TODO(), discoveryObj, time.Now())
+	assert.NoError(t, err)
+	results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err = fakeReconciler.Client.List(context.TODO(), results, client.InNamespace(namespace))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(results.Items))
+}
+
+func TestIsOwnedBy(t *testing.T) {
+	discoveryObj := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(discoveryObj)
+	tests := []struct {
+		labels   map[string]string
+		ownerUID types.UID
+		expected bool
+	}{
+		{ // case 1: labeled with the name of the discovery
+			labels:   map[string]string{common.DiscoveryNameLabel: name},
+			ownerUID: "discovery-uid",
+			expected: true,
+		},
+		{ // case 2: result of another discovery on the same node
+			labels:   map[string]string{common.DiscoveryNameLabel: "nvme-nodes"},
+			ownerUID: "discovery-uid",
+		},
+		{ // case 3: result created before the discovery name label
+			ownerUID: "discovery-uid",
+			expected: true,
+		},
+		{ // case 4: owned by a previous discovery with the same name
+			labels:   map[string]string{common.DiscoveryNameLabel: name},
+			ownerUID: "previous-uid",
+		},
+	}
+	for i, test := range tests {
+		result := &localv1alpha1.LocalVolumeDiscoveryResult{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:          test.labels,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "LocalVolumeDiscovery", Name: name, UID: test.ownerUID}},
+			},
+		}
+		assert.Equalf(t, test.expected, isOwnedBy(result, discoveryObj), "case %d", i+1)
+	}
+}
+
+func TestDiscoveryDaemonSetSettings(t *testing.T) {
+	discoveryObj := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(discoveryObj)
+	maxUnavailable := intstr.FromInt32(2)
+	discoveryObj.Spec.Resources = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("200Mi")},
+	}
+	discoveryObj.Spec.PriorityClassName = "system-node-critical"
+	discoveryObj.Spec.ImagePullPolicy = corev1.PullIfNotPresent
+	discoveryObj.Spec.MaxUnavailable = &maxUnavailable
+	discoveryObj.Spec.Annotations = map[string]string{"example.com/team": "storage"}
+
+	fakeReconciler := newFakeLocalVolumeDiscoveryReconciler(t, discoveryObj)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: discoveryObj.Name, Namespace: discoveryObj.Namespace}}
+	_, _ = fakeReconciler.Reconcile(context.TODO(), req)
+
+	ds := &appsv1.DaemonSet{}
+	err := fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: DiskMakerDiscovery, Namespace: namespace}, ds)
+	assert.NoError(t, err)
+	podSpec := ds.Spec.Template.Spec
+	assert.Equal(t, *discoveryObj.Spec.Resources, podSpec.Containers[0].Resources)
+	assert.Equal(t, "system-node-critical", podSpec.PriorityClassName)
+	assert.Equal(t, corev1.PullIfNotPresent, podSpec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, maxUnavailable, *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+	assert.Equal(t, "storage", ds.Spec.Template.Annotations["example.com/team"])
+	assert.Contains(t, ds.Spec.Template.Annotations, "target.workload.openshift.io/management")
+
+	// the pod annotations set by others are kept
+	ds.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2025-06-01T12:00:00Z"
+	ds.Spec.Template.Annotations["example.com/scanner"] = "enabled"
+	err = fakeReconciler.Client.Update(context.TODO(), ds)
+	assert.NoError(t, err)
+
+	// the defaults are restored when the settings are removed
+	err = fakeReconciler.Client.Get(context.TODO(), req.NamespacedName, discoveryObj)
+	assert.NoError(t, err)
+	discoveryObj.Spec.Resources = nil
+	discoveryObj.Spec.PriorityClassName = ""
+	discoveryObj.Spec.ImagePullPolicy = ""
+	discoveryObj.Spec.MaxUnavailable = nil
+	discoveryObj.Spec.Annotations = nil
+	err = fakeReconciler.Client.Update(context.TODO(), discoveryObj)
+	assert.NoError(t, err)
+	_, _ = fakeReconciler.Reconcile(context.TODO(), req)
+
+	err = fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: DiskMakerDiscovery, Namespace: namespace}, ds)
+	assert.NoError(t, err)
+	podSpec = ds.Spec.Template.Spec
+	assert.Equal(t, resource.MustParse("50Mi"), podSpec.Containers[0].Resources.Requests[corev1.ResourceMemory])
+	assert.Empty(t, podSpec.Containers[0].Resources.Limits)
+	assert.Empty(t, podSpec.PriorityClassName)
+	assert.Equal(t, corev1.PullAlways, podSpec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, intstr.FromString("10%"), *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+	assert.NotContains(t, ds.Spec.Template.Annotations, "example.com/team")
+	assert.Contains(t, ds.Spec.Template.Annotations, "target.workload.openshift.io/management")
+	assert.Equal(t, "2025-06-01T12:00:00Z", ds.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"])
+	assert.Equal(t, "enabled", ds.Spec.Template.Annotations["example.com/scanner"])
+}
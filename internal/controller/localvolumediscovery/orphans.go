@@ -0,0 +1,151 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultProbeInterval matches the probe interval of the discovery daemons
+	defaultProbeInterval = 5 * time.Minute
+	// staleResultProbes is the number of probe intervals a result may go without refresh before it is reported
+	staleResultProbes = 3
+	// resultTTLProbes is the number of probe intervals a result may go without refresh before it is deleted,
+	// when the discovery doesn't set a TTL. It is 1h with the default probe interval
+	resultTTLProbes = 12
+	// resultCleanupInterval is how often the results are checked when the daemons are running
+	resultCleanupInterval = 5 * time.Minute
+)
+
+// deleteOrphanDiscoveryResults deletes the results of the discovery whose node is gone or no longer selected,
+// and the results that were not refreshed for the TTL. The results that are late or that can't be
+// cleaned up are reported in the status of the discovery.
+func (r *LocalVolumeDiscoveryReconciler) deleteOrphanDiscoveryResults(ctx context.Context, instance *localv1alpha1.LocalVolumeDiscovery,
+	now time.Time) error {
+	discoveryResultList := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err := r.Client.List(ctx, discoveryResultList, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return fmt.Errorf("failed to list LocalVolumeDiscoveryResult instances in namespace %q", instance.Namespace)
+	}
+
+	probeInterval, ttl := getResultTimeouts(instance)
+
+	staleResults := []localv1alpha1.StaleDiscoveryResult{}
+	for i := range discoveryResultList.Items {
+		discoveryResult := &discoveryResultList.Items[i]
+		// the results of the nodes of other discoveries are theirs to clean up
+		if !isOwnedBy(discoveryResult, instance) || discoveryResult.DeletionTimestamp != nil {
+			continue
+		}
+		stale := localv1alpha1.StaleDiscoveryResult{
+			Name:                discoveryResult.Name,
+			NodeName:            discoveryResult.Spec.NodeName,
+			DiscoveredTimeStamp: discoveryResult.Status.DiscoveredTimeStamp,
+		}
+
+		node := &corev1.Node{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: discoveryResult.Spec.NodeName}, node)
+		if err != nil && !errors.IsNotFound(err) {
+			stale.Reason = fmt.Sprintf("failed to get node: %v", err)
+			staleResults = append(staleResults, stale)
+			continue
+		}
+		if err != nil {
+			node = nil
+		}
+
+		reason, err := getOrphanReason(instance, discoveryResult, node, ttl, now)
+		if err != nil {
+			stale.Reason = err.Error()
+			staleResults = append(staleResults, stale)
+			continue
+		}
+		if reason != "" {
+			klog.InfoS("deleting orphan discovery result", "name", discoveryResult.Name, "node", discoveryResult.Spec.NodeName, "reason", reason)
+			err = r.Client.Delete(ctx, discoveryResult)
+			if err != nil && !errors.IsNotFound(err) {
+				stale.Reason = fmt.Sprintf("failed to delete orphan result (%s): %v", reason, err)
+				staleResults = append(staleResults, stale)
+			}
+			continue
+		}
+
+		if lastDiscovered, ok := getLastDiscovered(discoveryResult); ok && now.Sub(lastDiscovered) > staleResultProbes*probeInterval {
+			stale.Reason = fmt.Sprintf("not refreshed for %s", now.Sub(lastDiscovered).Round(time.Second))
+			staleResults = append(staleResults, stale)
+		}
+	}
+
+	if len(staleResults) == 0 {
+		staleResults = nil
+	}
+	if reflect.DeepEqual(instance.Status.StaleResults, staleResults) {
+		return nil
+	}
+	instance.Status.StaleResults = staleResults
+	return r.updateStatus(ctx, instance)
+}
+
+// getResultTimeouts returns the probe interval of the daemons and the TTL of the results of the discovery.
+// The TTL follows the probe interval. A TTL that doesn't exceed the time after which a result is
+// reported as stale would delete the results of running daemons, so it is ignored.
+func getResultTimeouts(instance *localv1alpha1.LocalVolumeDiscovery) (time.Duration, time.Duration) {
+	probeInterval := defaultProbeInterval
+	if instance.Spec.ProbeInterval != nil && instance.Spec.ProbeInterval.Duration > 0 {
+		probeInterval = instance.Spec.ProbeInterval.Duration
+	}
+	ttl := resultTTLProbes * probeInterval
+	if instance.Spec.ResultTTL != nil {
+		if instance.Spec.ResultTTL.Duration > staleResultProbes*probeInterval {
+			ttl = instance.Spec.ResultTTL.Duration
+		} else {
+			klog.InfoS("ignoring a result TTL that doesn't exceed 3 probe intervals", "name", instance.Name,
+				"resultTTL", instance.Spec.ResultTTL.Duration, "probeInterval", probeInterval, "ttl", ttl)
+		}
+	}
+	return probeInterval, ttl
+}
+
+// getOrphanReason returns why the result must be deleted, or an empty string when it must be kept.
+// A nil node is a node that no longer exists.
+func getOrphanReason(instance *localv1alpha1.LocalVolumeDiscovery, result *localv1alpha1.LocalVolumeDiscoveryResult,
+	node *corev1.Node, ttl time.Duration, now time.Time) (string, error) {
+	if node == nil {
+		return "node does not exist", nil
+	}
+	matches, err := matchesNode(instance, node)
+	if err != nil {
+		return "", err
+	}
+	if !matches {
+		return "node is not selected", nil
+	}
+	if lastDiscovered, ok := getLastDiscovered(result); ok && now.Sub(lastDiscovered) > ttl {
+		return fmt.Sprintf("not refreshed for more than %s", ttl), nil
+	}
+	return "", nil
+}
+
+// getLastDiscovered returns the last time the daemon refreshed the result. A result that was never
+// refreshed counts from its creation.
+func getLastDiscovered(result *localv1alpha1.LocalVolumeDiscoveryResult) (time.Time, bool) {
+	if result.Status.DiscoveredTimeStamp != "" {
+		timestamp, err := time.Parse(time.RFC3339, result.Status.DiscoveredTimeStamp)
+		if err == nil {
+			return timestamp, true
+		}
+	}
+	if !result.CreationTimestamp.IsZero() {
+		return result.CreationTimestamp.Time, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,175 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1helper "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// NodeSelectorOverlapCondition is set when some nodes of a LocalVolumeDiscovery are also selected by another one
+	NodeSelectorOverlapCondition = "NodeSelectorOverlap"
+	// maxOverlapNodes bounds the number of nodes listed per discovery in the condition message
+	maxOverlapNodes = 5
+)
+
+// updateOverlapCondition sets the NodeSelectorOverlap condition of the discovery when other discoveries select
+// some of its nodes, and removes it when they don't anymore
+func (r *LocalVolumeDiscoveryReconciler) updateOverlapCondition(ctx context.Context, instance *localv1alpha1.LocalVolumeDiscovery) error {
+	discoveries := &localv1alpha1.LocalVolumeDiscoveryList{}
+	err := r.Client.List(ctx, discoveries)
+	if err != nil {
+		return fmt.Errorf("failed to list LocalVolumeDiscovery instances: %w", err)
+	}
+	nodes := &corev1.NodeList{}
+	err = r.Client.List(ctx, nodes)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	overlaps, err := findOverlappingNodes(instance, discoveries.Items, nodes.Items)
+	if err != nil {
+		return err
+	}
+
+	conditions := []operatorv1.OperatorCondition{}
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type != NodeSelectorOverlapCondition {
+			conditions = append(conditions, condition)
+		}
+	}
+	current := findCondition(instance.Status.Conditions, NodeSelectorOverlapCondition)
+	if len(overlaps) > 0 {
+		message := getOverlapMessage(overlaps)
+		if current != nil && current.Message == message {
+			return nil
+		}
+		klog.InfoS("node selectors overlap", "namespace", instance.Namespace, "name", instance.Name, "message", message)
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:               NodeSelectorOverlapCondition,
+			Status:             operatorv1.ConditionTrue,
+			Reason:             "NodesSelectedByOtherDiscoveries",
+			Message:            message,
+			LastTransitionTime: metav1.Now(),
+		})
+	} else if current == nil {
+		return nil
+	}
+
+	instance.Status.Conditions = conditions
+	return r.updateStatus(ctx, instance)
+}
+
+// findOverlappingNodes returns the nodes selected by the instance and by other discoveries, by discovery.
+// A discovery without node selector runs on all the nodes.
+func findOverlappingNodes(instance *localv1alpha1.LocalVolumeDiscovery, discoveries []localv1alpha1.LocalVolumeDiscovery,
+	nodes []corev1.Node) (map[string][]string, error) {
+	overlaps := map[string][]string{}
+	for i := range nodes {
+		node := &nodes[i]
+		matches, err := matchesNode(instance, node)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		for j := range discoveries {
+			other := &discoveries[j]
+			if (other.Namespace == instance.Namespace && other.Name == instance.Name) || other.DeletionTimestamp != nil {
+				continue
+			}
+			matches, err := matchesNode(other, node)
+			if err != nil {
+				return nil, err
+			}
+			if matches {
+				key := types.NamespacedName{Namespace: other.Namespace, Name: other.Name}.String()
+				overlaps[key] = append(overlaps[key], node.Name)
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+func matchesNode(lvd *localv1alpha1.LocalVolumeDiscovery, node *corev1.Node) (bool, error) {
+	if lvd.Spec.NodeSelector == nil || len(lvd.Spec.NodeSelector.NodeSelectorTerms) == 0 {
+		return true, nil
+	}
+	return v1helper.MatchNodeSelectorTerms(node, lvd.Spec.NodeSelector)
+}
+
+// getOverlapMessage lists the overlapping discoveries and some of their nodes in a stable order
+func getOverlapMessage(overlaps map[string][]string) string {
+	keys := []string{}
+	for key := range overlaps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		nodes := overlaps[key]
+		sort.Strings(nodes)
+		listed := strings.Join(nodes, ", ")
+		if len(nodes) > maxOverlapNodes {
+			listed = fmt.Sprintf("%s and %d more", strings.Join(nodes[:maxOverlapNodes], ", "), len(nodes)-maxOverlapNodes)
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", key, listed))
+	}
+	return fmt.Sprintf("nodes are also selected by other LocalVolumeDiscoveries: %s", strings.Join(parts, "; "))
+}
+
+func findCondition(conditions []operatorv1.OperatorCondition, conditionType string) *operatorv1.OperatorCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// isOwnedBy returns true if the result was created by the daemons of the discovery. The results created before
+// the discovery name label only have the owner reference, the daemons add the label when they start.
+func isOwnedBy(result *localv1alpha1.LocalVolumeDiscoveryResult, instance *localv1alpha1.LocalVolumeDiscovery) bool {
+	if name, ok := result.Labels[common.DiscoveryNameLabel]; ok && name != instance.Name {
+		return false
+	}
+	for _, ref := range result.OwnerReferences {
+		if ref.Kind == "LocalVolumeDiscovery" && ref.UID == instance.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueOtherDiscoveries requests the reconciliation of all the discoveries but the changed one,
+// which is already reconciled
+func (r *LocalVolumeDiscoveryReconciler) enqueueOtherDiscoveries(ctx context.Context, obj client.Object) []reconcile.Request {
+	discoveries := &localv1alpha1.LocalVolumeDiscoveryList{}
+	err := r.Client.List(ctx, discoveries)
+	if err != nil {
+		klog.ErrorS(err, "failed to list LocalVolumeDiscovery instances")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, lvd := range discoveries.Items {
+		if lvd.Namespace == obj.GetNamespace() && lvd.Name == obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: lvd.Namespace, Name: lvd.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,104 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// updateDiscoverySummary aggregates the results of the discovery in its status
+func (r *LocalVolumeDiscoveryReconciler) updateDiscoverySummary(ctx context.Context, instance *localv1alpha1.LocalVolumeDiscovery) error {
+	discoveryResultList := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	err := r.Client.List(ctx, discoveryResultList, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return fmt.Errorf("failed to list LocalVolumeDiscoveryResult instances in namespace %q", instance.Namespace)
+	}
+
+	results := []localv1alpha1.LocalVolumeDiscoveryResult{}
+	for _, result := range discoveryResultList.Items {
+		if isOwnedBy(&result, instance) {
+			results = append(results, result)
+		}
+	}
+	summary, nodes := summarizeDiscoveryResults(results)
+	if reflect.DeepEqual(instance.Status.Summary, summary) && reflect.DeepEqual(instance.Status.Nodes, nodes) {
+		return nil
+	}
+	instance.Status.Summary = summary
+	instance.Status.Nodes = nodes
+	return r.updateStatus(ctx, instance)
+}
+
+// summarizeDiscoveryResults returns the summary of the distinct devices of the results and the summary of
+// each node, sorted by node name. The devices with a WWN are counted once whatever the number of nodes
+// and paths that see them, preferably as the multipath device. The devices without WWN are local to their node.
+func summarizeDiscoveryResults(results []localv1alpha1.LocalVolumeDiscoveryResult) (*localv1alpha1.DiscoverySummary, []localv1alpha1.NodeDiscoverySummary) {
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	devices := map[string]localv1alpha1.DiscoveredDevice{}
+	nodes := []localv1alpha1.NodeDiscoverySummary{}
+	for _, result := range results {
+		for _, device := range result.Status.DiscoveredDevices {
+			key := device.WWN
+			if key == "" {
+				key = result.Spec.NodeName + "/" + device.DeviceID
+			}
+			if known, ok := devices[key]; !ok || (device.Type == localv1alpha1.MultiPathType && known.Type != localv1alpha1.MultiPathType) {
+				devices[key] = device
+			}
+		}
+
+		// the primary result has the summary of the node, including the devices of its shards
+		if _, isShard := result.Labels[common.DiscoveryShardLabel]; isShard {
+			continue
+		}
+		node := localv1alpha1.NodeDiscoverySummary{
+			NodeName:            result.Spec.NodeName,
+			DiscoveredTimeStamp: result.Status.DiscoveredTimeStamp,
+		}
+		if result.Status.Summary != nil {
+			node.Summary = *result.Status.Summary
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].NodeName < nodes[j].NodeName
+	})
+
+	summary := &localv1alpha1.DiscoverySummary{}
+	for _, device := range devices {
+		summary.AddDevice(device)
+	}
+	if len(nodes) == 0 {
+		nodes = nil
+	}
+	return summary, nodes
+}
+
+// discoveryResultChanged filters out the updates of the results that only refresh their timestamps, the
+// summary is then refreshed with the periodic reconciliation
+func discoveryResultChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldResult, ok := e.ObjectOld.(*localv1alpha1.LocalVolumeDiscoveryResult)
+			if !ok {
+				return true
+			}
+			newResult, ok := e.ObjectNew.(*localv1alpha1.LocalVolumeDiscoveryResult)
+			if !ok {
+				return true
+			}
+			return !reflect.DeepEqual(oldResult.Status.Summary, newResult.Status.Summary) ||
+				len(oldResult.Status.DiscoveredDevices) != len(newResult.Status.DiscoveredDevices)
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package localvolumediscovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSummarizeDiscoveryResults(t *testing.T) {
+	sharedLUN := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/dm-uuid-mpath-36005076812808104c800000000000001", WWN: "0x6005076812808104c800000000000001",
+		Type: localv1alpha1.MultiPathType, Property: localv1alpha1.Rotational, Size: 1000,
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	localNVMe := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/nvme-eui.0001", Type: localv1alpha1.DiskType,
+		Property: localv1alpha1.NonRotational, Size: 200,
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.NotAvailable},
+	}
+	newResult := func(resultName, nodeName string, devices ...localv1alpha1.DiscoveredDevice) localv1alpha1.LocalVolumeDiscoveryResult {
+		return localv1alpha1.LocalVolumeDiscoveryResult{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: resultName, Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "LocalVolumeDiscovery", Name: name, UID: "discovery-uid"}},
+			},
+			Spec: localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: nodeName},
+			Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+				DiscoveredTimeStamp: "2025-06-01T12:00:00Z",
+				DiscoveredDevices:   devices,
+			},
+		}
+	}
+
+	node2 := newResult("discovery-result-node2", "node2", sharedLUN)
+	node2.Status.Summary = &localv1alpha1.DiscoverySummary{TotalDevices: 2}
+	node1 := newResult("discovery-result-node1", "node1", sharedLUN, localNVMe)
+	node1.Status.Summary = &localv1alpha1.DiscoverySummary{TotalDevices: 3}
+	// the shard of node1 holds the devices that don't fit in the primary result
+	shard := newResult("discovery-result-node1-shard-1", "node1", localNVMe)
+	shard.Labels = map[string]string{common.DiscoveryShardLabel: "1"}
+
+	summary, nodes := summarizeDiscoveryResults([]localv1alpha1.LocalVolumeDiscoveryResult{node2, node1, shard})
+	// the LUN seen by both nodes is counted once, the local disk of node1 once
+	assert.Equal(t, &localv1alpha1.DiscoverySummary{
+		TotalDevices:          2,
+		AvailableDevices:      1,
+		NotAvailableDevices:   1,
+		TotalCapacity:         1200,
+		AvailableCapacity:     1000,
+		DiskDevices:           1,
+		MultipathDevices:      1,
+		RotationalDevices:     1,
+		RotationalCapacity:    1000,
+		NonRotationalDevices:  1,
+		NonRotationalCapacity: 200,
+	}, summary)
+	assert.Equal(t, []localv1alpha1.NodeDiscoverySummary{
+		{NodeName: "node1", DiscoveredTimeStamp: "2025-06-01T12:00:00Z", Summary: localv1alpha1.DiscoverySummary{TotalDevices: 3}},
+		{NodeName: "node2", DiscoveredTimeStamp: "2025-06-01T12:00:00Z", Summary: localv1alpha1.DiscoverySummary{TotalDevices: 2}},
+	}, nodes)
+
+	// the devices without WWN of different nodes are different devices
+	node2 = newResult("discovery-result-node2", "node2", localNVMe)
+	summary, _ = summarizeDiscoveryResults([]localv1alpha1.LocalVolumeDiscoveryResult{node1, node2})
+	assert.Equal(t, 3, summary.TotalDevices)
+
+	summary, nodes = summarizeDiscoveryResults(nil)
+	assert.Nil(t, summary)
+	assert.Nil(t, nodes)
+
+	// the summary is stored in the status of the discovery
+	discoveryObj := &localv1alpha1.LocalVolumeDiscovery{}
+	localVolumeDiscoveryCR.DeepCopyInto(discoveryObj)
+	fakeReconciler := newFakeLocalVolumeDiscoveryReconciler(t, []runtime.Object{discoveryObj, &node1}...)
+	err := fakeReconciler.updateDiscoverySummary(context.TODO(), discoveryObj)
+	assert.NoError(t, err)
+	err = fakeReconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, discoveryObj)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, discoveryObj.Status.Summary.TotalDevices)
+	assert.Len(t, discoveryObj.Status.Nodes, 1)
+}
+
+func TestSummarizeDiscoveryResultsMultipath(t *testing.T) {
+	const wwn = "0x6005076812808104c800000000000001"
+	path := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x6005076812808104c800000000000001", WWN: wwn,
+		Type: localv1alpha1.DiskType, Property: localv1alpha1.Rotational, Size: 1000,
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.NotAvailable},
+	}
+	mpath := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/dm-uuid-mpath-36005076812808104c800000000000001", WWN: wwn,
+		Type: localv1alpha1.MultiPathType, Property: localv1alpha1.Rotational, Size: 1000,
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	missing := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4", WWN: "0x5000c500a1b2c3d4",
+		Type: localv1alpha1.DiskType, Property: localv1alpha1.NonRotational, Size: 500,
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Missing},
+	}
+	results := []localv1alpha1.LocalVolumeDiscoveryResult{
+		{
+			Spec:   localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: "node1"},
+			Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{DiscoveredDevices: []localv1alpha1.DiscoveredDevice{path, path, missing}},
+		},
+		{
+			Spec:   localv1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: "node2"},
+			Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{DiscoveredDevices: []localv1alpha1.DiscoveredDevice{path, mpath, path}},
+		},
+	}
+
+	// the LUN is counted once as the multipath device, the size of the missing device is not counted
+	summary, _ := summarizeDiscoveryResults(results)
+	assert.Equal(t, &localv1alpha1.DiscoverySummary{
+		TotalDevices:         2,
+		AvailableDevices:     1,
+		MissingDevices:       1,
+		TotalCapacity:        1000,
+		AvailableCapacity:    1000,
+		DiskDevices:          1,
+		MultipathDevices:     1,
+		RotationalDevices:    1,
+		RotationalCapacity:   1000,
+		NonRotationalDevices: 1,
+	}, summary)
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+func TestNewLocalVolumeDiscovery(t *testing.T) {
+	seconds := int64(300)
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage"},
+		Spec: purplev1alpha1.PurpleStorageSpec{
+			NodeSpec: purplev1alpha1.NodeSpec{
+				Selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpExists},
+					},
+				}}},
+				Tolerations: []corev1.Toleration{
+					{Key: "storage", Operator: corev1.TolerationOpExists, TolerationSeconds: &seconds},
+				},
+			},
+		},
+	}
+
+	lvd := NewLocalVolumeDiscovery(purplestorage)
+	assert.Equal(t, common.LocalVolumeDiscoveryName, lvd.Name)
+	assert.Equal(t, "purple-storage", lvd.Namespace)
+
+	// the settings of the user are kept
+	lvd.Spec.ProbeInterval = &metav1.Duration{}
+	setLocalVolumeDiscoverySpec(lvd, purplestorage.Spec.NodeSpec)
+	assert.Equal(t, purplestorage.Spec.NodeSpec.Selector, lvd.Spec.NodeSelector)
+	assert.Equal(t, purplestorage.Spec.NodeSpec.Tolerations, lvd.Spec.Tolerations)
+	assert.NotNil(t, lvd.Spec.ProbeInterval)
+	assert.NotSame(t, purplestorage.Spec.NodeSpec.Selector, lvd.Spec.NodeSelector)
+
+	setLocalVolumeDiscoverySpec(lvd, purplev1alpha1.NodeSpec{})
+	assert.Nil(t, lvd.Spec.NodeSelector)
+	assert.Nil(t, lvd.Spec.Tolerations)
+}
+
+func TestReconcileLocalVolumeDiscovery(t *testing.T) {
+	ctx := context.TODO()
+	userSelector := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "storage", Operator: corev1.NodeSelectorOpIn, Values: []string{"san"}},
+		},
+	}}}
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage", UID: "purplestorage-uid"},
+	}
+	userLVD := &purplev1alpha1.LocalVolumeDiscovery{
+		ObjectMeta: metav1.ObjectMeta{Name: common.LocalVolumeDiscoveryName, Namespace: "purple-storage"},
+		Spec: purplev1alpha1.LocalVolumeDiscoverySpec{
+			NodeSelector: userSelector,
+			Tolerations:  []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	r := newFakePurpleStorageReconciler(t, purplestorage, userLVD)
+
+	// the discovery of the user is left alone without NodeSpec
+	assert.NoError(t, r.reconcileLocalVolumeDiscovery(ctx, purplestorage))
+	lvd := &purplev1alpha1.LocalVolumeDiscovery{}
+	assert.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(userLVD), lvd))
+	assert.Equal(t, userSelector, lvd.Spec.NodeSelector)
+	assert.Len(t, lvd.Spec.Tolerations, 1)
+	assert.Empty(t, lvd.OwnerReferences)
+
+	// the NodeSpec is copied once it is set
+	purplestorage.Spec.NodeSpec.Tolerations = []corev1.Toleration{{Key: "purple", Operator: corev1.TolerationOpExists}}
+	assert.NoError(t, r.reconcileLocalVolumeDiscovery(ctx, purplestorage))
+	assert.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(userLVD), lvd))
+	assert.Nil(t, lvd.Spec.NodeSelector)
+	assert.Equal(t, purplestorage.Spec.NodeSpec.Tolerations, lvd.Spec.Tolerations)
+	assert.True(t, metav1.IsControlledBy(lvd, purplestorage))
+
+	// and the discovery owned by the PurpleStorage follows the NodeSpec when it is emptied
+	purplestorage.Spec.NodeSpec.Tolerations = nil
+	assert.NoError(t, r.reconcileLocalVolumeDiscovery(ctx, purplestorage))
+	assert.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(userLVD), lvd))
+	assert.Nil(t, lvd.Spec.Tolerations)
+}
+
+func TestGetDaemonNodeSelector(t *testing.T) {
+	workerTerm := corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpIn, Values: []string{""}},
+			{Key: "purple.purplestorage.com/storage", Operator: corev1.NodeSelectorOpIn, Values: []string{"true"}},
+		},
+	}
+	testcases := []struct {
+		label              string
+		daemonNodeSelector map[string]string
+		selector           *corev1.NodeSelector
+		expected           map[string]string
+		expectErr          bool
+	}{
+		{
+			label: "case 1", // no selector at all
+		},
+		{
+			label:    "case 2", // the NodeSpec selector is translated
+			selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{workerTerm}},
+			expected: map[string]string{"node-role.kubernetes.io/worker": "", "purple.purplestorage.com/storage": "true"},
+		},
+		{
+			label:              "case 3", // an explicit daemon_nodeSelector wins
+			daemonNodeSelector: map[string]string{"storage": "yes"},
+			selector:           &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{workerTerm}},
+			expected:           map[string]string{"storage": "yes"},
+		},
+		{
+			label:     "case 4", // the terms of a selector are ORed
+			selector:  &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{workerTerm, workerTerm}},
+			expectErr: true,
+		},
+		{
+			label: "case 5", // only the In operator with a single value is a label
+			selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpExists},
+				},
+			}}},
+			expectErr: true,
+		},
+		{
+			label: "case 6", // a label can not have two values
+			selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}},
+				},
+			}}},
+			expectErr: true,
+		},
+		{
+			label: "case 7", // fields are not labels
+			selector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchFields: []corev1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"worker-0"}},
+				},
+			}}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		purplestorage := &purplev1alpha1.PurpleStorage{
+			Spec: purplev1alpha1.PurpleStorageSpec{
+				Cluster:  purplev1alpha1.IBMSpectrumCluster{Daemon_nodeSelector: tc.daemonNodeSelector},
+				NodeSpec: purplev1alpha1.NodeSpec{Selector: tc.selector},
+			},
+		}
+		nodeSelector, err := getDaemonNodeSelector(purplestorage)
+		if tc.expectErr {
+			assert.Errorf(t, err, "[%s] expected an error", tc.label)
+			continue
+		}
+		assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		assert.Equalf(t, tc.expected, nodeSelector, "[%s] invalid nodeSelector", tc.label)
+	}
+}
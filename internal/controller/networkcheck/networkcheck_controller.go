@@ -0,0 +1,405 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networkcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	v1helper "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// NetworkCheckDaemon prefixes the name of the daemonsets of the checks
+	NetworkCheckDaemon = "diskmaker-netcheck"
+	// maxDaemonSetNameLength keeps the name of the daemonset usable as the value of its app label
+	maxDaemonSetNameLength = validation.DNS1123LabelMaxLength
+	// defaultTimeout is the time the probes have to complete when the check doesn't set it
+	defaultTimeout = 10 * time.Minute
+	// requeueInterval is how often a running check is evaluated
+	requeueInterval = 15 * time.Second
+	// readinessPort is the port the kubelet probes to tell that a daemon listens on all the ports
+	readinessPort = 12345
+	// serviceAccount is the service account of the daemons, allowed to use the host network
+	serviceAccount = "purple-storage-rh-operator-controller-manager"
+	// maxListedProbes bounds the number of probes listed in the status message and in the events
+	maxListedProbes = 5
+
+	// ConnectivityPassed is the event of a check whose nodes all reach each other
+	ConnectivityPassed = "ConnectivityPassed"
+	// ConnectivityFailed is the event of a node that can't reach another one
+	ConnectivityFailed = "ConnectivityFailed"
+	// ConnectivityTimeout is the event of a check whose probes did not complete in time
+	ConnectivityTimeout = "ConnectivityTimeout"
+)
+
+// NetworkCheckReconciler runs the daemons of a NetworkCheck and computes its pass/fail matrix
+type NetworkCheckReconciler struct {
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=networkchecks,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=networkchecks/status,verbs=get;update;patch
+
+// Reconcile runs the daemons of the check until every selected node has probed every other one,
+// then stores the outcome and removes the daemons so that the ports are free for Storage Scale
+func (r *NetworkCheckReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	klog.InfoS("Reconciling NetworkCheck", "namespace", request.Namespace, "name", request.Name)
+
+	check := &localv1alpha1.NetworkCheck{}
+	err := r.Client.Get(ctx, request.NamespacedName, check)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if check.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	ds := newDaemonSet(check)
+	if isCompleted(check) {
+		return ctrl.Result{}, r.deleteDaemonSet(ctx, ds)
+	}
+
+	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, ds, func() error {
+		setDaemonSetSpec(ds, check)
+		return controllerutil.SetControllerReference(check, ds, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile daemonset %s: %w", ds.Name, err)
+	}
+	if opResult != controllerutil.OperationResultNone {
+		klog.InfoS("network check daemonset", "name", ds.Name, "result", opResult)
+	}
+
+	peers, err := r.getPeers(ctx, check, ds.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	evaluation := evaluateProbes(peers, check.Status.Probes)
+	if !evaluation.completed {
+		timeout := defaultTimeout
+		if check.Spec.Timeout != nil {
+			timeout = check.Spec.Timeout.Duration
+		}
+		if time.Since(check.CreationTimestamp.Time) > timeout {
+			evaluation.timeout(timeout)
+		}
+	}
+
+	status := check.Status.DeepCopy()
+	status.Peers = peers
+	status.Phase = evaluation.phase
+	status.Message = evaluation.message
+	status.FailedProbes = len(evaluation.failed)
+	if evaluation.completed {
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+	}
+	if !reflect.DeepEqual(&check.Status, status) {
+		check.Status = *status
+		if err := r.Client.Status().Update(ctx, check); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update the status of NetworkCheck %s: %w", check.Name, err)
+		}
+	}
+
+	if !evaluation.completed {
+		return ctrl.Result{RequeueAfter: requeueInterval}, nil
+	}
+	r.recordEvents(check, evaluation)
+	return ctrl.Result{}, r.deleteDaemonSet(ctx, ds)
+}
+
+func isCompleted(check *localv1alpha1.NetworkCheck) bool {
+	return check.Status.Phase == localv1alpha1.NetworkCheckPassed || check.Status.Phase == localv1alpha1.NetworkCheckFailed
+}
+
+// getPeers returns the selected nodes, sorted by name, with the address of their daemon once it is ready
+func (r *NetworkCheckReconciler) getPeers(ctx context.Context, check *localv1alpha1.NetworkCheck, dsName string) ([]localv1alpha1.NetworkCheckPeer, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.Client.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(check.Namespace), client.MatchingLabels{"app": dsName}); err != nil {
+		return nil, fmt.Errorf("failed to list the pods of daemonset %s: %w", dsName, err)
+	}
+	readyPods := map[string]*corev1.Pod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp == nil && isPodReady(pod) {
+			readyPods[pod.Spec.NodeName] = pod
+		}
+	}
+
+	peers := []localv1alpha1.NetworkCheckPeer{}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if check.Spec.NodeSelector != nil && len(check.Spec.NodeSelector.NodeSelectorTerms) > 0 {
+			matches, err := v1helper.MatchNodeSelectorTerms(node, check.Spec.NodeSelector)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+		}
+		peer := localv1alpha1.NetworkCheckPeer{NodeName: node.Name}
+		if pod, ok := readyPods[node.Name]; ok && pod.Status.HostIP != "" {
+			peer.Address = pod.Status.HostIP
+			peer.Ready = true
+		}
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].NodeName < peers[j].NodeName
+	})
+	return peers, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// evaluation is the state of the matrix of a check
+type evaluation struct {
+	phase     localv1alpha1.NetworkCheckPhase
+	message   string
+	completed bool
+	// failed are the probes that did not reach their target
+	failed []localv1alpha1.NetworkProbe
+	// missing are the source and target nodes without probe yet
+	missing []string
+}
+
+// evaluateProbes checks that every peer has probed every other one. The probes of the nodes that
+// are no longer selected are ignored.
+func evaluateProbes(peers []localv1alpha1.NetworkCheckPeer, probes []localv1alpha1.NetworkProbe) *evaluation {
+	byPair := map[string]localv1alpha1.NetworkProbe{}
+	for _, probe := range probes {
+		byPair[probe.Source+"/"+probe.Target] = probe
+	}
+
+	e := &evaluation{phase: localv1alpha1.NetworkCheckRunning}
+	for _, source := range peers {
+		for _, target := range peers {
+			if source.NodeName == target.NodeName {
+				continue
+			}
+			probe, ok := byPair[source.NodeName+"/"+target.NodeName]
+			if !ok {
+				e.missing = append(e.missing, fmt.Sprintf("%s -> %s", source.NodeName, target.NodeName))
+				continue
+			}
+			if probe.Result == localv1alpha1.NetworkProbeFailed {
+				e.failed = append(e.failed, probe)
+			}
+		}
+	}
+
+	pairs := len(peers) * (len(peers) - 1)
+	switch {
+	case len(peers) == 0:
+		e.message = "no node selected"
+	case len(e.missing) > 0:
+		notReady := []string{}
+		for _, peer := range peers {
+			if !peer.Ready {
+				notReady = append(notReady, peer.NodeName)
+			}
+		}
+		e.message = fmt.Sprintf("%d of %d probes completed", pairs-len(e.missing), pairs)
+		if len(notReady) > 0 {
+			e.message += fmt.Sprintf(", daemons not ready on %s", listItems(notReady))
+		}
+	case len(e.failed) > 0:
+		e.phase = localv1alpha1.NetworkCheckFailed
+		e.completed = true
+		e.message = fmt.Sprintf("%d of %d probes failed: %s", len(e.failed), pairs, listItems(describeProbes(e.failed)))
+	default:
+		e.phase = localv1alpha1.NetworkCheckPassed
+		e.completed = true
+		e.message = fmt.Sprintf("%d nodes reach each other on all the ports", len(peers))
+	}
+	return e
+}
+
+// timeout fails a check whose probes did not complete in time
+func (e *evaluation) timeout(timeout time.Duration) {
+	e.phase = localv1alpha1.NetworkCheckFailed
+	e.completed = true
+	e.message = fmt.Sprintf("probes did not complete within %s, missing %s", timeout, listItems(e.missing))
+	if len(e.failed) > 0 {
+		e.message += fmt.Sprintf(", failed %s", listItems(describeProbes(e.failed)))
+	}
+}
+
+func describeProbes(probes []localv1alpha1.NetworkProbe) []string {
+	described := []string{}
+	for _, probe := range probes {
+		described = append(described, fmt.Sprintf("%s -> %s (%s)", probe.Source, probe.Target, strings.Join(probe.FailedPorts, ", ")))
+	}
+	return described
+}
+
+// listItems lists some of the items
+func listItems(items []string) string {
+	if len(items) > maxListedProbes {
+		return fmt.Sprintf("%s and %d more", strings.Join(items[:maxListedProbes], "; "), len(items)-maxListedProbes)
+	}
+	return strings.Join(items, "; ")
+}
+
+// recordEvents reports the outcome of a completed check, with an event for each of the first failed probes
+func (r *NetworkCheckReconciler) recordEvents(check *localv1alpha1.NetworkCheck, e *evaluation) {
+	if r.Recorder == nil {
+		return
+	}
+	if e.phase == localv1alpha1.NetworkCheckPassed {
+		r.Recorder.Event(check, corev1.EventTypeNormal, ConnectivityPassed, e.message)
+		return
+	}
+	for i, probe := range e.failed {
+		if i == maxListedProbes {
+			break
+		}
+		r.Recorder.Eventf(check, corev1.EventTypeWarning, ConnectivityFailed, "%s can not reach %s on ports %s: %s",
+			probe.Source, probe.Target, strings.Join(probe.FailedPorts, ", "), probe.Message)
+	}
+	if len(e.missing) > 0 {
+		r.Recorder.Event(check, corev1.EventTypeWarning, ConnectivityTimeout, e.message)
+	}
+}
+
+// getDaemonSetName returns the name of the daemonset of a check
+func getDaemonSetName(checkName string) string {
+	name := fmt.Sprintf("%s-%s", NetworkCheckDaemon, checkName)
+	if len(name) > maxDaemonSetNameLength {
+		h := sha256.Sum256([]byte(checkName))
+		suffix := hex.EncodeToString(h[:4])
+		name = strings.TrimRight(name[:maxDaemonSetNameLength-len(suffix)-1], "-.") + "-" + suffix
+	}
+	return name
+}
+
+func newDaemonSet(check *localv1alpha1.NetworkCheck) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDaemonSetName(check.Name),
+			Namespace: check.Namespace,
+		},
+	}
+}
+
+// setDaemonSetSpec sets the daemons of the check on the host network of the selected nodes
+func setDaemonSetSpec(ds *appsv1.DaemonSet, check *localv1alpha1.NetworkCheck) {
+	labels := map[string]string{"app": ds.Name}
+	ds.Labels = labels
+	ds.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	ds.Spec.Template.Labels = labels
+
+	podSpec := &ds.Spec.Template.Spec
+	podSpec.HostNetwork = true
+	podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	podSpec.ServiceAccountName = serviceAccount
+	podSpec.Tolerations = nil
+	for _, toleration := range check.Spec.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, *toleration.DeepCopy())
+	}
+	podSpec.Affinity = nil
+	if check.Spec.NodeSelector != nil && len(check.Spec.NodeSelector.NodeSelectorTerms) > 0 {
+		podSpec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: check.Spec.NodeSelector.DeepCopy(),
+		}}
+	}
+
+	if len(podSpec.Containers) != 1 {
+		podSpec.Containers = []corev1.Container{{}}
+	}
+	container := &podSpec.Containers[0]
+	container.Name = "netcheck"
+	container.Image = common.GetDiskMakerImage()
+	container.Args = []string{"netcheck", "--name", check.Name}
+	container.Env = []corev1.EnvVar{
+		{
+			Name:      "MY_NODE_NAME",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"}},
+		},
+		{
+			Name:      "WATCH_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"}},
+		},
+	}
+	container.Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("50Mi"),
+		},
+	}
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(readinessPort)},
+		},
+		PeriodSeconds: 5,
+	}
+	container.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+}
+
+func (r *NetworkCheckReconciler) deleteDaemonSet(ctx context.Context, ds *appsv1.DaemonSet) error {
+	err := r.Client.Delete(ctx, ds)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete daemonset %s: %w", ds.Name, err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NetworkCheckReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&localv1alpha1.NetworkCheck{}).
+		Owns(&appsv1.DaemonSet{}).
+		Complete(r)
+}
@@ -0,0 +1,220 @@
+package networkcheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	name      = "storage-nodes"
+	namespace = "purple-storage"
+)
+
+func newFakeNetworkCheckReconciler(t *testing.T, objs ...runtime.Object) (*NetworkCheckReconciler, *record.FakeRecorder) {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+	err = corev1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding corev1 to scheme")
+	err = appsv1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding appsv1 to scheme")
+
+	crsWithStatus := []client.Object{
+		&localv1alpha1.NetworkCheck{},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(crsWithStatus...).WithRuntimeObjects(objs...).Build()
+	recorder := record.NewFakeRecorder(20)
+	return &NetworkCheckReconciler{Client: client, Scheme: scheme, Recorder: recorder}, recorder
+}
+
+func newNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func newDaemonPod(nodeName, hostIP string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDaemonSetName(name) + "-" + nodeName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": getDaemonSetName(name)},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{
+			HostIP:     hostIP,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func newProbe(source, target string, failedPorts ...string) localv1alpha1.NetworkProbe {
+	probe := localv1alpha1.NetworkProbe{Source: source, Target: target, Result: localv1alpha1.NetworkProbePassed}
+	if len(failedPorts) > 0 {
+		probe.Result = localv1alpha1.NetworkProbeFailed
+		probe.FailedPorts = failedPorts
+		probe.Message = "i/o timeout"
+	}
+	return probe
+}
+
+func TestEvaluateProbes(t *testing.T) {
+	peers := []localv1alpha1.NetworkCheckPeer{
+		{NodeName: "worker-0", Address: "10.0.0.1", Ready: true},
+		{NodeName: "worker-1", Address: "10.0.0.2", Ready: true},
+		{NodeName: "worker-2", Ready: false},
+	}
+	allPassed := []localv1alpha1.NetworkProbe{
+		newProbe("worker-0", "worker-1"), newProbe("worker-0", "worker-2"),
+		newProbe("worker-1", "worker-0"), newProbe("worker-1", "worker-2"),
+		newProbe("worker-2", "worker-0"), newProbe("worker-2", "worker-1"),
+	}
+	testcases := []struct {
+		label             string
+		peers             []localv1alpha1.NetworkCheckPeer
+		probes            []localv1alpha1.NetworkProbe
+		expectedPhase     localv1alpha1.NetworkCheckPhase
+		expectedMessage   string
+		expectedCompleted bool
+		expectedFailed    int
+	}{
+		{
+			label:           "case 1", // no node
+			expectedPhase:   localv1alpha1.NetworkCheckRunning,
+			expectedMessage: "no node selected",
+		},
+		{
+			label:           "case 2", // probes missing while a daemon is not ready
+			peers:           peers,
+			probes:          allPassed[:2],
+			expectedPhase:   localv1alpha1.NetworkCheckRunning,
+			expectedMessage: "2 of 6 probes completed, daemons not ready on worker-2",
+		},
+		{
+			label:             "case 3", // all the probes passed, the probes of unselected nodes are ignored
+			peers:             peers,
+			probes:            append(append([]localv1alpha1.NetworkProbe{}, allPassed...), newProbe("worker-9", "worker-0", "1191")),
+			expectedPhase:     localv1alpha1.NetworkCheckPassed,
+			expectedMessage:   "3 nodes reach each other on all the ports",
+			expectedCompleted: true,
+		},
+		{
+			label: "case 4", // a blocked port range in one direction
+			peers: peers,
+			probes: []localv1alpha1.NetworkProbe{
+				newProbe("worker-0", "worker-1"), newProbe("worker-0", "worker-2"),
+				newProbe("worker-1", "worker-0"), newProbe("worker-1", "worker-2", "60000-61000"),
+				newProbe("worker-2", "worker-0"), newProbe("worker-2", "worker-1"),
+			},
+			expectedPhase:     localv1alpha1.NetworkCheckFailed,
+			expectedMessage:   "1 of 6 probes failed: worker-1 -> worker-2 (60000-61000)",
+			expectedCompleted: true,
+			expectedFailed:    1,
+		},
+	}
+	for _, tc := range testcases {
+		e := evaluateProbes(tc.peers, tc.probes)
+		assert.Equalf(t, tc.expectedPhase, e.phase, "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedMessage, e.message, "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedCompleted, e.completed, "[%s]", tc.label)
+		assert.Lenf(t, e.failed, tc.expectedFailed, "[%s]", tc.label)
+	}
+
+	e := evaluateProbes(peers, allPassed[:5])
+	e.timeout(10 * time.Minute)
+	assert.Equal(t, localv1alpha1.NetworkCheckFailed, e.phase)
+	assert.True(t, e.completed)
+	assert.Equal(t, "probes did not complete within 10m0s, missing worker-2 -> worker-1", e.message)
+}
+
+func TestGetDaemonSetName(t *testing.T) {
+	assert.Equal(t, "diskmaker-netcheck-storage-nodes", getDaemonSetName("storage-nodes"))
+	long := getDaemonSetName(strings.Repeat("a", 60))
+	assert.Len(t, long, maxDaemonSetNameLength)
+	assert.NotEqual(t, long, getDaemonSetName(strings.Repeat("a", 61)))
+}
+
+func TestNetworkCheckReconciler(t *testing.T) {
+	ctx := context.TODO()
+	storageLabels := map[string]string{"storage": "true"}
+	check := &localv1alpha1.NetworkCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "check-uid", CreationTimestamp: metav1.Now()},
+		Spec: localv1alpha1.NetworkCheckSpec{
+			NodeSelector: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "storage", Operator: corev1.NodeSelectorOpIn, Values: []string{"true"}}},
+			}}},
+			Tolerations: []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	r, recorder := newFakeNetworkCheckReconciler(t, check,
+		newNode("worker-0", storageLabels), newNode("worker-1", storageLabels), newNode("master-0", nil),
+		newDaemonPod("worker-0", "10.0.0.1", true), newDaemonPod("worker-1", "10.0.0.2", false))
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+
+	// the daemons run on the host network of the selected nodes
+	result, err := r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, requeueInterval, result.RequeueAfter)
+	ds := &appsv1.DaemonSet{}
+	assert.NoError(t, r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: getDaemonSetName(name)}, ds))
+	podSpec := ds.Spec.Template.Spec
+	assert.True(t, podSpec.HostNetwork)
+	assert.Equal(t, []string{"netcheck", "--name", name}, podSpec.Containers[0].Args)
+	assert.Equal(t, check.Spec.NodeSelector, podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	assert.Equal(t, check.Spec.Tolerations, podSpec.Tolerations)
+	assert.Equal(t, "check-uid", string(ds.OwnerReferences[0].UID))
+
+	assert.NoError(t, r.Client.Get(ctx, request.NamespacedName, check))
+	assert.Equal(t, localv1alpha1.NetworkCheckRunning, check.Status.Phase)
+	assert.Equal(t, []localv1alpha1.NetworkCheckPeer{
+		{NodeName: "worker-0", Address: "10.0.0.1", Ready: true},
+		{NodeName: "worker-1", Ready: false},
+	}, check.Status.Peers)
+	assert.Equal(t, "0 of 2 probes completed, daemons not ready on worker-1", check.Status.Message)
+
+	// one direction is blocked
+	check.Status.Probes = []localv1alpha1.NetworkProbe{
+		newProbe("worker-0", "worker-1"),
+		newProbe("worker-1", "worker-0", "1191", "60000-61000"),
+	}
+	assert.NoError(t, r.Client.Status().Update(ctx, check))
+	result, err = r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	assert.NoError(t, r.Client.Get(ctx, request.NamespacedName, check))
+	assert.Equal(t, localv1alpha1.NetworkCheckFailed, check.Status.Phase)
+	assert.Equal(t, 1, check.Status.FailedProbes)
+	assert.NotNil(t, check.Status.CompletionTime)
+	assert.Equal(t, "1 of 2 probes failed: worker-1 -> worker-0 (1191, 60000-61000)", check.Status.Message)
+
+	// the daemons are removed to free the ports
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: getDaemonSetName(name)}, ds)
+	assert.True(t, errors.IsNotFound(err))
+	if assert.Len(t, recorder.Events, 1) {
+		assert.Equal(t, "Warning ConnectivityFailed worker-1 can not reach worker-0 on ports 1191, 60000-61000: i/o timeout", <-recorder.Events)
+	}
+
+	// a completed check is not run again
+	result, err = r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: getDaemonSetName(name)}, ds)
+	assert.True(t, errors.IsNotFound(err))
+}
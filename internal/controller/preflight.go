@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+const (
+	// PreflightCondition is set on the PurpleStorage with the outcome of the preflight checks of the nodes
+	PreflightCondition = "PreflightChecksPassed"
+	// preflightRequeueInterval is how often the reports are checked while the checks run or fail
+	preflightRequeueInterval = 30 * time.Second
+	// maxPreflightNameLength keeps the name of the jobs a valid label value for their pods
+	maxPreflightNameLength = 63
+	// preflightServiceAccount is the service account of the preflight jobs, allowed to run privileged pods
+	preflightServiceAccount = "purple-storage-rh-operator-controller-manager"
+	// maxPreflightNodes bounds the number of nodes listed in the condition message
+	maxPreflightNodes = 5
+	// PreflightNetworkCheckName is the NetworkCheck of the nodes of the daemons
+	PreflightNetworkCheckName = "preflight-network"
+)
+
+// getPreflightName returns the name of the PreflightReport and job of a node
+func getPreflightName(nodeName string) string {
+	name := "preflight-" + nodeName
+	if len(name) > maxPreflightNameLength {
+		h := sha256.Sum256([]byte(nodeName))
+		suffix := hex.EncodeToString(h[:4])
+		name = strings.TrimRight(name[:maxPreflightNameLength-len(suffix)-1], "-.") + "-" + suffix
+	}
+	return name
+}
+
+// NewPreflightReport returns the PreflightReport of a node, in the namespace of the PurpleStorage
+func NewPreflightReport(purplestorage *purplev1alpha1.PurpleStorage, nodeName string) *purplev1alpha1.PreflightReport {
+	report := &purplev1alpha1.PreflightReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getPreflightName(nodeName),
+			Namespace: purplestorage.Namespace,
+		},
+		Spec: purplev1alpha1.PreflightReportSpec{
+			NodeName:       nodeName,
+			IbmCnsaVersion: purplestorage.Spec.IbmCnsaVersion,
+		},
+	}
+	if purplestorage.Spec.Preflight.PagepoolSize != nil {
+		pagepool := purplestorage.Spec.Preflight.PagepoolSize.DeepCopy()
+		report.Spec.PagepoolSize = &pagepool
+	}
+	return report
+}
+
+// NewPreflightJob returns the job that runs the checks of the report on its node
+func NewPreflightJob(purplestorage *purplev1alpha1.PurpleStorage, report *purplev1alpha1.PreflightReport) *batchv1.Job {
+	backoffLimit := int32(1)
+	privileged := true
+	tolerations := []corev1.Toleration{}
+	for _, toleration := range purplestorage.Spec.NodeSpec.Tolerations {
+		tolerations = append(tolerations, *toleration.DeepCopy())
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      report.Name,
+			Namespace: report.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeName:           report.Spec.NodeName,
+					HostPID:            true,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: preflightServiceAccount,
+					Tolerations:        tolerations,
+					Containers: []corev1.Container{{
+						Name:  "preflight",
+						Image: common.GetDiskMakerImage(),
+						Args:  []string{"preflight", "--report", report.Name},
+						Env: []corev1.EnvVar{
+							{
+								Name:      "MY_NODE_NAME",
+								ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}},
+							},
+							{
+								Name:      "WATCH_NAMESPACE",
+								ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+							},
+						},
+						SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+								corev1.ResourceMemory: resource.MustParse("50Mi"),
+							},
+						},
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// reconcilePreflight runs the preflight checks on the nodes of the daemons and returns true when all of
+// them passed. The outcome is stored in the PreflightChecksPassed condition of the PurpleStorage.
+func (r *PurpleStorageReconciler) reconcilePreflight(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage,
+	daemonNodeSelector map[string]string) (bool, error) {
+	nodes := &corev1.NodeList{}
+	err := r.Client.List(ctx, nodes, client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(daemonNodeSelector)})
+	if err != nil {
+		return false, fmt.Errorf("failed to list the nodes of the daemons: %w", err)
+	}
+
+	failed := []string{}
+	pending := []string{}
+	for i := range nodes.Items {
+		nodeName := nodes.Items[i].Name
+		phase, message, err := r.reconcileNodePreflight(ctx, purplestorage, nodeName)
+		if err != nil {
+			return false, err
+		}
+		switch phase {
+		case purplev1alpha1.PreflightPassed:
+		case purplev1alpha1.PreflightFailed:
+			failed = append(failed, fmt.Sprintf("%s (%s)", nodeName, message))
+		default:
+			pending = append(pending, nodeName)
+		}
+	}
+
+	// the daemons of the network check listen on the ports that the checks of the nodes expect to be free,
+	// so the network is only checked once the nodes passed
+	var network *purplev1alpha1.NetworkCheck
+	if len(nodes.Items) > 0 && len(failed) == 0 && len(pending) == 0 {
+		network, err = r.reconcilePreflightNetworkCheck(ctx, purplestorage, daemonNodeSelector)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{Type: PreflightCondition, Status: operatorv1.ConditionFalse}
+	switch {
+	case len(nodes.Items) == 0:
+		condition.Reason = "NoNodes"
+		condition.Message = "no node matches the nodeSelector of the daemons"
+	case len(failed) > 0:
+		condition.Reason = "ChecksFailed"
+		condition.Message = fmt.Sprintf("preflight checks failed on %s, delete their PreflightReport to run the checks again",
+			getPreflightNodesMessage(failed))
+	case len(pending) > 0:
+		condition.Reason = "ChecksRunning"
+		condition.Message = fmt.Sprintf("preflight checks running on %s", getPreflightNodesMessage(pending))
+	case network == nil || (network.Status.Phase != purplev1alpha1.NetworkCheckPassed && network.Status.Phase != purplev1alpha1.NetworkCheckFailed):
+		condition.Reason = "NetworkCheckRunning"
+		condition.Message = fmt.Sprintf("network check %s running", PreflightNetworkCheckName)
+		if network != nil && network.Status.Message != "" {
+			condition.Message += ": " + network.Status.Message
+		}
+	case network.Status.Phase == purplev1alpha1.NetworkCheckFailed:
+		condition.Reason = "NetworkCheckFailed"
+		condition.Message = fmt.Sprintf("network check %s failed: %s, delete it to run the check again",
+			PreflightNetworkCheckName, network.Status.Message)
+	default:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "ChecksPassed"
+		condition.Message = fmt.Sprintf("preflight checks passed on %d nodes, they reach each other on the Storage Scale ports", len(nodes.Items))
+	}
+	if err := r.setCondition(ctx, purplestorage, condition); err != nil {
+		return false, err
+	}
+	return condition.Status == operatorv1.ConditionTrue, nil
+}
+
+// reconcileNodePreflight creates the report and job of a node and returns the phase of the report.
+// A report of another version or pagepool size is deleted so that the checks run again.
+func (r *PurpleStorageReconciler) reconcileNodePreflight(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage,
+	nodeName string) (purplev1alpha1.PreflightPhase, string, error) {
+	expected := NewPreflightReport(purplestorage, nodeName)
+	job := NewPreflightJob(purplestorage, expected)
+
+	report := &purplev1alpha1.PreflightReport{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: expected.Namespace, Name: expected.Name}, report)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return "", "", fmt.Errorf("failed to get PreflightReport %s: %w", expected.Name, err)
+	}
+	if err == nil && report.DeletionTimestamp != nil {
+		return purplev1alpha1.PreflightPending, "", nil
+	}
+	if err == nil && !isPreflightReportCurrent(report, expected) {
+		log.Log.Info(fmt.Sprintf("Deleting outdated PreflightReport %s", report.Name))
+		if err := r.deletePreflight(ctx, report, job); err != nil {
+			return "", "", err
+		}
+		return purplev1alpha1.PreflightPending, "", nil
+	}
+	if kerrors.IsNotFound(err) {
+		// the job of a previous report would not run again
+		if err := r.deletePreflight(ctx, nil, job); err != nil {
+			return "", "", err
+		}
+		if err := controllerutil.SetControllerReference(purplestorage, expected, r.Scheme); err != nil {
+			return "", "", err
+		}
+		if err := r.Client.Create(ctx, expected); err != nil && !kerrors.IsAlreadyExists(err) {
+			return "", "", fmt.Errorf("failed to create PreflightReport %s: %w", expected.Name, err)
+		}
+		log.Log.Info(fmt.Sprintf("Created PreflightReport %s", expected.Name))
+		report = expected
+	}
+
+	switch report.Status.Phase {
+	case purplev1alpha1.PreflightPassed:
+		return report.Status.Phase, "", nil
+	case purplev1alpha1.PreflightFailed:
+		return report.Status.Phase, getFailedChecks(report), nil
+	}
+
+	existing := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: job.Namespace, Name: job.Name}, existing)
+	if kerrors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(purplestorage, job, r.Scheme); err != nil {
+			return "", "", err
+		}
+		if err := r.Client.Create(ctx, job); err != nil && !kerrors.IsAlreadyExists(err) {
+			return "", "", fmt.Errorf("failed to create preflight job %s: %w", job.Name, err)
+		}
+		log.Log.Info(fmt.Sprintf("Created preflight job %s", job.Name))
+		return purplev1alpha1.PreflightPending, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get preflight job %s: %w", job.Name, err)
+	}
+	if isJobFailed(existing) {
+		return purplev1alpha1.PreflightFailed, fmt.Sprintf("job %s failed", existing.Name), nil
+	}
+	return purplev1alpha1.PreflightPending, "", nil
+}
+
+// NewPreflightNetworkCheck returns the NetworkCheck of the nodes of the daemons, in the namespace of the PurpleStorage
+func NewPreflightNetworkCheck(purplestorage *purplev1alpha1.PurpleStorage, daemonNodeSelector map[string]string) *purplev1alpha1.NetworkCheck {
+	check := &purplev1alpha1.NetworkCheck{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PreflightNetworkCheckName,
+			Namespace: purplestorage.Namespace,
+		},
+		Spec: purplev1alpha1.NetworkCheckSpec{
+			NodeSelector: labelsToNodeSelector(daemonNodeSelector),
+		},
+	}
+	for _, toleration := range purplestorage.Spec.NodeSpec.Tolerations {
+		check.Spec.Tolerations = append(check.Spec.Tolerations, *toleration.DeepCopy())
+	}
+	return check
+}
+
+// labelsToNodeSelector returns the NodeSelector that matches the nodes with all the labels
+func labelsToNodeSelector(nodeLabels map[string]string) *corev1.NodeSelector {
+	if len(nodeLabels) == 0 {
+		return nil
+	}
+	keys := []string{}
+	for key := range nodeLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	term := corev1.NodeSelectorTerm{}
+	for _, key := range keys {
+		term.MatchExpressions = append(term.MatchExpressions, corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{nodeLabels[key]},
+		})
+	}
+	return &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{term}}
+}
+
+// reconcilePreflightNetworkCheck creates the NetworkCheck of the nodes of the daemons and returns it. A check
+// of other nodes is deleted so that it runs again, nil is returned until it is created again.
+func (r *PurpleStorageReconciler) reconcilePreflightNetworkCheck(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage,
+	daemonNodeSelector map[string]string) (*purplev1alpha1.NetworkCheck, error) {
+	expected := NewPreflightNetworkCheck(purplestorage, daemonNodeSelector)
+	check := &purplev1alpha1.NetworkCheck{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: expected.Namespace, Name: expected.Name}, check)
+	if kerrors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(purplestorage, expected, r.Scheme); err != nil {
+			return nil, err
+		}
+		if err := r.Client.Create(ctx, expected); err != nil && !kerrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create NetworkCheck %s: %w", expected.Name, err)
+		}
+		log.Log.Info(fmt.Sprintf("Created NetworkCheck %s", expected.Name))
+		return expected, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NetworkCheck %s: %w", expected.Name, err)
+	}
+	if check.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	if !equality.Semantic.DeepEqual(check.Spec.NodeSelector, expected.Spec.NodeSelector) ||
+		!equality.Semantic.DeepEqual(check.Spec.Tolerations, expected.Spec.Tolerations) {
+		log.Log.Info(fmt.Sprintf("Deleting outdated NetworkCheck %s", check.Name))
+		if err := r.Client.Delete(ctx, check); err != nil && !kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete NetworkCheck %s: %w", check.Name, err)
+		}
+		return nil, nil
+	}
+	return check, nil
+}
+
+// deletePreflight deletes the report, when set, and the job of a node
+func (r *PurpleStorageReconciler) deletePreflight(ctx context.Context, report *purplev1alpha1.PreflightReport, job *batchv1.Job) error {
+	err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete preflight job %s: %w", job.Name, err)
+	}
+	if report == nil {
+		return nil
+	}
+	err = r.Client.Delete(ctx, report)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PreflightReport %s: %w", report.Name, err)
+	}
+	return nil
+}
+
+// isPreflightReportCurrent returns true if the report checks the version and pagepool of the expected one
+func isPreflightReportCurrent(report, expected *purplev1alpha1.PreflightReport) bool {
+	if report.Spec.NodeName != expected.Spec.NodeName || report.Spec.IbmCnsaVersion != expected.Spec.IbmCnsaVersion {
+		return false
+	}
+	if report.Spec.PagepoolSize == nil || expected.Spec.PagepoolSize == nil {
+		return report.Spec.PagepoolSize == nil && expected.Spec.PagepoolSize == nil
+	}
+	return report.Spec.PagepoolSize.Cmp(*expected.Spec.PagepoolSize) == 0
+}
+
+func getFailedChecks(report *purplev1alpha1.PreflightReport) string {
+	failed := []string{}
+	for _, check := range report.Status.Checks {
+		if check.Result == purplev1alpha1.PreflightCheckFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// getPreflightNodesMessage lists some of the nodes in a stable order
+func getPreflightNodesMessage(nodes []string) string {
+	sort.Strings(nodes)
+	if len(nodes) > maxPreflightNodes {
+		return fmt.Sprintf("%s and %d more nodes", strings.Join(nodes[:maxPreflightNodes], ", "), len(nodes)-maxPreflightNodes)
+	}
+	return strings.Join(nodes, ", ")
+}
+
+// setCondition sets a condition of the PurpleStorage and updates its status when the condition changed.
+// The transition time is kept while the status of the condition does not change.
+func (r *PurpleStorageReconciler) setCondition(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage,
+	condition operatorv1.OperatorCondition) error {
+	conditions := []operatorv1.OperatorCondition{}
+	for _, current := range purplestorage.Status.Conditions {
+		if current.Type != condition.Type {
+			conditions = append(conditions, current)
+			continue
+		}
+		if current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+			return nil
+		}
+		if current.Status == condition.Status {
+			condition.LastTransitionTime = current.LastTransitionTime
+		}
+	}
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	purplestorage.Status.Conditions = append(conditions, condition)
+	if err := r.Client.Status().Update(ctx, purplestorage); err != nil {
+		return fmt.Errorf("failed to update the status of PurpleStorage %s: %w", purplestorage.Name, err)
+	}
+	return nil
+}
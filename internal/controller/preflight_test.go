@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+)
+
+func newFakePurpleStorageReconciler(t *testing.T, objs ...runtime.Object) *PurpleStorageReconciler {
+	scheme, err := purplev1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+	err = corev1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding corev1 to scheme")
+	err = batchv1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding batchv1 to scheme")
+	err = configv1.AddToScheme(scheme)
+	assert.NoErrorf(t, err, "adding configv1 to scheme")
+
+	crsWithStatus := []client.Object{
+		&purplev1alpha1.PurpleStorage{},
+		&purplev1alpha1.PreflightReport{},
+		&purplev1alpha1.NetworkCheck{},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(crsWithStatus...).WithRuntimeObjects(objs...).Build()
+	return &PurpleStorageReconciler{Client: client, Scheme: scheme}
+}
+
+func newPreflightNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestGetPreflightName(t *testing.T) {
+	assert.Equal(t, "preflight-worker-0", getPreflightName("worker-0"))
+
+	long := getPreflightName(strings.Repeat("worker", 20) + ".example.com")
+	assert.Len(t, long, maxPreflightNameLength)
+	assert.NotEqual(t, long, getPreflightName(strings.Repeat("worker", 20)+".example.org"))
+}
+
+func TestReconcilePreflight(t *testing.T) {
+	ctx := context.TODO()
+	storageLabels := map[string]string{"storage": "true"}
+	pagepool := resource.MustParse("2Gi")
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage", UID: "purplestorage-uid"},
+		Spec: purplev1alpha1.PurpleStorageSpec{
+			IbmCnsaVersion: "v5.2.2.0",
+			Preflight:      purplev1alpha1.PreflightSpec{PagepoolSize: &pagepool},
+			NodeSpec: purplev1alpha1.NodeSpec{
+				Tolerations: []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists}},
+			},
+		},
+	}
+	r := newFakePurpleStorageReconciler(t, purplestorage,
+		newPreflightNode("worker-0", storageLabels),
+		newPreflightNode("worker-1", storageLabels),
+		newPreflightNode("master-0", nil))
+
+	// the reports and jobs of the selected nodes are created
+	passed, err := r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	condition := findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+		assert.Equal(t, "ChecksRunning", condition.Reason)
+		assert.Equal(t, "preflight checks running on worker-0, worker-1", condition.Message)
+	}
+
+	reports := &purplev1alpha1.PreflightReportList{}
+	assert.NoError(t, r.Client.List(ctx, reports))
+	assert.Len(t, reports.Items, 2)
+	for _, report := range reports.Items {
+		assert.Equal(t, "v5.2.2.0", report.Spec.IbmCnsaVersion)
+		assert.Equal(t, "2Gi", report.Spec.PagepoolSize.String())
+		assert.Equal(t, "purplestorage-uid", string(report.OwnerReferences[0].UID))
+	}
+	job := &batchv1.Job{}
+	assert.NoError(t, r.Client.Get(ctx, types.NamespacedName{Namespace: "purple-storage", Name: "preflight-worker-0"}, job))
+	assert.Equal(t, "worker-0", job.Spec.Template.Spec.NodeName)
+	assert.True(t, job.Spec.Template.Spec.HostPID)
+	assert.Equal(t, []string{"preflight", "--report", "preflight-worker-0"}, job.Spec.Template.Spec.Containers[0].Args)
+	assert.Equal(t, purplestorage.Spec.NodeSpec.Tolerations, job.Spec.Template.Spec.Tolerations)
+	assert.Equal(t, "purplestorage-uid", string(job.OwnerReferences[0].UID))
+
+	// a failed check blocks the cluster
+	setPreflightReportStatus(t, r, "preflight-worker-0", purplev1alpha1.PreflightPassed, nil)
+	setPreflightReportStatus(t, r, "preflight-worker-1", purplev1alpha1.PreflightFailed, []purplev1alpha1.PreflightCheck{
+		{Name: "ports", Result: purplev1alpha1.PreflightCheckFailed, Message: "ports 1191 are already in use"},
+		{Name: "mtu", Result: purplev1alpha1.PreflightCheckWarning, Message: "no default route found"},
+	})
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "ChecksFailed", condition.Reason)
+		assert.Equal(t, "preflight checks failed on worker-1 (ports: ports 1191 are already in use), "+
+			"delete their PreflightReport to run the checks again", condition.Message)
+	}
+
+	// all the nodes passed, their network is checked
+	setPreflightReportStatus(t, r, "preflight-worker-1", purplev1alpha1.PreflightPassed, nil)
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "NetworkCheckRunning", condition.Reason)
+	}
+	network := &purplev1alpha1.NetworkCheck{}
+	assert.NoError(t, r.Client.Get(ctx, types.NamespacedName{Namespace: "purple-storage", Name: PreflightNetworkCheckName}, network))
+	assert.Equal(t, labelsToNodeSelector(storageLabels), network.Spec.NodeSelector)
+	assert.Equal(t, purplestorage.Spec.NodeSpec.Tolerations, network.Spec.Tolerations)
+	assert.Equal(t, "purplestorage-uid", string(network.OwnerReferences[0].UID))
+
+	network.Status.Phase = purplev1alpha1.NetworkCheckFailed
+	network.Status.Message = "1 of 2 probes failed: worker-0 -> worker-1 (60000-61000)"
+	assert.NoError(t, r.Client.Status().Update(ctx, network))
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "NetworkCheckFailed", condition.Reason)
+		assert.Equal(t, "network check preflight-network failed: 1 of 2 probes failed: worker-0 -> worker-1 (60000-61000), "+
+			"delete it to run the check again", condition.Message)
+	}
+
+	network.Status.Phase = purplev1alpha1.NetworkCheckPassed
+	assert.NoError(t, r.Client.Status().Update(ctx, network))
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.True(t, passed)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+		assert.Equal(t, "preflight checks passed on 2 nodes, they reach each other on the Storage Scale ports", condition.Message)
+	}
+
+	// the network check of other nodes is deleted
+	_, err = r.reconcilePreflightNetworkCheck(ctx, purplestorage, map[string]string{"storage": "other"})
+	assert.NoError(t, err)
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: "purple-storage", Name: PreflightNetworkCheckName}, network)
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the reports of another version are deleted so that the checks run again
+	purplestorage.Spec.IbmCnsaVersion = "v5.2.2.1"
+	assert.NoError(t, r.Client.Update(ctx, purplestorage))
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	assert.NoError(t, r.Client.List(ctx, reports))
+	assert.Empty(t, reports.Items)
+	jobs := &batchv1.JobList{}
+	assert.NoError(t, r.Client.List(ctx, jobs))
+	assert.Empty(t, jobs.Items)
+
+	passed, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	reports = &purplev1alpha1.PreflightReportList{}
+	assert.NoError(t, r.Client.List(ctx, reports))
+	assert.Len(t, reports.Items, 2)
+	assert.Equal(t, "v5.2.2.1", reports.Items[0].Spec.IbmCnsaVersion)
+
+	// a job that fails before writing the report fails the node
+	job = &batchv1.Job{}
+	assert.NoError(t, r.Client.Get(ctx, types.NamespacedName{Namespace: "purple-storage", Name: "preflight-worker-0"}, job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	assert.NoError(t, r.Client.Status().Update(ctx, job))
+	_, err = r.reconcilePreflight(ctx, purplestorage, storageLabels)
+	assert.NoError(t, err)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "ChecksFailed", condition.Reason)
+		assert.Contains(t, condition.Message, "worker-0 (job preflight-worker-0 failed)")
+	}
+
+	// no node selected
+	passed, err = r.reconcilePreflight(ctx, purplestorage, map[string]string{"storage": "none"})
+	assert.NoError(t, err)
+	assert.False(t, passed)
+	condition = findPurpleStorageCondition(purplestorage, PreflightCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "NoNodes", condition.Reason)
+	}
+}
+
+func setPreflightReportStatus(t *testing.T, r *PurpleStorageReconciler, name string, phase purplev1alpha1.PreflightPhase,
+	checks []purplev1alpha1.PreflightCheck) {
+	report := &purplev1alpha1.PreflightReport{}
+	assert.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "purple-storage", Name: name}, report))
+	report.Status.Phase = phase
+	report.Status.Checks = checks
+	assert.NoError(t, r.Client.Status().Update(context.TODO(), report))
+}
+
+func findPurpleStorageCondition(purplestorage *purplev1alpha1.PurpleStorage, conditionType string) *operatorv1.OperatorCondition {
+	for i := range purplestorage.Status.Conditions {
+		if purplestorage.Status.Conditions[i].Type == conditionType {
+			return &purplestorage.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -19,9 +19,9 @@ package controller
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -33,12 +33,14 @@ import (
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mfc "github.com/manifestival/controller-runtime-client"
 	"github.com/manifestival/manifestival"
 	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
 )
 
 // PurpleStorageReconciler reconciles a PurpleStorage object
@@ -54,6 +56,14 @@ type PurpleStorageReconciler struct {
 //+kubebuilder:rbac:groups=purple.purplestorage.com,resources=purplestorages,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=purple.purplestorage.com,resources=purplestorages/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=purple.purplestorage.com,resources=purplestorages/finalizers,verbs=update
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=localvolumediscoveries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=preflightreports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=preflightreports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=networkchecks,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Operator requests the credentials to open the ports in the cloud provider
+//+kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;list;watch;create;update;patch
 
 // Operator needs to create some machine configs
 //+kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigs,verbs=get;list;watch;create;update;patch;delete
@@ -253,6 +263,16 @@ func (r *PurpleStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// Create the LocalVolumeDiscovery that runs the discovery daemons on the nodes of the NodeSpec
+	if err := r.reconcileLocalVolumeDiscovery(ctx, purplestorage); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Report whether the cluster is outside of the support matrix of the IBM CNSA version
+	if err := r.reconcileSupportCondition(ctx, purplestorage); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create machineconfig to enable kernel modules if needed
 	if purplestorage.Spec.MachineConfig.Create {
 		new_mc := NewMachineConfig(purplestorage.Spec.MachineConfig.Labels)
@@ -286,14 +306,9 @@ func (r *PurpleStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	// Load and install manifests from ibm
-	install_path := fmt.Sprintf("files/%s/install.yaml", purplestorage.Spec.IbmCnsaVersion)
-	_, err = os.Stat(install_path)
-	if os.IsNotExist(err) {
-		install_path = fmt.Sprintf("/%s", install_path)
-		_, err = os.Stat(install_path)
-		if os.IsNotExist(err) {
-			return ctrl.Result{}, err
-		}
+	install_path, err := utils.GetInstallManifestPath(purplestorage.Spec.IbmCnsaVersion)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 	installManifest, err := manifestival.NewManifest(install_path, manifestival.UseClient(mfc.NewClient(r.Client)))
 	if err != nil {
@@ -337,7 +352,12 @@ func (r *PurpleStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 	if purplestorage.Spec.Cluster.Create {
 		// Create IBM storage cluster
-		cluster := NewSpectrumCluster(purplestorage.Spec.Cluster.Daemon_nodeSelector)
+		var daemonNodeSelector map[string]string
+		daemonNodeSelector, err = getDaemonNodeSelector(purplestorage)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get the nodeSelector of the IBM daemons: %w", err)
+		}
+		cluster := NewSpectrumCluster(daemonNodeSelector)
 		gvr := schema.GroupVersionResource{
 			Group:    "scale.spectrum.ibm.com",
 			Version:  "v1beta1",
@@ -349,6 +369,30 @@ func (r *PurpleStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 		if err != nil {
 			if kerrors.IsNotFound(err) {
+				// The ports of the daemons are opened in the cloud provider before the network is checked
+				if purplestorage.Spec.CloudIntegration.OpenPorts {
+					var opened bool
+					opened, err = r.reconcileCloudPorts(ctx, purplestorage, daemonNodeSelector)
+					if err != nil {
+						return ctrl.Result{}, err
+					}
+					if !opened {
+						log.Log.Info("Waiting for the cloud credentials to open the ports of the daemons")
+						return ctrl.Result{RequeueAfter: preflightRequeueInterval}, nil
+					}
+				}
+				// The nodes must pass the preflight checks before the cluster is created
+				if !purplestorage.Spec.Preflight.Skip {
+					var passed bool
+					passed, err = r.reconcilePreflight(ctx, purplestorage, daemonNodeSelector)
+					if err != nil {
+						return ctrl.Result{}, err
+					}
+					if !passed {
+						log.Log.Info("Waiting for the preflight checks of the nodes before creating the cluster")
+						return ctrl.Result{RequeueAfter: preflightRequeueInterval}, nil
+					}
+				}
 				// Resource does not exist, create it
 				err = r.Client.Create(ctx, cluster)
 				log.Log.Info("Created cluster")
@@ -360,6 +404,25 @@ func (r *PurpleStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{}, err
 }
 
+// reconcileLocalVolumeDiscovery creates or updates the LocalVolumeDiscovery owned by the PurpleStorage
+func (r *PurpleStorageReconciler) reconcileLocalVolumeDiscovery(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage) error {
+	lvd := NewLocalVolumeDiscovery(purplestorage)
+	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, lvd, func() error {
+		if !isLocalVolumeDiscoveryManaged(lvd, purplestorage) {
+			return nil
+		}
+		setLocalVolumeDiscoverySpec(lvd, purplestorage.Spec.NodeSpec)
+		return controllerutil.SetControllerReference(purplestorage, lvd, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile LocalVolumeDiscovery %s: %w", lvd.Name, err)
+	}
+	if opResult != controllerutil.OperationResultNone {
+		log.Log.Info(fmt.Sprintf("LocalVolumeDiscovery %s %s", lvd.Name, opResult))
+	}
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PurpleStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	var err error
@@ -372,5 +435,9 @@ func (r *PurpleStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&purplev1alpha1.PurpleStorage{}).
+		Owns(&purplev1alpha1.LocalVolumeDiscovery{}).
+		Owns(&purplev1alpha1.PreflightReport{}).
+		Owns(&purplev1alpha1.NetworkCheck{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shareddeviceinventory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// SharedDeviceInventoryReconciler computes the SharedDeviceInventory from the LocalVolumeDiscoveryResults
+type SharedDeviceInventoryReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=shareddeviceinventories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=shareddeviceinventories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=purple.purplestorage.com,resources=localvolumediscoveryresults,verbs=get;list;watch
+
+// Reconcile joins the devices of all the LocalVolumeDiscoveryResults on their WWN and stores
+// the outcome in the SharedDeviceInventory singleton
+func (r *SharedDeviceInventoryReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	klog.InfoS("Reconciling SharedDeviceInventory", "name", request.Name)
+
+	results := &localv1alpha1.LocalVolumeDiscoveryResultList{}
+	if err := r.Client.List(ctx, results); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list LocalVolumeDiscoveryResult instances: %w", err)
+	}
+
+	inventory := &localv1alpha1.SharedDeviceInventory{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: localv1alpha1.SharedDeviceInventoryName}, inventory)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		inventory = &localv1alpha1.SharedDeviceInventory{
+			ObjectMeta: metav1.ObjectMeta{Name: localv1alpha1.SharedDeviceInventoryName},
+		}
+		if err := r.Client.Create(ctx, inventory); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create SharedDeviceInventory: %w", err)
+		}
+	}
+
+	devices := joinDiscoveryResults(results.Items)
+	failureDomains := groupByFailureDomain(results.Items)
+	if reflect.DeepEqual(inventory.Status.Devices, devices) && reflect.DeepEqual(inventory.Status.FailureDomains, failureDomains) &&
+		!inventory.Status.LastUpdated.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	inventory.Status.Devices = devices
+	inventory.Status.FailureDomains = failureDomains
+	inventory.Status.TotalDevices = len(devices)
+	inventory.Status.SharedDevices = 0
+	for _, device := range devices {
+		if device.Shared {
+			inventory.Status.SharedDevices++
+		}
+	}
+	inventory.Status.LastUpdated = metav1.Now()
+	if err := r.Client.Status().Update(ctx, inventory); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update SharedDeviceInventory status: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// joinDiscoveryResults groups the discovered devices of all the nodes by WWN.
+// Devices without a WWN can't be matched across nodes and are skipped. A node is listed once per
+// device, with its multipath device rather than the paths of the multipath device.
+func joinDiscoveryResults(results []localv1alpha1.LocalVolumeDiscoveryResult) []localv1alpha1.SharedDevice {
+	// the results of a node may be split in shards or come from several discoveries
+	byNode := map[string][]localv1alpha1.DiscoveredDevice{}
+	for _, result := range results {
+		byNode[result.Spec.NodeName] = append(byNode[result.Spec.NodeName], result.Status.DiscoveredDevices...)
+	}
+
+	byWWN := map[string]*localv1alpha1.SharedDevice{}
+	for nodeName, nodeDevices := range byNode {
+		for _, discovered := range localv1alpha1.UniqueDevicesByWWN(nodeDevices) {
+			if discovered.WWN == "" {
+				continue
+			}
+			device, ok := byWWN[discovered.WWN]
+			if !ok {
+				device = &localv1alpha1.SharedDevice{
+					WWN:        discovered.WWN,
+					Size:       discovered.Size,
+					SizesMatch: true,
+					PathsMatch: true,
+				}
+				byWWN[discovered.WWN] = device
+			}
+			device.Nodes = append(device.Nodes, localv1alpha1.SharedDeviceNode{
+				NodeName: nodeName,
+				Path:     discovered.Path,
+				DeviceID: discovered.DeviceID,
+				Size:     discovered.Size,
+				State:    discovered.Status.State,
+			})
+			if discovered.Status.State != localv1alpha1.Available {
+				device.Unavailable = true
+			}
+		}
+	}
+
+	devices := make([]localv1alpha1.SharedDevice, 0, len(byWWN))
+	for _, device := range byWWN {
+		sort.Slice(device.Nodes, func(i, j int) bool {
+			return device.Nodes[i].NodeName < device.Nodes[j].NodeName
+		})
+		for _, node := range device.Nodes[1:] {
+			if node.Size != device.Nodes[0].Size {
+				device.SizesMatch = false
+			}
+			if node.DeviceID != device.Nodes[0].DeviceID {
+				device.PathsMatch = false
+			}
+		}
+		device.Size = device.Nodes[0].Size
+		device.Shared = len(device.Nodes) > 1
+		devices = append(devices, *device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].WWN < devices[j].WWN
+	})
+
+	return devices
+}
+
+// groupByFailureDomain groups the discovered devices by the region, zone and rack of the nodes.
+// A device seen by several nodes of a failure domain is counted once.
+func groupByFailureDomain(results []localv1alpha1.LocalVolumeDiscoveryResult) []localv1alpha1.FailureDomain {
+	type domainKey struct{ region, zone, rack string }
+	type domainDevices struct {
+		nodes         sets.Set[string]
+		architectures sets.Set[string]
+		devices       map[string]localv1alpha1.DiscoveredDevice
+	}
+	byDomain := map[domainKey]*domainDevices{}
+	for _, result := range results {
+		topology := localv1alpha1.NodeTopology{}
+		if result.Spec.Topology != nil {
+			topology = *result.Spec.Topology
+		}
+		key := domainKey{topology.Region, topology.Zone, topology.Rack}
+		domain, ok := byDomain[key]
+		if !ok {
+			domain = &domainDevices{
+				nodes:         sets.New[string](),
+				architectures: sets.New[string](),
+				devices:       map[string]localv1alpha1.DiscoveredDevice{},
+			}
+			byDomain[key] = domain
+		}
+		domain.nodes.Insert(result.Spec.NodeName)
+		if topology.Architecture != "" {
+			domain.architectures.Insert(topology.Architecture)
+		}
+		for _, discovered := range localv1alpha1.UniqueDevicesByWWN(result.Status.DiscoveredDevices) {
+			if discovered.WWN == "" {
+				continue
+			}
+			if known, ok := domain.devices[discovered.WWN]; !ok ||
+				(discovered.Type == localv1alpha1.MultiPathType && known.Type != localv1alpha1.MultiPathType) {
+				domain.devices[discovered.WWN] = discovered
+			}
+		}
+	}
+
+	domains := make([]localv1alpha1.FailureDomain, 0, len(byDomain))
+	for key, domain := range byDomain {
+		failureDomain := localv1alpha1.FailureDomain{
+			Region: key.region,
+			Zone:   key.zone,
+			Rack:   key.rack,
+			Nodes:  sets.List(domain.nodes),
+		}
+		if domain.architectures.Len() > 0 {
+			failureDomain.Architectures = sets.List(domain.architectures)
+		}
+		for _, device := range domain.devices {
+			failureDomain.Summary.AddDevice(device)
+		}
+		domains = append(domains, failureDomain)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Region != domains[j].Region {
+			return domains[i].Region < domains[j].Region
+		}
+		if domains[i].Zone != domains[j].Zone {
+			return domains[i].Zone < domains[j].Zone
+		}
+		return domains[i].Rack < domains[j].Rack
+	})
+
+	return domains
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SharedDeviceInventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueueInventory := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: localv1alpha1.SharedDeviceInventoryName}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&localv1alpha1.SharedDeviceInventory{}).
+		Watches(&localv1alpha1.LocalVolumeDiscoveryResult{}, enqueueInventory).
+		Complete(r)
+}
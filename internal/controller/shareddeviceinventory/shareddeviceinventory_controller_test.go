@@ -0,0 +1,238 @@
+package shareddeviceinventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	localv1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const namespace = "purple-storage"
+
+func newDiscoveryResult(nodeName string, devices ...localv1alpha1.DiscoveredDevice) *localv1alpha1.LocalVolumeDiscoveryResult {
+	return &localv1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "discovery-result-" + nodeName,
+			Namespace: namespace,
+		},
+		Spec: localv1alpha1.LocalVolumeDiscoveryResultSpec{
+			NodeName: nodeName,
+		},
+		Status: localv1alpha1.LocalVolumeDiscoveryResultStatus{
+			DiscoveredDevices: devices,
+		},
+	}
+}
+
+func newFakeSharedDeviceInventoryReconciler(t *testing.T, objs ...runtime.Object) *SharedDeviceInventoryReconciler {
+	scheme, err := localv1alpha1.SchemeBuilder.Build()
+	assert.NoErrorf(t, err, "creating scheme")
+
+	client := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&localv1alpha1.SharedDeviceInventory{}).
+		WithRuntimeObjects(objs...).Build()
+
+	return &SharedDeviceInventoryReconciler{
+		Client: client,
+		Scheme: scheme,
+	}
+}
+
+func TestSharedDeviceInventoryReconciler(t *testing.T) {
+	lun1 := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x6005076810810261f800000000000001",
+		Path:     "/dev/dm-0",
+		Size:     107374182400,
+		WWN:      "0x6005076810810261f800000000000001",
+		Status:   localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	lun2 := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x6005076810810261f800000000000002",
+		Path:     "/dev/dm-1",
+		Size:     107374182400,
+		WWN:      "0x6005076810810261f800000000000002",
+		Status:   localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	lun2Resized := lun2
+	lun2Resized.Size = 214748364800
+	lun2Resized.Status.State = localv1alpha1.NotAvailable
+	lun2Resized.Path = "/dev/dm-3"
+	local := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		Path:     "/dev/sdb",
+		Size:     479559942144,
+		WWN:      "0x5000c500a1b2c3d4",
+		Status:   localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	noWWN := localv1alpha1.DiscoveredDevice{Path: "/dev/sdc", Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Available}}
+
+	fakeReconciler := newFakeSharedDeviceInventoryReconciler(t,
+		newDiscoveryResult("worker-1", lun1, lun2, local, noWWN),
+		newDiscoveryResult("worker-0", lun1, lun2Resized),
+	)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: localv1alpha1.SharedDeviceInventoryName}}
+	_, err := fakeReconciler.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+
+	inventory := &localv1alpha1.SharedDeviceInventory{}
+	err = fakeReconciler.Client.Get(context.TODO(), client.ObjectKey{Name: localv1alpha1.SharedDeviceInventoryName}, inventory)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, inventory.Status.TotalDevices)
+	assert.Equal(t, 2, inventory.Status.SharedDevices)
+
+	devices := map[string]localv1alpha1.SharedDevice{}
+	for _, device := range inventory.Status.Devices {
+		devices[device.WWN] = device
+	}
+
+	shared := devices[lun1.WWN]
+	assert.True(t, shared.Shared)
+	assert.True(t, shared.SizesMatch)
+	assert.True(t, shared.PathsMatch)
+	assert.False(t, shared.Unavailable)
+	assert.Equal(t, "worker-0", shared.Nodes[0].NodeName)
+	assert.Equal(t, "worker-1", shared.Nodes[1].NodeName)
+
+	mismatched := devices[lun2.WWN]
+	assert.True(t, mismatched.Shared)
+	assert.False(t, mismatched.SizesMatch)
+	assert.True(t, mismatched.PathsMatch)
+	assert.True(t, mismatched.Unavailable)
+
+	single := devices[local.WWN]
+	assert.False(t, single.Shared)
+	assert.Len(t, single.Nodes, 1)
+
+	// a second reconcile without changes keeps the inventory as is
+	lastUpdated := inventory.Status.LastUpdated
+	_, err = fakeReconciler.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	err = fakeReconciler.Client.Get(context.TODO(), client.ObjectKey{Name: localv1alpha1.SharedDeviceInventoryName}, inventory)
+	assert.NoError(t, err)
+	assert.Equal(t, lastUpdated, inventory.Status.LastUpdated)
+}
+
+func TestJoinMultipathDevices(t *testing.T) {
+	const wwn = "0x60014053a37f792d97e41faab6e498e6"
+	path := func(kname string) localv1alpha1.DiscoveredDevice {
+		return localv1alpha1.DiscoveredDevice{
+			Path:   "/dev/" + kname,
+			Type:   localv1alpha1.DiskType,
+			FSType: "mpath_member",
+			Size:   75161927680,
+			WWN:    wwn,
+			Status: localv1alpha1.DeviceStatus{State: localv1alpha1.NotAvailable},
+		}
+	}
+	mpath := localv1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/dm-uuid-mpath-3" + wwn[2:],
+		Path:     "/dev/dm-0",
+		Type:     localv1alpha1.MultiPathType,
+		Size:     75161927680,
+		WWN:      wwn,
+		Status:   localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+
+	testcases := []struct {
+		label    string
+		results  []localv1alpha1.LocalVolumeDiscoveryResult
+		expected []localv1alpha1.SharedDeviceNode
+	}{
+		{
+			label:   "case 1", // the paths are replaced by their multipath device
+			results: []localv1alpha1.LocalVolumeDiscoveryResult{*newDiscoveryResult("worker-0", path("sdc"), path("sdf"), mpath)},
+			expected: []localv1alpha1.SharedDeviceNode{
+				{NodeName: "worker-0", Path: "/dev/dm-0", DeviceID: mpath.DeviceID, Size: 75161927680, State: localv1alpha1.Available},
+			},
+		},
+		{
+			label:   "case 2", // without multipath device, the node is listed once with the first path
+			results: []localv1alpha1.LocalVolumeDiscoveryResult{*newDiscoveryResult("worker-0", path("sdc"), path("sdf"))},
+			expected: []localv1alpha1.SharedDeviceNode{
+				{NodeName: "worker-0", Path: "/dev/sdc", Size: 75161927680, State: localv1alpha1.NotAvailable},
+			},
+		},
+		{
+			label: "case 3", // the multipath device is in a shard of the node
+			results: []localv1alpha1.LocalVolumeDiscoveryResult{
+				*newDiscoveryResult("worker-0", path("sdc"), path("sdf")),
+				*newDiscoveryResult("worker-0", mpath),
+			},
+			expected: []localv1alpha1.SharedDeviceNode{
+				{NodeName: "worker-0", Path: "/dev/dm-0", DeviceID: mpath.DeviceID, Size: 75161927680, State: localv1alpha1.Available},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		devices := joinDiscoveryResults(tc.results)
+		if !assert.Lenf(t, devices, 1, "[%s] invalid number of devices", tc.label) {
+			continue
+		}
+		assert.Equalf(t, tc.expected, devices[0].Nodes, "[%s] invalid nodes", tc.label)
+		assert.Falsef(t, devices[0].Shared, "[%s] a LUN seen by a single node is not shared", tc.label)
+		assert.Truef(t, devices[0].PathsMatch, "[%s] invalid paths match", tc.label)
+	}
+}
+
+func TestGroupByFailureDomain(t *testing.T) {
+	lun1 := localv1alpha1.DiscoveredDevice{
+		Path:   "/dev/dm-0",
+		Size:   107374182400,
+		WWN:    "0x6005076810810261f800000000000001",
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+	local0 := localv1alpha1.DiscoveredDevice{
+		Path:   "/dev/sdb",
+		Size:   479559942144,
+		WWN:    "0x5000c500a1b2c3d4",
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.NotAvailable},
+	}
+	local2 := localv1alpha1.DiscoveredDevice{
+		Path:   "/dev/sdb",
+		Size:   479559942144,
+		WWN:    "0x5000c500a1b2c3d5",
+		Status: localv1alpha1.DeviceStatus{State: localv1alpha1.Available},
+	}
+
+	worker0 := newDiscoveryResult("worker-0", lun1, local0)
+	worker0.Spec.Topology = &localv1alpha1.NodeTopology{Region: "eu", Zone: "eu-1a", Rack: "r1", Architecture: "amd64"}
+	worker1 := newDiscoveryResult("worker-1", lun1)
+	worker1.Spec.Topology = &localv1alpha1.NodeTopology{Region: "eu", Zone: "eu-1a", Rack: "r1", Architecture: "arm64"}
+	worker2 := newDiscoveryResult("worker-2", lun1, local2)
+	worker2.Spec.Topology = &localv1alpha1.NodeTopology{Region: "eu", Zone: "eu-1b", Architecture: "amd64"}
+	worker3 := newDiscoveryResult("worker-3", local2)
+
+	domains := groupByFailureDomain([]localv1alpha1.LocalVolumeDiscoveryResult{*worker2, *worker1, *worker0, *worker3})
+	assert.Equal(t, []localv1alpha1.FailureDomain{
+		{
+			Nodes:   []string{"worker-3"},
+			Summary: localv1alpha1.DiscoverySummary{TotalDevices: 1, AvailableDevices: 1, TotalCapacity: 479559942144, AvailableCapacity: 479559942144},
+		},
+		{
+			Region:        "eu",
+			Zone:          "eu-1a",
+			Rack:          "r1",
+			Nodes:         []string{"worker-0", "worker-1"},
+			Architectures: []string{"amd64", "arm64"},
+			// the LUN seen by both nodes is counted once
+			Summary: localv1alpha1.DiscoverySummary{TotalDevices: 2, AvailableDevices: 1, NotAvailableDevices: 1,
+				TotalCapacity: 586934124544, AvailableCapacity: 107374182400},
+		},
+		{
+			Region:        "eu",
+			Zone:          "eu-1b",
+			Nodes:         []string{"worker-2"},
+			Architectures: []string{"amd64"},
+			Summary: localv1alpha1.DiscoverySummary{TotalDevices: 2, AvailableDevices: 2,
+				TotalCapacity: 586934124544, AvailableCapacity: 586934124544},
+		},
+	}, domains)
+}
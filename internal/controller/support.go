@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+)
+
+// UnsupportedCondition is set on the PurpleStorage when the cluster is outside of the support matrix of its IBM CNSA version
+const UnsupportedCondition = "UnsupportedConfiguration"
+
+// reconcileSupportCondition checks the support matrix against the current OpenShift version and nodes of the daemons.
+// The webhook only admits unsupported configurations with the allow-unsupported annotation, every such override is
+// kept in the UnsupportedConfiguration condition, as well as the nodes and upgrades that left the matrix afterwards.
+func (r *PurpleStorageReconciler) reconcileSupportCondition(ctx context.Context, purplestorage *purplev1alpha1.PurpleStorage) error {
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		return fmt.Errorf("failed to get the ClusterVersion: %w", err)
+	}
+	ocpVersion, err := utils.GetCurrentClusterVersion(clusterVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get current cluster version: %w", err)
+	}
+	// the support matrix can be overridden in the namespace of the PurpleStorage
+	configMap := &corev1.ConfigMap{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: utils.SupportMatrixConfigMap, Namespace: purplestorage.Namespace}, configMap)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get ConfigMap %s: %w", utils.SupportMatrixConfigMap, err)
+		}
+		configMap = nil
+	}
+	matrix, err := utils.GetSupportMatrix(configMap)
+	if err != nil {
+		return err
+	}
+	nodes := &corev1.NodeList{}
+	if err := r.Client.List(ctx, nodes); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+	architectures, err := purplev1alpha1.GetDaemonNodeArchitectures(purplestorage, nodes.Items)
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:    UnsupportedCondition,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "Supported",
+		Message: fmt.Sprintf("IBM CNSA version %s supports OpenShift %s and the nodes of the daemons", purplestorage.Spec.IbmCnsaVersion, ocpVersion),
+	}
+	if issues := utils.GetSupportIssues(matrix, purplestorage.Spec.IbmCnsaVersion, ocpVersion, architectures); len(issues) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "NotSupported"
+		condition.Message = strings.Join(issues, "; ")
+		if purplestorage.IsUnsupportedAllowed() {
+			condition.Reason = "AllowedByAnnotation"
+			condition.Message = fmt.Sprintf("allowed by the %s annotation: %s", purplev1alpha1.AllowUnsupportedAnnotation, condition.Message)
+		}
+	}
+	return r.setCondition(ctx, purplestorage, condition)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	purplev1alpha1 "github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+)
+
+func TestReconcileSupportCondition(t *testing.T) {
+	// the install manifests are looked up relative to the root of the repository
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir("../.."))
+	defer func() { _ = os.Chdir(wd) }()
+
+	ctx := context.TODO()
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			History: []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Version: "4.16.3"}},
+		},
+	}
+	storageLabels := map[string]string{"storage": "true"}
+	worker := newPreflightNode("worker-0", storageLabels)
+	worker.Status.NodeInfo.Architecture = "amd64"
+	arm := newPreflightNode("worker-1", nil)
+	arm.Status.NodeInfo.Architecture = "arm64"
+	purplestorage := &purplev1alpha1.PurpleStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "purplestorage-sample", Namespace: "purple-storage"},
+		Spec: purplev1alpha1.PurpleStorageSpec{
+			IbmCnsaVersion: "v5.2.2.0",
+			Cluster:        purplev1alpha1.IBMSpectrumCluster{Daemon_nodeSelector: storageLabels},
+		},
+	}
+	r := newFakePurpleStorageReconciler(t, purplestorage, clusterVersion, worker, arm)
+
+	// the nodes of the daemons are supported
+	assert.NoError(t, r.reconcileSupportCondition(ctx, purplestorage))
+	condition := findPurpleStorageCondition(purplestorage, UnsupportedCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+		assert.Equal(t, "Supported", condition.Reason)
+	}
+
+	// an arm64 node of the daemons is reported
+	arm.Labels = storageLabels
+	assert.NoError(t, r.Client.Update(ctx, arm))
+	assert.NoError(t, r.reconcileSupportCondition(ctx, purplestorage))
+	condition = findPurpleStorageCondition(purplestorage, UnsupportedCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+		assert.Equal(t, "NotSupported", condition.Reason)
+		assert.Equal(t, "IBM CNSA version v5.2.2.0 does not support the aarch64 architecture of nodes worker-1", condition.Message)
+	}
+
+	// the architecture is supported by the ConfigMap that overrides the support matrix
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.SupportMatrixConfigMap, Namespace: "purple-storage"},
+		Data: map[string]string{"v5.2.2.0": `schema_version: 1
+csi_version: "2.13.0"
+architecture: [x86_64, aarch64]
+openshift_levels: ["4.16"]
+`},
+	}
+	assert.NoError(t, r.Client.Create(ctx, configMap))
+	assert.NoError(t, r.reconcileSupportCondition(ctx, purplestorage))
+	condition = findPurpleStorageCondition(purplestorage, UnsupportedCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "Supported", condition.Reason)
+	}
+	assert.NoError(t, r.Client.Delete(ctx, configMap))
+
+	// the override of the annotation is recorded
+	purplestorage.Annotations = map[string]string{purplev1alpha1.AllowUnsupportedAnnotation: "true"}
+	assert.NoError(t, r.Client.Update(ctx, purplestorage))
+	assert.NoError(t, r.reconcileSupportCondition(ctx, purplestorage))
+	condition = findPurpleStorageCondition(purplestorage, UnsupportedCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+		assert.Equal(t, "AllowedByAnnotation", condition.Reason)
+		assert.Equal(t, "allowed by the purple.purplestorage.com/allow-unsupported annotation: "+
+			"IBM CNSA version v5.2.2.0 does not support the aarch64 architecture of nodes worker-1", condition.Message)
+	}
+}
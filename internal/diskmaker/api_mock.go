@@ -2,17 +2,30 @@ package diskmaker
 
 import (
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // MockAPIUpdater mocks all the ApiUpdater Commands
 type MockAPIUpdater struct {
-	events                          []*DiskEvent
-	MockGetDiscoveryResult          func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error)
-	MockCreateDiscoveryResult       func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
-	MockUpdateDiscoveryResultStatus func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
-	MockUpdateDiscoveryResult       func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
-	MockGetLocalVolumeDiscovery     func(name, namespace string) (*v1alpha1.LocalVolumeDiscovery, error)
+	events                             []*DiskEvent
+	MockGetDiscoveryResult             func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error)
+	MockCreateDiscoveryResult          func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	MockUpdateDiscoveryResultStatus    func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	MockUpdateDiscoveryResult          func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	MockApplyDiscoveryResult           func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	MockApplyDiscoveryResultStatus     func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	MockDeleteDiscoveryResult          func(name, namespace string) error
+	MockGetLocalVolumeDiscovery        func(name, namespace string) (*v1alpha1.LocalVolumeDiscovery, error)
+	MockListDiskPrepareRequests        func(namespace string) (*v1alpha1.DiskPrepareRequestList, error)
+	MockUpdateDiskPrepareRequestStatus func(dpr *v1alpha1.DiskPrepareRequest) error
+	MockGetPreflightReport             func(name, namespace string) (*v1alpha1.PreflightReport, error)
+	MockUpdatePreflightReportStatus    func(report *v1alpha1.PreflightReport) error
+	MockGetNetworkCheck                func(name, namespace string) (*v1alpha1.NetworkCheck, error)
+	MockApplyNetworkCheckProbes        func(name, namespace, nodeName string, probes []v1alpha1.NetworkProbe) error
+	MockListLocalDiskDevices           func(nodeName string) ([]string, error)
+	MockGetNode                        func(name string) (*corev1.Node, error)
+	MockGetConfigMap                   func(name, namespace string) (*corev1.ConfigMap, error)
 }
 
 var _ ApiUpdater = &MockAPIUpdater{}
@@ -57,6 +70,33 @@ func (f *MockAPIUpdater) UpdateDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscove
 	return nil
 }
 
+// ApplyDiscoveryResult mocks ApplyDiscoveryResult
+func (f *MockAPIUpdater) ApplyDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+	if f.MockApplyDiscoveryResult != nil {
+		return f.MockApplyDiscoveryResult(lvdr)
+	}
+
+	return nil
+}
+
+// ApplyDiscoveryResultStatus mocks ApplyDiscoveryResultStatus
+func (f *MockAPIUpdater) ApplyDiscoveryResultStatus(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+	if f.MockApplyDiscoveryResultStatus != nil {
+		return f.MockApplyDiscoveryResultStatus(lvdr)
+	}
+
+	return nil
+}
+
+// DeleteDiscoveryResult mocks DeleteDiscoveryResult
+func (f *MockAPIUpdater) DeleteDiscoveryResult(name, namespace string) error {
+	if f.MockDeleteDiscoveryResult != nil {
+		return f.MockDeleteDiscoveryResult(name, namespace)
+	}
+
+	return nil
+}
+
 // GetLocalVolumeDiscovery mocks GetLocalVolumeDiscovery
 func (f *MockAPIUpdater) GetLocalVolumeDiscovery(name, namespace string) (*v1alpha1.LocalVolumeDiscovery, error) {
 	if f.MockGetLocalVolumeDiscovery != nil {
@@ -65,3 +105,84 @@ func (f *MockAPIUpdater) GetLocalVolumeDiscovery(name, namespace string) (*v1alp
 
 	return &v1alpha1.LocalVolumeDiscovery{}, nil
 }
+
+// ListDiskPrepareRequests mocks ListDiskPrepareRequests
+func (f *MockAPIUpdater) ListDiskPrepareRequests(namespace string) (*v1alpha1.DiskPrepareRequestList, error) {
+	if f.MockListDiskPrepareRequests != nil {
+		return f.MockListDiskPrepareRequests(namespace)
+	}
+
+	return &v1alpha1.DiskPrepareRequestList{}, nil
+}
+
+// UpdateDiskPrepareRequestStatus mocks UpdateDiskPrepareRequestStatus
+func (f *MockAPIUpdater) UpdateDiskPrepareRequestStatus(dpr *v1alpha1.DiskPrepareRequest) error {
+	if f.MockUpdateDiskPrepareRequestStatus != nil {
+		return f.MockUpdateDiskPrepareRequestStatus(dpr)
+	}
+
+	return nil
+}
+
+// GetPreflightReport mocks GetPreflightReport
+func (f *MockAPIUpdater) GetPreflightReport(name, namespace string) (*v1alpha1.PreflightReport, error) {
+	if f.MockGetPreflightReport != nil {
+		return f.MockGetPreflightReport(name, namespace)
+	}
+
+	return &v1alpha1.PreflightReport{}, nil
+}
+
+// UpdatePreflightReportStatus mocks UpdatePreflightReportStatus
+func (f *MockAPIUpdater) UpdatePreflightReportStatus(report *v1alpha1.PreflightReport) error {
+	if f.MockUpdatePreflightReportStatus != nil {
+		return f.MockUpdatePreflightReportStatus(report)
+	}
+
+	return nil
+}
+
+// GetNetworkCheck mocks GetNetworkCheck
+func (f *MockAPIUpdater) GetNetworkCheck(name, namespace string) (*v1alpha1.NetworkCheck, error) {
+	if f.MockGetNetworkCheck != nil {
+		return f.MockGetNetworkCheck(name, namespace)
+	}
+
+	return &v1alpha1.NetworkCheck{}, nil
+}
+
+// ApplyNetworkCheckProbes mocks ApplyNetworkCheckProbes
+func (f *MockAPIUpdater) ApplyNetworkCheckProbes(name, namespace, nodeName string, probes []v1alpha1.NetworkProbe) error {
+	if f.MockApplyNetworkCheckProbes != nil {
+		return f.MockApplyNetworkCheckProbes(name, namespace, nodeName, probes)
+	}
+
+	return nil
+}
+
+// ListLocalDiskDevices mocks ListLocalDiskDevices
+func (f *MockAPIUpdater) ListLocalDiskDevices(nodeName string) ([]string, error) {
+	if f.MockListLocalDiskDevices != nil {
+		return f.MockListLocalDiskDevices(nodeName)
+	}
+
+	return []string{}, nil
+}
+
+// GetNode mocks GetNode
+func (f *MockAPIUpdater) GetNode(name string) (*corev1.Node, error) {
+	if f.MockGetNode != nil {
+		return f.MockGetNode(name)
+	}
+
+	return &corev1.Node{}, nil
+}
+
+// GetConfigMap mocks GetConfigMap
+func (f *MockAPIUpdater) GetConfigMap(name, namespace string) (*corev1.ConfigMap, error) {
+	if f.MockGetConfigMap != nil {
+		return f.MockGetConfigMap(name, namespace)
+	}
+
+	return &corev1.ConfigMap{}, nil
+}
@@ -8,7 +8,11 @@ import (
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -18,7 +22,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-const componentName = "local-storage-diskmaker"
+const (
+	componentName = "local-storage-diskmaker"
+	// fieldOwner is the field manager used for the server-side apply patches of the discovery results
+	fieldOwner = "diskmaker-discovery"
+	// networkCheckFieldOwnerPrefix prefixes the node name in the field manager of the probes of a node
+	networkCheckFieldOwnerPrefix = "diskmaker-netcheck-"
+)
+
+// localDiskListGVK is the Storage Scale LocalDisk list
+var localDiskListGVK = schema.GroupVersionKind{Group: "scale.spectrum.ibm.com", Version: "v1beta1", Kind: "LocalDiskList"}
 
 type ApiUpdater interface {
 	recordEvent(obj runtime.Object, e *DiskEvent)
@@ -26,7 +39,19 @@ type ApiUpdater interface {
 	GetDiscoveryResult(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error)
 	UpdateDiscoveryResultStatus(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
 	UpdateDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	ApplyDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	ApplyDiscoveryResultStatus(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error
+	DeleteDiscoveryResult(name, namespace string) error
 	GetLocalVolumeDiscovery(name, namespace string) (*v1alpha1.LocalVolumeDiscovery, error)
+	ListDiskPrepareRequests(namespace string) (*v1alpha1.DiskPrepareRequestList, error)
+	UpdateDiskPrepareRequestStatus(dpr *v1alpha1.DiskPrepareRequest) error
+	GetPreflightReport(name, namespace string) (*v1alpha1.PreflightReport, error)
+	UpdatePreflightReportStatus(report *v1alpha1.PreflightReport) error
+	GetNetworkCheck(name, namespace string) (*v1alpha1.NetworkCheck, error)
+	ApplyNetworkCheckProbes(name, namespace, nodeName string, probes []v1alpha1.NetworkProbe) error
+	ListLocalDiskDevices(nodeName string) ([]string, error)
+	GetNode(name string) (*v1.Node, error)
+	GetConfigMap(name, namespace string) (*v1.ConfigMap, error)
 }
 
 type sdkAPIUpdater struct {
@@ -107,8 +132,126 @@ func (s *sdkAPIUpdater) UpdateDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscover
 	return s.client.Update(context.TODO(), lvdr)
 }
 
+// ApplyDiscoveryResult creates or updates the metadata and spec of a result with a server-side apply patch.
+// The patches force the ownership of the fields and have no resourceVersion, they don't conflict.
+func (s *sdkAPIUpdater) ApplyDiscoveryResult(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+	obj := newApplyObject(lvdr)
+	obj.Labels = lvdr.Labels
+	obj.OwnerReferences = lvdr.OwnerReferences
+	obj.Spec = lvdr.Spec
+	return s.client.Patch(context.TODO(), obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
+
+// ApplyDiscoveryResultStatus updates the status of a result with a server-side apply patch
+func (s *sdkAPIUpdater) ApplyDiscoveryResultStatus(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+	obj := newApplyObject(lvdr)
+	obj.Status = lvdr.Status
+	return s.client.Status().Patch(context.TODO(), obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
+
+func (s *sdkAPIUpdater) DeleteDiscoveryResult(name, namespace string) error {
+	discoveryResult := &v1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	return client.IgnoreNotFound(s.client.Delete(context.TODO(), discoveryResult))
+}
+
+// newApplyObject returns an object with only the identity of lvdr set, to build apply patches from
+func newApplyObject(lvdr *v1alpha1.LocalVolumeDiscoveryResult) *v1alpha1.LocalVolumeDiscoveryResult {
+	return &v1alpha1.LocalVolumeDiscoveryResult{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "LocalVolumeDiscoveryResult",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lvdr.Name,
+			Namespace: lvdr.Namespace,
+		},
+	}
+}
+
 func (s *sdkAPIUpdater) GetLocalVolumeDiscovery(name, namespace string) (*v1alpha1.LocalVolumeDiscovery, error) {
 	discoveryCR := &v1alpha1.LocalVolumeDiscovery{}
 	err := s.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, discoveryCR)
 	return discoveryCR, err
 }
+
+func (s *sdkAPIUpdater) GetNode(name string) (*v1.Node, error) {
+	node := &v1.Node{}
+	err := s.client.Get(context.TODO(), types.NamespacedName{Name: name}, node)
+	return node, err
+}
+
+func (s *sdkAPIUpdater) GetConfigMap(name, namespace string) (*v1.ConfigMap, error) {
+	configMap := &v1.ConfigMap{}
+	err := s.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, configMap)
+	return configMap, err
+}
+
+func (s *sdkAPIUpdater) ListDiskPrepareRequests(namespace string) (*v1alpha1.DiskPrepareRequestList, error) {
+	requests := &v1alpha1.DiskPrepareRequestList{}
+	err := s.client.List(context.TODO(), requests, client.InNamespace(namespace))
+	return requests, err
+}
+
+func (s *sdkAPIUpdater) UpdateDiskPrepareRequestStatus(dpr *v1alpha1.DiskPrepareRequest) error {
+	return s.client.Status().Update(context.TODO(), dpr)
+}
+
+func (s *sdkAPIUpdater) GetPreflightReport(name, namespace string) (*v1alpha1.PreflightReport, error) {
+	report := &v1alpha1.PreflightReport{}
+	err := s.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, report)
+	return report, err
+}
+
+func (s *sdkAPIUpdater) UpdatePreflightReportStatus(report *v1alpha1.PreflightReport) error {
+	return s.client.Status().Update(context.TODO(), report)
+}
+
+func (s *sdkAPIUpdater) GetNetworkCheck(name, namespace string) (*v1alpha1.NetworkCheck, error) {
+	check := &v1alpha1.NetworkCheck{}
+	err := s.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, check)
+	return check, err
+}
+
+// ApplyNetworkCheckProbes sets the probes sent by a node in the matrix of a check with a server-side apply
+// patch. Each node is a field manager of its own, so that the nodes don't overwrite the probes of each other.
+func (s *sdkAPIUpdater) ApplyNetworkCheckProbes(name, namespace, nodeName string, probes []v1alpha1.NetworkProbe) error {
+	obj := &v1alpha1.NetworkCheck{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "NetworkCheck",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	obj.Status.Probes = probes
+	return s.client.Status().Patch(context.TODO(), obj, client.Apply, client.FieldOwner(networkCheckFieldOwnerPrefix+nodeName),
+		client.ForceOwnership)
+}
+
+// ListLocalDiskDevices returns the device paths of the Storage Scale LocalDisks of the node, in all the namespaces.
+// The path is the one given when the LocalDisk was created, the device may have another name since then.
+// It returns an empty list when Storage Scale is not installed.
+func (s *sdkAPIUpdater) ListLocalDiskDevices(nodeName string) ([]string, error) {
+	localDisks := &unstructured.UnstructuredList{}
+	localDisks.SetGroupVersionKind(localDiskListGVK)
+	if err := s.client.List(context.TODO(), localDisks); err != nil {
+		if meta.IsNoMatchError(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	devices := []string{}
+	for _, localDisk := range localDisks.Items {
+		node, _, _ := unstructured.NestedString(localDisk.Object, "spec", "node")
+		device, _, _ := unstructured.NestedString(localDisk.Object, "spec", "device")
+		if node == nodeName && device != "" {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
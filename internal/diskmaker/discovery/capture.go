@@ -0,0 +1,64 @@
+package discovery
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"k8s.io/klog/v2"
+)
+
+// CaptureSnapshot records everything the discovery reads from the host, along with the multipath
+// maps, the mount points and the udev properties of the block devices, so that the discovery can
+// be replayed anywhere with a diskutil.ReplayHost
+func CaptureSnapshot(host diskutil.Host) (*diskutil.Snapshot, error) {
+	recorder := diskutil.NewRecordingHost(host)
+	if _, err := GetInventory(recorder); err != nil {
+		return nil, errors.Wrap(err, "failed to capture the discovery")
+	}
+
+	// the multipath maps and the mount points are only read for the devices that need them
+	if _, err := diskutil.GetMultipathMaps(recorder); err != nil {
+		klog.Warningf("failed to capture multipath maps: %v", err)
+	}
+	if _, err := recorder.Glob(filepath.Join(diskutil.DiskDMDir, "*")); err != nil {
+		klog.Warningf("failed to capture %s: %v", diskutil.DiskDMDir, err)
+	}
+	if _, err := recorder.MountInfo(); err != nil {
+		klog.Warningf("failed to capture mount points: %v", err)
+	}
+
+	snapshot := recorder.Snapshot()
+	blockDevices, _, err := diskutil.ListBlockDevices(diskutil.NewReplayHost(snapshot), []string{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the captured block devices")
+	}
+	for _, kname := range getKNames(blockDevices) {
+		output, err := host.Execute("udevadm", "info", "--query=property", "--name="+filepath.Join("/dev", kname)).CombinedOutput()
+		if err != nil {
+			klog.Warningf("failed to capture udev properties of %q: %v", kname, err)
+			continue
+		}
+		snapshot.Udev[kname] = string(output)
+	}
+
+	return snapshot, nil
+}
+
+// getKNames returns the kernel names of the block devices and of their children
+func getKNames(blockDevices []diskutil.BlockDevice) []string {
+	knames := []string{}
+	seen := map[string]bool{}
+	var walk func([]diskutil.BlockDevice)
+	walk = func(devices []diskutil.BlockDevice) {
+		for _, dev := range devices {
+			if dev.KName != "" && !seen[dev.KName] {
+				seen[dev.KName] = true
+				knames = append(knames, dev.KName)
+			}
+			walk(dev.Children)
+		}
+	}
+	walk(blockDevices)
+	return knames
+}
@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+)
+
+func TestCaptureSnapshot(t *testing.T) {
+	// capture a node served by a snapshot, the replay of the capture must discover the same devices
+	node, err := diskutils.LoadSnapshot(filepath.Join(snapshotsDir, "mpath-node"))
+	assert.NoError(t, err)
+	nodeHost := diskutils.NewReplayHost(node)
+	host := &diskutils.MockHost{
+		MockExecute: func(name string, args ...string) diskutils.Command {
+			if name == "udevadm" {
+				return &mockCmdExec{stdout: []string{"ID_BUS=scsi\n"}}
+			}
+			return nodeHost.Execute(name, args...)
+		},
+		MockGlob:               nodeHost.Glob,
+		MockEvalSymlinks:       nodeHost.EvalSymlinks,
+		MockMountInfo:          nodeHost.MountInfo,
+		MockCanOpenExclusively: nodeHost.CanOpenExclusively,
+		MockReadDeviceHeader:   nodeHost.ReadDeviceHeader,
+	}
+
+	snapshot, err := CaptureSnapshot(host)
+	assert.NoError(t, err)
+	assert.Equal(t, node.MountInfo, snapshot.MountInfo)
+	assert.Equal(t, "/dev/dm-0", snapshot.Symlinks["/dev/mapper/mpatha"])
+	// udev properties of the disks and of their partitions
+	assert.Contains(t, snapshot.Udev, "sdb")
+	assert.Contains(t, snapshot.Udev, "sdb4")
+
+	tarball := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	f, err := os.Create(tarball)
+	assert.NoError(t, err)
+	assert.NoError(t, snapshot.WriteTarball(f))
+	assert.NoError(t, f.Close())
+
+	replayHost, err := diskutils.NewReplayHostFromPath(tarball)
+	assert.NoError(t, err)
+	expected, err := GetInventory(nodeHost)
+	assert.NoError(t, err)
+	actual, err := GetInventory(replayHost)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
@@ -10,30 +10,48 @@ import (
 	"time"
 
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
 	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
 
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 )
 
 const (
-	localVolumeDiscoveryComponent = "auto-discover-devices"
-	udevEventPeriod               = 5 * time.Second
-	probeInterval                 = 5 * time.Minute
-	resultCRName                  = "discovery-result-%s"
+	defaultUdevEventPeriod = 5 * time.Second
+	defaultProbeInterval   = 5 * time.Minute
+	resultCRName           = "discovery-result-%s"
+	// discoveryResultCRName is the name of the results of the discoveries other than auto-discover-devices,
+	// whose results keep the historical resultCRName
+	discoveryResultCRName = "discovery-result-%s-%%s"
+	// maxDevicesPerResult bounds the size of a LocalVolumeDiscoveryResult on nodes with many devices
+	maxDevicesPerResult = 100
 )
 
 var supportedDeviceTypes = sets.NewString("mpath", "disk")
 
 // DeviceDiscovery instance
 type DeviceDiscovery struct {
+	host                 diskutil.Host
 	apiClient            diskmaker.ApiUpdater
 	eventSync            *diskmaker.EventReporter
 	disks                []v1alpha1.DiscoveredDevice
+	ignoredDevices       []v1alpha1.IgnoredDevice
+	initiators           *v1alpha1.NodeInitiators
 	localVolumeDiscovery *v1alpha1.LocalVolumeDiscovery
+	// lastUpdate is the last time the LocalVolumeDiscoveryResult status was written
+	lastUpdate time.Time
+	// lastSeenRefreshInterval is the minimum time between status updates that only refresh lastSeen
+	lastSeenRefreshInterval time.Duration
+	// expectedPaths keeps the highest number of paths seen per multipath device WWN
+	expectedPaths map[string]int
+	// clock drives the probe interval and the DiskPrepareRequest lookups
+	clock clock.WithTicker
 }
 
 // NewDeviceDiscovery returns a new DeviceDiscovery instance
@@ -52,9 +70,15 @@ func NewDeviceDiscovery() (*DeviceDiscovery, error) {
 	}
 
 	dd := &DeviceDiscovery{}
+	dd.host = diskutil.NewLiveHost()
+	dd.clock = clock.RealClock{}
 	dd.apiClient = apiUpdater
 	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
-	lvd, err := dd.apiClient.GetLocalVolumeDiscovery(localVolumeDiscoveryComponent, os.Getenv("WATCH_NAMESPACE"))
+	lvdName := os.Getenv("DISCOVERY_OBJECT_NAME")
+	if lvdName == "" {
+		lvdName = common.LocalVolumeDiscoveryName
+	}
+	lvd, err := dd.apiClient.GetLocalVolumeDiscovery(lvdName, os.Getenv("WATCH_NAMESPACE"))
 	if err != nil {
 		klog.Error(err, "failed to get LocalVolumeDiscovery object")
 		return &DeviceDiscovery{}, err
@@ -74,6 +98,11 @@ func (discovery *DeviceDiscovery) Start() error {
 		return errors.Wrapf(err, message)
 	}
 
+	settings := getDiscoverySettings()
+	klog.Infof("probe interval %s, udev monitoring enabled %t, udev event period %s",
+		settings.probeInterval, settings.udevMonitoringEnabled, settings.udevEventPeriod)
+	discovery.lastSeenRefreshInterval = getLastSeenRefreshInterval(settings.probeInterval)
+
 	err = discovery.discoverDevices()
 	if err != nil {
 		errors.Wrapf(err, "failed to discover devices")
@@ -83,23 +112,51 @@ func (discovery *DeviceDiscovery) Start() error {
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGTERM)
 
-	udevEvents := make(chan string)
-	go udevBlockMonitor(udevEvents, udevEventPeriod)
+	var udevEvents chan string
+	if settings.udevMonitoringEnabled {
+		udevEvents = make(chan string)
+		go udevBlockMonitor(udevEvents, settings.udevEventPeriod, settings.udevExclusionFilter)
+	}
+
+	discovery.watch(sigc, udevEvents, settings.probeInterval)
+	return nil
+}
+
+// watch runs the discovery every probe interval and on the udev events, and looks up the
+// DiskPrepareRequests every prepareRequestInterval, until the shutdown signal is received.
+// A discovery triggered by udev restarts the probe interval.
+func (discovery *DeviceDiscovery) watch(sigc <-chan os.Signal, udevEvents chan string, probeInterval time.Duration) {
+	probeTimer := discovery.clock.NewTimer(probeInterval)
+	defer probeTimer.Stop()
+	prepareTicker := discovery.clock.NewTicker(prepareRequestInterval)
+	defer prepareTicker.Stop()
 	for {
 		select {
 		case <-sigc:
 			klog.Info("shutdown signal received, exiting...")
-			return nil
-		case <-time.After(probeInterval):
+			return
+		case <-probeTimer.C():
 			if err := discovery.discoverDevices(); err != nil {
 				klog.Errorf("failed to discover devices during probe interval. %v", err)
 			}
+			probeTimer.Reset(probeInterval)
+		case <-prepareTicker.C():
+			if err := discovery.processPrepareRequests(); err != nil {
+				klog.Errorf("failed to process DiskPrepareRequests. %v", err)
+			}
 		case _, ok := <-udevEvents:
 			if ok {
 				klog.Info("trigger probe from udev event")
 				if err := discovery.discoverDevices(); err != nil {
 					klog.Errorf("failed to discover devices triggered from udev event. %v", err)
 				}
+				if !probeTimer.Stop() {
+					select {
+					case <-probeTimer.C():
+					default:
+					}
+				}
+				probeTimer.Reset(probeInterval)
 			} else {
 				klog.Warningf("disabling udev monitoring")
 				udevEvents = nil
@@ -111,7 +168,7 @@ func (discovery *DeviceDiscovery) Start() error {
 // discoverDevices identifies the list of usable disks on the current node
 func (discovery *DeviceDiscovery) discoverDevices() error {
 	// List all the valid block devices on the node
-	validDevices, err := getValidBlockDevices()
+	validDevices, ignoredDevices, err := getBlockDevices(discovery.host)
 	if err != nil {
 		message := "failed to discover devices"
 		e := diskmaker.NewEvent(diskmaker.ErrorListingBlockDevices, fmt.Sprintf("%s. Error: %+v", message, err), "")
@@ -121,13 +178,27 @@ func (discovery *DeviceDiscovery) discoverDevices() error {
 
 	klog.Infof("valid block devices: %+v", validDevices)
 
-	discoveredDisks := getDiscoverdDevices(validDevices)
+	discoveredDisks := getDiscoverdDevices(discovery.host, validDevices)
+	discovery.setMultipathTopology(discoveredDisks)
 	klog.Infof("discovered devices: %+v", discoveredDisks)
 
-	// Update discovered devices in the  LocalVolumeDiscoveryResult resource
-	if !reflect.DeepEqual(discovery.disks, discoveredDisks) {
+	now := metav1.Now()
+	devices, events := trackDeviceLifecycle(discovery.disks, discoveredDisks, now)
+	for _, e := range events {
+		klog.Info(e.Message)
+		discovery.eventSync.ReportChange(e, discovery.localVolumeDiscovery)
+	}
+
+	initiators := getInitiators(discovery.host)
+
+	// Update discovered devices in the LocalVolumeDiscoveryResult resource. Unchanged devices
+	// still get their lastSeen timestamp refreshed once in a while.
+	if devicesChanged(discovery.disks, devices) || !reflect.DeepEqual(discovery.ignoredDevices, ignoredDevices) ||
+		!reflect.DeepEqual(discovery.initiators, initiators) || now.Sub(discovery.lastUpdate) >= discovery.lastSeenRefreshInterval {
 		klog.Info("device list updated. Updating LocalVolumeDiscoveryResult status...")
-		discovery.disks = discoveredDisks
+		discovery.disks = devices
+		discovery.ignoredDevices = ignoredDevices
+		discovery.initiators = initiators
 		err = discovery.updateStatus()
 		if err != nil {
 			message := "failed to update LocalVolumeDiscoveryResult status"
@@ -135,6 +206,7 @@ func (discovery *DeviceDiscovery) discoverDevices() error {
 			discovery.eventSync.Report(e, discovery.localVolumeDiscovery)
 			return errors.Wrapf(err, message)
 		}
+		discovery.lastUpdate = now.Time
 		message := "successfully updated discovered device details in the LocalVolumeDiscoveryResult resource"
 		e := diskmaker.NewSuccessEvent(diskmaker.UpdatedDiscoveredDeviceList, message, "")
 		discovery.eventSync.Report(e, discovery.localVolumeDiscovery)
@@ -144,29 +216,43 @@ func (discovery *DeviceDiscovery) discoverDevices() error {
 }
 
 // getValidBlockDevices fetchs all the block devices sutitable for discovery
-func getValidBlockDevices() ([]diskutil.BlockDevice, error) {
-	blockDevices, output, err := diskutil.ListBlockDevices([]string{})
+func getValidBlockDevices(host diskutil.Host) ([]diskutil.BlockDevice, error) {
+	validDevices, _, err := getBlockDevices(host)
+	return validDevices, err
+}
+
+// getBlockDevices lists all the block devices of the node and splits them in the ones suitable
+// for discovery and the ignored ones
+func getBlockDevices(host diskutil.Host) ([]diskutil.BlockDevice, []v1alpha1.IgnoredDevice, error) {
+	blockDevices, output, err := diskutil.ListBlockDevices(host, []string{})
 	if err != nil {
-		return blockDevices, errors.Wrapf(err, "failed to list all the block devices in the node, stderr=%v", output)
+		return blockDevices, nil, errors.Wrapf(err, "failed to list all the block devices in the node, stderr=%v", output)
 	}
 
 	// Get valid list of devices
 	validDevices := make([]diskutil.BlockDevice, 0)
-	for _, blockDevice := range blockDevices {
-		if ignoreDevices(blockDevice) {
+	ignoredDevices := make([]v1alpha1.IgnoredDevice, 0)
+	for _, blockDevice := range append(blockDevices, getMultipathDevices(blockDevices)...) {
+		if filter, reason := getIgnoreReason(blockDevice); filter != "" {
+			klog.Infof("ignoring device %q: %s: %s", blockDevice.Name, filter, reason)
+			ignoredDevices = append(ignoredDevices, v1alpha1.IgnoredDevice{Name: blockDevice.Name, Filter: filter, Reason: reason})
 			continue
 		}
 		validDevices = append(validDevices, blockDevice)
 	}
 
-	return validDevices, nil
+	return validDevices, ignoredDevices, nil
 }
 
 // getDiscoverdDevices creates v1alpha1.DiscoveredDevice from diskutil.BlockDevices
-func getDiscoverdDevices(blockDevices []diskutil.BlockDevice) []v1alpha1.DiscoveredDevice {
+func getDiscoverdDevices(host diskutil.Host, blockDevices []diskutil.BlockDevice) []v1alpha1.DiscoveredDevice {
 	discoveredDevices := make([]v1alpha1.DiscoveredDevice, 0)
+	localStorageLinks, err := diskutil.GetLocalStorageLinks(host)
+	if err != nil {
+		klog.Warningf("failed to look for Local Storage Operator devices. Error %v", err)
+	}
 	for _, blockDevice := range blockDevices {
-		deviceID, err := blockDevice.GetPathByID("" /*existing symlink path*/)
+		deviceID, err := blockDevice.GetPathByID(host, "" /*existing symlink path*/)
 		if err != nil {
 			klog.Warningf("failed to get persistent ID for the device %q. Error %v", blockDevice.Name, err)
 			deviceID = ""
@@ -176,6 +262,10 @@ func getDiscoverdDevices(blockDevices []diskutil.BlockDevice) []v1alpha1.Discove
 		if err != nil {
 			klog.Warningf("failed to parse path for the device %q. Error %v", blockDevice.KName, err)
 		}
+		slot, err := blockDevice.GetPathBySlot(host)
+		if err != nil {
+			klog.Warningf("failed to get the slot of the device %q. Error %v", blockDevice.Name, err)
+		}
 		discoveredDevice := v1alpha1.DiscoveredDevice{
 			Path:     path,
 			Model:    blockDevice.Model,
@@ -186,9 +276,12 @@ func getDiscoverdDevices(blockDevices []diskutil.BlockDevice) []v1alpha1.Discove
 			DeviceID: deviceID,
 			Size:     blockDevice.Size,
 			Property: parseDeviceProperty(blockDevice.Rotational),
-			Status:   getDeviceStatus(blockDevice),
+			Status:   getDeviceStatus(host, blockDevice),
 			WWN:      blockDevice.WWN,
+			Slot:     slot,
 		}
+		setDeviceOwner(host, blockDevice, &discoveredDevice)
+		setLocalStorageOwner(localStorageLinks, blockDevice, &discoveredDevice)
 		discoveredDevices = append(discoveredDevices, discoveredDevice)
 	}
 
@@ -216,80 +309,114 @@ func uniqueDevices(sample []v1alpha1.DiscoveredDevice) []v1alpha1.DiscoveredDevi
 
 // ignoreDevices checks if a device should be ignored during discovery
 func ignoreDevices(dev diskutil.BlockDevice) bool {
+	filter, _ := getIgnoreReason(dev)
+	return filter != ""
+}
+
+// getIgnoreReason returns the filter that ignores a device during discovery and why, or empty
+// strings when the device is suitable for discovery
+func getIgnoreReason(dev diskutil.BlockDevice) (string, string) {
 	if dev.ReadOnly {
-		klog.Infof("ignoring read only device %q", dev.Name)
-		return true
+		return notReadOnly, "read only device"
 	}
 
 	if dev.State == diskutil.StateSuspended {
-		klog.Infof("ignoring device %q with invalid state %q", dev.Name, dev.State)
-		return true
+		return notSuspended, fmt.Sprintf("invalid state %q", dev.State)
 	}
 
 	if !supportedDeviceTypes.Has(dev.Type) {
-		klog.Infof("ignoring device %q with unsupported type %q", dev.Name, dev.Type)
-		return true
+		return supportedType, fmt.Sprintf("unsupported type %q", dev.Type)
 	}
 
 	if dev.Removable {
-		klog.Infof("ignoring device %s with removable capability", dev.Name)
-		return true
+		return notRemovable, "removable device"
 	}
 
 	if strings.Trim(dev.WWN, " ") == "" {
-		klog.Infof("ignoring device %q with undefined WWN", dev.Name)
-		return true
+		return hasWWN, "undefined WWN"
 	}
 
-	return false
+	return "", ""
 }
 
-// getDeviceStatus returns device status as "Available", "NotAvailable" or "Unknown"
-func getDeviceStatus(dev diskutil.BlockDevice) v1alpha1.DeviceStatus {
+// getDeviceStatus returns device status as "Available", "NotAvailable" or "Unknown" along with
+// the filters that made the device not available or unknown
+func getDeviceStatus(host diskutil.Host, dev diskutil.BlockDevice) v1alpha1.DeviceStatus {
 	status := v1alpha1.DeviceStatus{}
-	if dev.FSType != "" {
-		klog.Infof("device %q with filesystem %q is not available", dev.Name, dev.FSType)
-		status.State = v1alpha1.NotAvailable
-		return status
+	notAvailable, unknown := false, false
+
+	checks := []struct {
+		filter string
+		detail string
+	}{
+		{filter: noFilesystemSignature, detail: fmt.Sprintf("filesystem %q found", dev.FSType)},
+		{filter: noBiosBootInPartLabel, detail: fmt.Sprintf("part label %q", dev.PartLabel)},
+		{filter: canOpenExclusively, detail: "device is in use"},
+	}
+	for _, check := range checks {
+		match, err := filterMap[check.filter](host, dev)
+		if err != nil {
+			unknown = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: %v", check.filter, err))
+			continue
+		}
+		if !match {
+			notAvailable = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: %s", check.filter, check.detail))
+		}
 	}
 
-	noBiosBootInPartLabel, err := filterMap["noBiosBootInPartLabel"](dev)
+	hasBindMounts, mountPoint, err := dev.HasBindMounts(host)
 	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
+		unknown = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("%s: %v", noBindMounts, err))
+	} else if hasBindMounts {
+		notAvailable = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("%s: mounted at %q", noBindMounts, mountPoint))
 	}
-	if !noBiosBootInPartLabel {
-		klog.Infof("device %q with part label %q is not available", dev.Name, dev.PartLabel)
+
+	switch {
+	case notAvailable:
 		status.State = v1alpha1.NotAvailable
-		return status
+	case unknown:
+		status.State = v1alpha1.Unknown
+	default:
+		status.State = v1alpha1.Available
 	}
+	klog.Infof("device %q is %s %v", dev.Name, status.State, status.Reasons)
+	return status
+}
 
-	canOpen, err := filterMap["canOpenExclusively"](dev)
+// setDeviceOwner marks devices that already belong to Storage Scale as owned and not available
+func setDeviceOwner(host diskutil.Host, dev diskutil.BlockDevice, device *v1alpha1.DiscoveredDevice) {
+	owned, err := dev.IsStorageScaleOwned(host)
 	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
+		klog.Warningf("failed to look for an NSD descriptor on device %q: %v", dev.Name, err)
 	}
-	if !canOpen {
-		klog.Infof("device %q is not available as it can't be opened exclusively", dev.Name)
-		status.State = v1alpha1.NotAvailable
-		return status
+	if !owned {
+		return
 	}
 
-	hasBindMounts, mountPoint, err := dev.HasBindMounts()
-	if err != nil {
-		status.State = v1alpha1.Unknown
-		return status
-	}
+	device.OwnedBy = v1alpha1.StorageScaleOwner
+	device.Status.State = v1alpha1.NotAvailable
+	device.Status.Reasons = append(device.Status.Reasons, fmt.Sprintf("%s: Storage Scale NSD found", notOwned))
+	klog.Infof("device %q is a Storage Scale NSD", dev.Name)
+}
 
-	if hasBindMounts {
-		klog.Infof("device %q with mount point %q is not available", dev.Name, mountPoint)
-		status.State = v1alpha1.NotAvailable
-		return status
+// setLocalStorageOwner marks devices that the Local Storage Operator uses, directly or through a
+// partition or multipath device, as owned and not available
+func setLocalStorageOwner(links map[string]string, dev diskutil.BlockDevice, device *v1alpha1.DiscoveredDevice) {
+	for _, d := range append([]diskutil.BlockDevice{dev}, dev.Children...) {
+		link, ok := links[d.KName]
+		if !ok {
+			continue
+		}
+		device.OwnedBy = v1alpha1.LocalStorageOwner
+		device.Status.State = v1alpha1.NotAvailable
+		device.Status.Reasons = append(device.Status.Reasons, fmt.Sprintf("%s: %s linked by the Local Storage Operator at %q", notOwned, d.KName, link))
+		klog.Infof("device %q is used by the Local Storage Operator through %q", dev.Name, link)
+		return
 	}
-
-	klog.Infof("device %q is available", dev.Name)
-	status.State = v1alpha1.Available
-	return status
 }
 
 func parseDeviceProperty(property bool) v1alpha1.DeviceMechanicalProperty {
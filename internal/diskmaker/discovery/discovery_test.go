@@ -4,14 +4,19 @@ package discovery
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
 )
 
 var lsblkOut string
@@ -189,11 +194,6 @@ func TestIgnoreDevices(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		diskutils.FilePathGlob = tc.fakeGlobfunc
-		defer func() {
-			diskutils.FilePathGlob = filepath.Glob
-		}()
-
 		actual := ignoreDevices(tc.blockDevice)
 		assert.Equalf(t, tc.expected, actual, "[%s]: %s", tc.label, tc.errMessage)
 	}
@@ -282,10 +282,11 @@ func TestValidBlockDevices(t *testing.T) {
 	for _, tc := range testcases {
 		lsblkOut = tc.fakeLsblkCmdOutput
 		blkidOut = tc.fakeblkidCmdOutput
-		m := &mockCmdExec{stdout: []string{blkidOut, lsblkOut}}
-		diskutils.ExecCommand = m
-		diskutils.FilePathGlob = tc.fakeGlobfunc
-		actual, err := getValidBlockDevices()
+		host := &diskutils.MockHost{
+			MockExecute: (&mockCmdExec{stdout: []string{blkidOut, lsblkOut}}).Execute,
+			MockGlob:    tc.fakeGlobfunc,
+		}
+		actual, err := getValidBlockDevices(host)
 		assert.NoError(t, err, "[%s]:", tc.label)
 		assert.Equalf(t, tc.expectedDiscoveredDeviceSize, len(actual), "[%s]: %s", tc.label, tc.errMessage)
 	}
@@ -329,8 +330,11 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62914560000),
 					Property: "Rotational",
 					FSType:   "ext4",
-					Status:   v1alpha1.DeviceStatus{State: "NotAvailable"},
-					WWN:      "aff-bcdd",
+					Status: v1alpha1.DeviceStatus{
+						State:   "NotAvailable",
+						Reasons: []string{"noFilesystemSignature: filesystem \"ext4\" found", "canOpenExclusively: open /dev/sdb: no such file or directory"},
+					},
+					WWN: "aff-bcdd",
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -370,7 +374,10 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62913494528),
 					Property: "NonRotational",
 					FSType:   "ext4",
-					Status:   v1alpha1.DeviceStatus{State: "NotAvailable"},
+					Status: v1alpha1.DeviceStatus{
+						State:   "NotAvailable",
+						Reasons: []string{"noFilesystemSignature: filesystem \"ext4\" found", "canOpenExclusively: open /dev/sda1: no such file or directory"},
+					},
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -410,7 +417,10 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62913494528),
 					Property: "NonRotational",
 					FSType:   "",
-					Status:   v1alpha1.DeviceStatus{State: "NotAvailable"},
+					Status: v1alpha1.DeviceStatus{
+						State:   "NotAvailable",
+						Reasons: []string{"noBiosBootInPartLabel: part label \"BIOS-BOOT\"", "canOpenExclusively: open /dev/sda1: no such file or directory"},
+					},
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -450,7 +460,10 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62913494528),
 					Property: "NonRotational",
 					FSType:   "vfat",
-					Status:   v1alpha1.DeviceStatus{State: "NotAvailable"},
+					Status: v1alpha1.DeviceStatus{
+						State:   "NotAvailable",
+						Reasons: []string{"noFilesystemSignature: filesystem \"vfat\" found", "canOpenExclusively: open /dev/sda1: no such file or directory"},
+					},
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -495,8 +508,11 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62913494528),
 					Property: "NonRotational",
 					FSType:   "",
-					Status:   v1alpha1.DeviceStatus{State: v1alpha1.Unknown},
-					WWN:      "mpathID",
+					Status: v1alpha1.DeviceStatus{
+						State:   v1alpha1.Unknown,
+						Reasons: []string{"canOpenExclusively: open /dev/dm-0: no such file or directory"},
+					},
+					WWN: "mpathID",
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -555,8 +571,11 @@ func TestGetDiscoveredDevices(t *testing.T) {
 					Size:     int64(62913494528),
 					Property: "NonRotational",
 					FSType:   "",
-					Status:   v1alpha1.DeviceStatus{State: v1alpha1.Unknown},
-					WWN:      "a",
+					Status: v1alpha1.DeviceStatus{
+						State:   v1alpha1.Unknown,
+						Reasons: []string{"canOpenExclusively: open /dev/dm-0: no such file or directory"},
+					},
+					WWN: "a",
 				},
 			},
 			fakeGlobfunc: func(name string) ([]string, error) {
@@ -569,14 +588,23 @@ func TestGetDiscoveredDevices(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		diskutils.FilePathGlob = tc.fakeGlobfunc
-		diskutils.FilePathEvalSymLinks = tc.fakeEvalSymlinkfunc
-		defer func() {
-			diskutils.FilePathGlob = filepath.Glob
-			diskutils.FilePathEvalSymLinks = filepath.EvalSymlinks
-		}()
+		fakeGlobfunc := tc.fakeGlobfunc
+		host := &diskutils.MockHost{
+			MockGlob: func(pattern string) ([]string, error) {
+				// the devices only have by-id links, they have no slot and are not used by the Local Storage Operator
+				if !strings.HasPrefix(pattern, diskutils.DiskByIDDir) {
+					return []string{}, nil
+				}
+				return fakeGlobfunc(pattern)
+			},
+			MockEvalSymlinks: tc.fakeEvalSymlinkfunc,
+			// the devices of the test cases don't exist
+			MockCanOpenExclusively: func(path string) (bool, error) {
+				return false, fmt.Errorf("open %s: no such file or directory", path)
+			},
+		}
 
-		actual := getDiscoverdDevices(tc.blockDevices)
+		actual := getDiscoverdDevices(host, tc.blockDevices)
 
 		if !assert.Equalf(t, len(tc.expected), len(actual), "Expected discovered device count: %v, but got: %v ", len(tc.expected), len(actual)) {
 			t.Errorf("\nExpected:\n%#v\nGot:\n%#v", tc.expected, actual)
@@ -658,6 +686,8 @@ func TestParseDeviceProperty(t *testing.T) {
 
 func getFakeDeviceDiscovery() *DeviceDiscovery {
 	dd := &DeviceDiscovery{}
+	dd.host = &diskutils.MockHost{}
+	dd.clock = clock.RealClock{}
 	dd.apiClient = &diskmaker.MockAPIUpdater{}
 	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
 	dd.disks = []v1alpha1.DiscoveredDevice{}
@@ -671,6 +701,7 @@ func setEnv() {
 	os.Setenv("WATCH_NAMESPACE", "ns")
 	os.Setenv("DISCOVERY_OBJECT_UID", "uid")
 	os.Setenv("DISCOVERY_OBJECT_NAME", "auto-discover-devices")
+	os.Setenv("POD_NAME", "diskmaker-discovery-abcde")
 }
 
 func unsetEnv() {
@@ -678,4 +709,140 @@ func unsetEnv() {
 	os.Unsetenv("WATCH_NAMESPACE")
 	os.Unsetenv("DISCOVERY_OBJECT_UID")
 	os.Unsetenv("DISCOVERY_OBJECT_NAME")
+	os.Unsetenv("POD_NAME")
+}
+
+func TestSetDeviceOwner(t *testing.T) {
+	nsdHeader := make([]byte, 1024)
+	copy(nsdHeader[512:], "NSD desc")
+	host := &diskutils.MockHost{Snapshot: diskutils.Snapshot{
+		Headers: map[string][]byte{"/dev/sdd": nsdHeader},
+	}}
+
+	device := v1alpha1.DiscoveredDevice{Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}}
+	setDeviceOwner(host, diskutils.BlockDevice{Name: "sdd", KName: "sdd"}, &device)
+	assert.Equal(t, v1alpha1.StorageScaleOwner, device.OwnedBy)
+	assert.Equal(t, v1alpha1.NotAvailable, device.Status.State)
+	assert.Equal(t, []string{"notOwned: Storage Scale NSD found"}, device.Status.Reasons)
+
+	device = v1alpha1.DiscoveredDevice{Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}}
+	setDeviceOwner(host, diskutils.BlockDevice{
+		Name:     "sde",
+		KName:    "sde",
+		Children: []diskutils.BlockDevice{{Name: "sde1", KName: "sde1", PartType: diskutils.GPFSPartitionType}},
+	}, &device)
+	assert.Equal(t, v1alpha1.StorageScaleOwner, device.OwnedBy)
+	assert.Equal(t, v1alpha1.NotAvailable, device.Status.State)
+	assert.Equal(t, []string{"notOwned: Storage Scale NSD found"}, device.Status.Reasons)
+
+	device = v1alpha1.DiscoveredDevice{Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}}
+	setDeviceOwner(host, diskutils.BlockDevice{Name: "sdf", KName: "sdf"}, &device)
+	assert.Empty(t, device.OwnedBy)
+	assert.Equal(t, v1alpha1.Available, device.Status.State)
+}
+
+func TestGetDeviceStatus(t *testing.T) {
+	host := diskutils.NewReplayHost(&diskutils.Snapshot{
+		MountInfo:   "2298 2296 8:48 / /var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-1 rw,relatime shared:1 - xfs /dev/sdd rw\n",
+		BusyDevices: []string{"/dev/sdc"},
+	})
+	testcases := []struct {
+		label    string
+		device   diskutils.BlockDevice
+		expected v1alpha1.DeviceStatus
+	}{
+		{
+			label:    "Case 1: available device",
+			device:   diskutils.BlockDevice{Name: "sdb", KName: "sdb"},
+			expected: v1alpha1.DeviceStatus{State: v1alpha1.Available},
+		},
+		{
+			label:  "Case 2: device in use",
+			device: diskutils.BlockDevice{Name: "sdc", KName: "sdc"},
+			expected: v1alpha1.DeviceStatus{
+				State:   v1alpha1.NotAvailable,
+				Reasons: []string{"canOpenExclusively: device is in use"},
+			},
+		},
+		{
+			label:  "Case 3: mounted device with a filesystem",
+			device: diskutils.BlockDevice{Name: "sdd", KName: "sdd", FSType: "xfs"},
+			expected: v1alpha1.DeviceStatus{
+				State: v1alpha1.NotAvailable,
+				Reasons: []string{
+					`noFilesystemSignature: filesystem "xfs" found`,
+					`noBindMounts: mounted at "/var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-1"`,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.Equalf(t, tc.expected, getDeviceStatus(host, tc.device), "[%s]: invalid device status", tc.label)
+	}
+}
+
+func TestSetLocalStorageOwner(t *testing.T) {
+	links := map[string]string{
+		"sdb":  "/mnt/local-storage/local-sc/wwn-0x5000c500155a3457",
+		"dm-1": "/mnt/local-storage/local-sc/dm-name-mpathb",
+	}
+
+	device := v1alpha1.DiscoveredDevice{Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}}
+	setLocalStorageOwner(links, diskutils.BlockDevice{Name: "sda", KName: "sda"}, &device)
+	assert.Empty(t, device.OwnedBy)
+	assert.Equal(t, v1alpha1.Available, device.Status.State)
+
+	setLocalStorageOwner(links, diskutils.BlockDevice{Name: "sdb", KName: "sdb"}, &device)
+	assert.Equal(t, v1alpha1.LocalStorageOwner, device.OwnedBy)
+	assert.Equal(t, v1alpha1.NotAvailable, device.Status.State)
+	assert.Equal(t, []string{`notOwned: sdb linked by the Local Storage Operator at "/mnt/local-storage/local-sc/wwn-0x5000c500155a3457"`}, device.Status.Reasons)
+
+	device = v1alpha1.DiscoveredDevice{Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}}
+	setLocalStorageOwner(links, diskutils.BlockDevice{
+		Name:     "sdc",
+		KName:    "sdc",
+		Children: []diskutils.BlockDevice{{Name: "mpathb", KName: "dm-1", Type: "mpath"}},
+	}, &device)
+	assert.Equal(t, v1alpha1.LocalStorageOwner, device.OwnedBy)
+	assert.Equal(t, v1alpha1.NotAvailable, device.Status.State)
+}
+
+func TestWatchProbeInterval(t *testing.T) {
+	setEnv()
+	defer unsetEnv()
+	var probes, lookups atomic.Int32
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	dd := getFakeDeviceDiscovery()
+	dd.clock = fakeClock
+	// each probe stops at its first command
+	dd.host = &diskutils.MockHost{MockExecute: func(name string, args ...string) diskutils.Command {
+		probes.Add(1)
+		return fakeCommand{err: fmt.Errorf("no devices")}
+	}}
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockListDiskPrepareRequests: func(namespace string) (*v1alpha1.DiskPrepareRequestList, error) {
+			lookups.Add(1)
+			return &v1alpha1.DiskPrepareRequestList{}, nil
+		},
+	}
+	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
+
+	sigc := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		dd.watch(sigc, nil, 2*time.Minute)
+		close(done)
+	}()
+
+	// the prepare lookups every 30s don't hold back the probe
+	for i := int32(1); i <= 8; i++ {
+		assert.Eventually(t, fakeClock.HasWaiters, time.Second, time.Millisecond)
+		fakeClock.Step(prepareRequestInterval)
+		assert.Eventually(t, func() bool { return lookups.Load() == i }, time.Second, time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return probes.Load() == 2 }, time.Second, time.Millisecond)
+
+	sigc <- syscall.SIGTERM
+	<-done
 }
@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+
+	"k8s.io/klog/v2"
+)
+
+// getInitiators reads the Fibre Channel ports and the iSCSI initiator name of the node.
+// It returns nil when the node has neither.
+func getInitiators(host diskutil.Host) *v1alpha1.NodeInitiators {
+	initiators := &v1alpha1.NodeInitiators{}
+
+	fcHosts, err := diskutil.GetFCHosts(host)
+	if err != nil {
+		klog.Warningf("failed to read the Fibre Channel host adapters: %v", err)
+	}
+	for _, fcHost := range fcHosts {
+		initiators.FibreChannel = append(initiators.FibreChannel, v1alpha1.FibreChannelPort{
+			Host:  fcHost.Name,
+			WWPN:  fcHost.PortName,
+			WWNN:  fcHost.NodeName,
+			State: fcHost.PortState,
+			Speed: fcHost.Speed,
+		})
+	}
+
+	initiators.ISCSIInitiatorName, err = diskutil.GetISCSIInitiatorName(host)
+	if err != nil {
+		klog.Warningf("failed to read the iSCSI initiator name: %v", err)
+	}
+
+	if len(initiators.FibreChannel) == 0 && initiators.ISCSIInitiatorName == "" {
+		return nil
+	}
+	return initiators
+}
@@ -0,0 +1,32 @@
+package discovery
+
+import (
+	"github.com/pkg/errors"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+)
+
+// Inventory is the outcome of a local discovery of the block devices of a node
+type Inventory struct {
+	Devices        []v1alpha1.DiscoveredDevice `json:"devices"`
+	IgnoredDevices []v1alpha1.IgnoredDevice    `json:"ignoredDevices"`
+	Initiators     *v1alpha1.NodeInitiators    `json:"initiators,omitempty"`
+}
+
+// GetInventory discovers the devices of the host the same way the discovery daemon does,
+// without talking to the API server
+func GetInventory(host diskutil.Host) (*Inventory, error) {
+	validDevices, ignoredDevices, err := getBlockDevices(host)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to discover devices")
+	}
+
+	devices := getDiscoverdDevices(host, validDevices)
+	if devices == nil {
+		devices = []v1alpha1.DiscoveredDevice{}
+	}
+	discovery := &DeviceDiscovery{host: host}
+	discovery.setMultipathTopology(devices)
+
+	return &Inventory{Devices: devices, IgnoredDevices: ignoredDevices, Initiators: getInitiators(host)}, nil
+}
@@ -0,0 +1,49 @@
+//nolint:lll
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+)
+
+func TestGetInventory(t *testing.T) {
+	lsblkOut := `{"blockdevices": [
+				{"name": "sda", "rota": true, "type": "disk", "size": 480103981056, "model": "MTFDDAK480TDS", "vendor": "ATA", "ro": true, "rm": false, "state": "running", "kname": "sda", "serial": "20442B9F5254", "partlabel": null, "wwn": "0x500a07512b9f5254"},
+				{"name": "sdb", "rota": false, "type": "disk", "size": 3840755982336, "model": "SSDSC2KB038TZR", "vendor": "ATA", "ro": false, "rm": false, "state": "running", "kname": "sdb", "serial": "PHYI313002K23P8EGN", "partlabel": null, "wwn": "0x55cd2e41563851e9"},
+				{"name": "loop0", "rota": false, "type": "loop", "size": 1073741824, "ro": false, "rm": false, "kname": "loop0", "wwn": null},
+				{"name": "sdc", "rota": false, "type": "disk", "size": 1073741824, "ro": false, "rm": false, "kname": "sdc", "wwn": null}]}`
+	host := diskutils.NewReplayHost(&diskutils.Snapshot{
+		Commands: map[string]string{
+			"blkid": "/dev/sdb: TYPE=\"xfs\"",
+			"lsblk": lsblkOut,
+		},
+		Symlinks: map[string]string{
+			"/dev/disk/by-id/wwn-0x55cd2e41563851e9": "/dev/sdb",
+			"/sys/class/fc_host/host7":               "/sys/devices/pci0000:00/0000:00:03.0/0000:08:00.0/host7/fc_host/host7",
+		},
+		HostFiles: map[string][]byte{
+			"/sys/class/fc_host/host7/port_name":  []byte("0x10000090fa5b1c2d\n"),
+			"/sys/class/fc_host/host7/port_state": []byte("Online\n"),
+			diskutils.ISCSIInitiatorNameFile:      []byte("InitiatorName=iqn.1994-05.com.redhat:5e6f7a8b9c0d\n"),
+		},
+	})
+
+	inventory, err := GetInventory(host)
+	assert.NoError(t, err)
+	assert.Len(t, inventory.Devices, 1)
+	assert.Equal(t, "/dev/sdb", inventory.Devices[0].Path)
+	assert.Equal(t, "/dev/disk/by-id/wwn-0x55cd2e41563851e9", inventory.Devices[0].DeviceID)
+	assert.Equal(t, "xfs", inventory.Devices[0].FSType)
+	assert.Equal(t, []v1alpha1.IgnoredDevice{
+		{Name: "sda", Filter: notReadOnly, Reason: "read only device"},
+		{Name: "loop0", Filter: supportedType, Reason: `unsupported type "loop"`},
+		{Name: "sdc", Filter: hasWWN, Reason: "undefined WWN"},
+	}, inventory.IgnoredDevices)
+	assert.Equal(t, &v1alpha1.NodeInitiators{
+		FibreChannel:       []v1alpha1.FibreChannelPort{{Host: "host7", WWPN: "0x10000090fa5b1c2d", State: "Online"}},
+		ISCSIInitiatorName: "iqn.1994-05.com.redhat:5e6f7a8b9c0d",
+	}, inventory.Initiators)
+}
@@ -0,0 +1,177 @@
+package discovery
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// missingDeviceGracePeriod is how long a device that disappeared is kept as Missing before it is removed
+	missingDeviceGracePeriod = 30 * time.Minute
+	// lastSeenRefreshesPerGracePeriod is the number of times lastSeen is refreshed in the status of unchanged
+	// devices during the grace period, the status is not written on every probe
+	lastSeenRefreshesPerGracePeriod = 3
+	// lastSeenRefreshProbes bounds the refresh period in probe intervals, the operator reports the results
+	// that were not refreshed for 3 probe intervals
+	lastSeenRefreshProbes = 2
+)
+
+// getLastSeenRefreshInterval returns the minimum time between status updates that only refresh lastSeen
+func getLastSeenRefreshInterval(probeInterval time.Duration) time.Duration {
+	return min(missingDeviceGracePeriod/lastSeenRefreshesPerGracePeriod, lastSeenRefreshProbes*probeInterval)
+}
+
+type deviceKey struct{ deviceID, path string }
+
+func getDeviceKey(device v1alpha1.DiscoveredDevice) deviceKey {
+	return deviceKey{device.DeviceID, device.Path}
+}
+
+// trackDeviceLifecycle merges the freshly discovered devices with the previously known ones.
+// It keeps firstSeen and state transition times, refreshes lastSeen, keeps devices that
+// disappeared as Missing for a grace period, replaces the devices swapped in the same slot
+// and returns an event for every change.
+func trackDeviceLifecycle(previous, current []v1alpha1.DiscoveredDevice, now metav1.Time) ([]v1alpha1.DiscoveredDevice, []*diskmaker.DiskEvent) {
+	events := []*diskmaker.DiskEvent{}
+	known := map[deviceKey]v1alpha1.DiscoveredDevice{}
+	for _, device := range previous {
+		known[getDeviceKey(device)] = device
+	}
+
+	// devices that are not discovered anymore can have been swapped for a new one in the same slot
+	vacatedSlots := map[string]v1alpha1.DiscoveredDevice{}
+	currentKeys := map[deviceKey]bool{}
+	for _, device := range current {
+		currentKeys[getDeviceKey(device)] = true
+	}
+	for _, device := range previous {
+		if device.Slot != "" && !currentKeys[getDeviceKey(device)] {
+			vacatedSlots[device.Slot] = device
+		}
+	}
+
+	devices := make([]v1alpha1.DiscoveredDevice, 0, len(current))
+	seen := map[deviceKey]bool{}
+	for _, device := range current {
+		key := getDeviceKey(device)
+		seen[key] = true
+		device.LastSeen = now.DeepCopy()
+
+		old, ok := known[key]
+		if !ok || isReplacement(old, device) {
+			if !ok {
+				old, ok = vacatedSlots[device.Slot]
+			}
+			device.FirstSeen = now.DeepCopy()
+			device.Status.LastTransitionTime = now.DeepCopy()
+			if ok && isReplacement(old, device) {
+				// the replaced device is dropped instead of being reported as missing
+				seen[getDeviceKey(old)] = true
+				device.Replaced = &v1alpha1.ReplacedDevice{
+					DeviceID:   old.DeviceID,
+					WWN:        old.WWN,
+					Serial:     old.Serial,
+					OwnedBy:    old.OwnedBy,
+					ReplacedAt: now,
+				}
+				message := fmt.Sprintf("device in slot %q was replaced: WWN %s -> %s, serial %s -> %s", device.Slot, old.WWN, device.WWN, old.Serial, device.Serial)
+				if old.OwnedBy != "" {
+					message = fmt.Sprintf("%s, the replaced device was owned by %s", message, old.OwnedBy)
+				}
+				events = append(events, diskmaker.NewEvent(diskmaker.DeviceReplaced, message, device.Path))
+				devices = append(devices, device)
+				continue
+			}
+			message := fmt.Sprintf("device %q (WWN %s, %d bytes) was added with state %s", device.Path, device.WWN, device.Size, device.Status.State)
+			events = append(events, diskmaker.NewSuccessEvent(diskmaker.DeviceAdded, message, device.Path))
+			devices = append(devices, device)
+			continue
+		}
+
+		device.Replaced = old.Replaced
+		device.FirstSeen = old.FirstSeen
+		if device.FirstSeen == nil {
+			device.FirstSeen = now.DeepCopy()
+		}
+		device.Status.LastTransitionTime = old.Status.LastTransitionTime
+		if old.Status.State != device.Status.State {
+			device.Status.LastTransitionTime = now.DeepCopy()
+			message := fmt.Sprintf("device %q (WWN %s) changed state from %s to %s", device.Path, device.WWN, old.Status.State, device.Status.State)
+			events = append(events, diskmaker.NewSuccessEvent(diskmaker.DeviceStateChanged, message, device.Path))
+		}
+		if old.Size != device.Size {
+			message := fmt.Sprintf("device %q (WWN %s) was resized from %d to %d bytes", device.Path, device.WWN, old.Size, device.Size)
+			events = append(events, diskmaker.NewSuccessEvent(diskmaker.DeviceResized, message, device.Path))
+		}
+		devices = append(devices, device)
+	}
+
+	for _, device := range previous {
+		if seen[getDeviceKey(device)] {
+			continue
+		}
+		if device.Status.State != v1alpha1.Missing {
+			message := fmt.Sprintf("device %q (WWN %s) is missing, it was last seen at %s", device.Path, device.WWN, formatTime(device.LastSeen))
+			events = append(events, diskmaker.NewEvent(diskmaker.DeviceMissing, message, device.Path))
+			device.Status.State = v1alpha1.Missing
+			device.Status.LastTransitionTime = now.DeepCopy()
+			device.Status.Reasons = nil
+			devices = append(devices, device)
+			continue
+		}
+		// the devices stored before lastSeen was tracked count from the time they were reported missing
+		missingSince := device.LastSeen
+		if missingSince == nil {
+			missingSince = device.Status.LastTransitionTime
+		}
+		if missingSince == nil {
+			device.Status.LastTransitionTime = now.DeepCopy()
+			missingSince = device.Status.LastTransitionTime
+		}
+		if now.Sub(missingSince.Time) > missingDeviceGracePeriod {
+			message := fmt.Sprintf("device %q (WWN %s) was removed after being missing for more than %s", device.Path, device.WWN, missingDeviceGracePeriod)
+			events = append(events, diskmaker.NewSuccessEvent(diskmaker.DeviceRemoved, message, device.Path))
+			continue
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, events
+}
+
+// isReplacement tells if current is a different physical device plugged in the slot of previous
+func isReplacement(previous, current v1alpha1.DiscoveredDevice) bool {
+	if previous.Slot == "" || previous.Slot != current.Slot {
+		return false
+	}
+	wwnChanged := previous.WWN != "" && current.WWN != "" && previous.WWN != current.WWN
+	serialChanged := previous.Serial != "" && current.Serial != "" && previous.Serial != current.Serial
+	return wwnChanged || serialChanged
+}
+
+// devicesChanged compares two device lists ignoring the lastSeen timestamps
+func devicesChanged(previous, current []v1alpha1.DiscoveredDevice) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for i := range previous {
+		a, b := previous[i], current[i]
+		a.LastSeen, b.LastSeen = nil, nil
+		if !reflect.DeepEqual(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatTime(t *metav1.Time) string {
+	if t == nil {
+		return "an unknown time"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
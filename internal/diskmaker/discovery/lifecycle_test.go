@@ -0,0 +1,196 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getEventReasons(events []*diskmaker.DiskEvent) []string {
+	reasons := []string{}
+	for _, e := range events {
+		reasons = append(reasons, e.EventReason)
+	}
+	return reasons
+}
+
+func TestTrackDeviceLifecycle(t *testing.T) {
+	start := metav1.NewTime(time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC))
+	sdb := v1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		Path:     "/dev/sdb",
+		Size:     62914560000,
+		WWN:      "0x5000c500a1b2c3d4",
+		Status:   v1alpha1.DeviceStatus{State: v1alpha1.Available},
+	}
+	sdc := v1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d5",
+		Path:     "/dev/sdc",
+		Size:     62914560000,
+		WWN:      "0x5000c500a1b2c3d5",
+		Status:   v1alpha1.DeviceStatus{State: v1alpha1.Available},
+	}
+
+	// first discovery adds every device
+	devices, events := trackDeviceLifecycle(nil, []v1alpha1.DiscoveredDevice{sdb, sdc}, start)
+	assert.Equal(t, []string{diskmaker.DeviceAdded, diskmaker.DeviceAdded}, getEventReasons(events))
+	assert.Equal(t, start, *devices[0].FirstSeen)
+	assert.Equal(t, start, *devices[0].LastSeen)
+	assert.Equal(t, start, *devices[0].Status.LastTransitionTime)
+
+	// nothing changed, only lastSeen moves
+	later := metav1.NewTime(start.Add(5 * time.Minute))
+	unchanged, events := trackDeviceLifecycle(devices, []v1alpha1.DiscoveredDevice{sdb, sdc}, later)
+	assert.Empty(t, events)
+	assert.Equal(t, start, *unchanged[0].FirstSeen)
+	assert.Equal(t, later, *unchanged[0].LastSeen)
+	assert.False(t, devicesChanged(devices, unchanged))
+
+	// sdb is resized and becomes unavailable, sdc disappears
+	resized := sdb
+	resized.Size = 107374182400
+	resized.Status.State = v1alpha1.NotAvailable
+	later = metav1.NewTime(start.Add(10 * time.Minute))
+	changed, events := trackDeviceLifecycle(unchanged, []v1alpha1.DiscoveredDevice{resized}, later)
+	assert.Equal(t, []string{diskmaker.DeviceStateChanged, diskmaker.DeviceResized, diskmaker.DeviceMissing}, getEventReasons(events))
+	assert.True(t, devicesChanged(unchanged, changed))
+	assert.Len(t, changed, 2)
+	assert.Equal(t, start, *changed[0].FirstSeen)
+	assert.Equal(t, later, *changed[0].Status.LastTransitionTime)
+	assert.Equal(t, v1alpha1.Missing, changed[1].Status.State)
+	assert.Equal(t, start.Add(5*time.Minute), changed[1].LastSeen.Time)
+
+	// sdc is kept as missing during the grace period
+	later = metav1.NewTime(start.Add(20 * time.Minute))
+	missing, events := trackDeviceLifecycle(changed, []v1alpha1.DiscoveredDevice{resized}, later)
+	assert.Empty(t, events)
+	assert.Len(t, missing, 2)
+
+	// sdc comes back
+	returned, events := trackDeviceLifecycle(missing, []v1alpha1.DiscoveredDevice{resized, sdc}, later)
+	assert.Equal(t, []string{diskmaker.DeviceStateChanged}, getEventReasons(events))
+	assert.Equal(t, v1alpha1.Available, returned[1].Status.State)
+	assert.Equal(t, start, *returned[1].FirstSeen)
+
+	// sdc is removed after the grace period
+	later = metav1.NewTime(start.Add(5*time.Minute + missingDeviceGracePeriod + time.Second))
+	removed, events := trackDeviceLifecycle(missing, []v1alpha1.DiscoveredDevice{resized}, later)
+	assert.Equal(t, []string{diskmaker.DeviceRemoved}, getEventReasons(events))
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "/dev/sdb", removed[0].Path)
+
+	// a missing device without lastSeen is removed after the grace period since it was reported missing
+	legacy := missing[1]
+	legacy.LastSeen = nil
+	kept, events := trackDeviceLifecycle([]v1alpha1.DiscoveredDevice{legacy}, nil, later)
+	assert.Empty(t, events)
+	assert.Len(t, kept, 1)
+	later = metav1.NewTime(start.Add(10*time.Minute + missingDeviceGracePeriod + time.Second))
+	removed, events = trackDeviceLifecycle([]v1alpha1.DiscoveredDevice{legacy}, nil, later)
+	assert.Equal(t, []string{diskmaker.DeviceRemoved}, getEventReasons(events))
+	assert.Empty(t, removed)
+
+	// and without any timestamp, from the first discovery that finds it missing
+	legacy.Status.LastTransitionTime = nil
+	kept, _ = trackDeviceLifecycle([]v1alpha1.DiscoveredDevice{legacy}, nil, later)
+	assert.Len(t, kept, 1)
+	assert.Equal(t, later, *kept[0].Status.LastTransitionTime)
+	later = metav1.NewTime(later.Add(missingDeviceGracePeriod + time.Second))
+	removed, _ = trackDeviceLifecycle(kept, nil, later)
+	assert.Empty(t, removed)
+}
+
+func TestTrackDeviceReplacement(t *testing.T) {
+	start := metav1.NewTime(time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC))
+	slot := "/dev/disk/by-path/pci-0000:3b:00.0-sas-phy4-lun-0"
+	failed := v1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		Path:     "/dev/sdb",
+		Serial:   "ZA1B2C3D",
+		WWN:      "0x5000c500a1b2c3d4",
+		Slot:     slot,
+		OwnedBy:  v1alpha1.StorageScaleOwner,
+		Status:   v1alpha1.DeviceStatus{State: v1alpha1.NotAvailable},
+	}
+	devices, _ := trackDeviceLifecycle(nil, []v1alpha1.DiscoveredDevice{failed}, start)
+
+	// the new disk gets the kernel name of the failed one
+	swapped := v1alpha1.DiscoveredDevice{
+		DeviceID: "/dev/disk/by-id/wwn-0x5000c500e5f6a7b8",
+		Path:     "/dev/sdb",
+		Serial:   "ZE5F6A7B",
+		WWN:      "0x5000c500e5f6a7b8",
+		Slot:     slot,
+		Status:   v1alpha1.DeviceStatus{State: v1alpha1.Available},
+	}
+	later := metav1.NewTime(start.Add(5 * time.Minute))
+	replaced, events := trackDeviceLifecycle(devices, []v1alpha1.DiscoveredDevice{swapped}, later)
+	assert.Equal(t, []string{diskmaker.DeviceReplaced}, getEventReasons(events))
+	assert.Equal(t, `device in slot "/dev/disk/by-path/pci-0000:3b:00.0-sas-phy4-lun-0" was replaced: `+
+		`WWN 0x5000c500a1b2c3d4 -> 0x5000c500e5f6a7b8, serial ZA1B2C3D -> ZE5F6A7B, the replaced device was owned by storage-scale`, events[0].Message)
+	assert.Len(t, replaced, 1)
+	assert.Equal(t, later, *replaced[0].FirstSeen)
+	assert.Equal(t, &v1alpha1.ReplacedDevice{
+		DeviceID:   "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		WWN:        "0x5000c500a1b2c3d4",
+		Serial:     "ZA1B2C3D",
+		OwnedBy:    v1alpha1.StorageScaleOwner,
+		ReplacedAt: later,
+	}, replaced[0].Replaced)
+
+	// the replacement is remembered on the following discoveries
+	later = metav1.NewTime(start.Add(10 * time.Minute))
+	unchanged, events := trackDeviceLifecycle(replaced, []v1alpha1.DiscoveredDevice{swapped}, later)
+	assert.Empty(t, events)
+	assert.Equal(t, replaced[0].Replaced, unchanged[0].Replaced)
+
+	// a disk that was pulled and reported missing is replaced once a new disk is plugged in its slot
+	missing, events := trackDeviceLifecycle(devices, []v1alpha1.DiscoveredDevice{}, later)
+	assert.Equal(t, []string{diskmaker.DeviceMissing}, getEventReasons(events))
+	swapped.Path = "/dev/sdd"
+	replaced, events = trackDeviceLifecycle(missing, []v1alpha1.DiscoveredDevice{swapped}, later)
+	assert.Equal(t, []string{diskmaker.DeviceReplaced}, getEventReasons(events))
+	assert.Len(t, replaced, 1)
+	assert.Equal(t, "/dev/sdd", replaced[0].Path)
+
+	// the same by-id path with a new serial is a replacement too
+	reflashed := failed
+	reflashed.Serial = "ZE5F6A7B"
+	replaced, events = trackDeviceLifecycle(devices, []v1alpha1.DiscoveredDevice{reflashed}, later)
+	assert.Equal(t, []string{diskmaker.DeviceReplaced}, getEventReasons(events))
+	assert.Len(t, replaced, 1)
+
+	// a device without slot is added
+	noSlot := swapped
+	noSlot.Slot = ""
+	added, events := trackDeviceLifecycle(missing, []v1alpha1.DiscoveredDevice{noSlot}, later)
+	assert.Equal(t, []string{diskmaker.DeviceAdded}, getEventReasons(events))
+	assert.Len(t, added, 2)
+}
+
+func TestGetLastSeenRefreshInterval(t *testing.T) {
+	tests := []struct {
+		probeInterval time.Duration
+		expected      time.Duration
+	}{
+		{ // case 1: default probe interval
+			probeInterval: defaultProbeInterval,
+			expected:      10 * time.Minute,
+		},
+		{ // case 2: frequent probes refresh within 2 probe intervals, before the operator reports the result
+			probeInterval: 30 * time.Second,
+			expected:      time.Minute,
+		},
+		{ // case 3: rare probes refresh on every probe
+			probeInterval: time.Hour,
+			expected:      10 * time.Minute,
+		},
+	}
+	for i, test := range tests {
+		assert.Equalf(t, test.expected, getLastSeenRefreshInterval(test.probeInterval), "case %d", i+1)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"strings"
 
 	internal "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
-
-	"golang.org/x/sys/unix"
 )
 
 const (
@@ -17,64 +15,52 @@ const (
 	noBiosBootInPartLabel = "noBiosBootInPartLabel"
 	noFilesystemSignature = "noFilesystemSignature"
 	noBindMounts          = "noBindMounts"
-	// file access , can't mock test
-	noChildren = "noChildren"
-	// file access , can't mock test
-	canOpenExclusively = "canOpenExclusively"
+	noChildren            = "noChildren"
+	canOpenExclusively    = "canOpenExclusively"
+	// filter names that are not in filterMap:
+	supportedType = "supportedType"
+	hasWWN        = "hasWWN"
+	notOwned      = "notOwned"
 )
 
 // maps of function identifier (for logs) to filter function.
-// These are passed the localv1alpha1.DeviceInclusionSpec to make testing easier,
-// but they aren't expected to use it
+// These are passed the host the device is on, so file access goes through it
 // they verify that the device itself is good to use
-var filterMap = map[string]func(internal.BlockDevice) (bool, error){
-	notReadOnly: func(dev internal.BlockDevice) (bool, error) {
+var filterMap = map[string]func(internal.Host, internal.BlockDevice) (bool, error){
+	notReadOnly: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		return !dev.ReadOnly, nil
 	},
 
-	notRemovable: func(dev internal.BlockDevice) (bool, error) {
+	notRemovable: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		return !dev.Removable, nil
 	},
 
-	notSuspended: func(dev internal.BlockDevice) (bool, error) {
+	notSuspended: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		return dev.State != internal.StateSuspended, nil
 	},
 
-	noBiosBootInPartLabel: func(dev internal.BlockDevice) (bool, error) {
+	noBiosBootInPartLabel: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		biosBootInPartLabel := strings.Contains(strings.ToLower(dev.PartLabel), strings.ToLower("bios")) ||
 			strings.Contains(strings.ToLower(dev.PartLabel), strings.ToLower("boot"))
 		return !biosBootInPartLabel, nil
 	},
 
-	noFilesystemSignature: func(dev internal.BlockDevice) (bool, error) {
+	noFilesystemSignature: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		return dev.FSType == "", nil
 	},
-	noBindMounts: func(dev internal.BlockDevice) (bool, error) {
-		hasBindMounts, _, err := dev.HasBindMounts()
+	noBindMounts: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
+		hasBindMounts, _, err := dev.HasBindMounts(host)
 		return !hasBindMounts, err
 	},
 
-	noChildren: func(dev internal.BlockDevice) (bool, error) {
+	noChildren: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		return len(dev.Children) == 0, nil
 	},
-	canOpenExclusively: func(dev internal.BlockDevice) (bool, error) {
+	canOpenExclusively: func(host internal.Host, dev internal.BlockDevice) (bool, error) {
 		pathname, err := dev.GetDevPath()
 		if err != nil {
 			return false, fmt.Errorf("pathname: %q: %w", pathname, err)
 		}
-		fd, errno := unix.Open(pathname, unix.O_RDONLY|unix.O_EXCL, 0)
-		// If the device is in use, open will return an invalid fd.
-		// When this happens, it is expected that Close will fail and throw an error.
-		defer unix.Close(fd)
-		if errno == nil {
-			// device not in use
-			return true, nil
-		} else if errno == unix.EBUSY {
-			// device is in use
-			return false, nil
-		}
-		// error during call to Open
-		return false, fmt.Errorf("pathname: %q: %w", pathname, errno)
-
+		return host.CanOpenExclusively(pathname)
 	},
 }
@@ -183,8 +183,9 @@ func TestNoFilesystemSignature(t *testing.T) {
 // a known result for a particular filter that can be asserted
 type knownMatcherResult struct {
 	// should pass one of filterMap or matcherMap
-	matcherMap  map[string]func(internal.BlockDevice) (bool, error)
+	matcherMap  map[string]func(internal.Host, internal.BlockDevice) (bool, error)
 	matcher     string
+	host        internal.Host
 	dev         internal.BlockDevice
 	expectMatch bool
 	expectErr   bool
@@ -200,7 +201,11 @@ func (r *knownMatcherResult) assert(t *testing.T) {
 	t.Logf("matcher name: %s, dev: %+v", r.matcher, r.dev)
 	matcher, ok := r.matcherMap[r.matcher]
 	assert.True(t, ok, "expected to find matcher in map", r.matcher)
-	match, err := matcher(r.dev)
+	host := r.host
+	if host == nil {
+		host = &internal.MockHost{}
+	}
+	match, err := matcher(host, r.dev)
 	if r.expectErr {
 		assert.Error(t, err)
 	} else {
@@ -212,3 +217,53 @@ func (r *knownMatcherResult) assert(t *testing.T) {
 		assert.False(t, match)
 	}
 }
+
+func TestNoBindMounts(t *testing.T) {
+	matcherMap := filterMap
+	matcher := noBindMounts
+	host := internal.NewReplayHost(&internal.Snapshot{
+		MountInfo: "2298 2296 8:16 / /var/lib/kubelet/plugins/kubernetes.io/local-volume/mounts/local-pv-1 rw,relatime shared:1 - xfs /dev/sdb rw\n" +
+			"2371 2298 8:32 /sdc /var/lib/kubelet/pods/uid/volumeDevices/kubernetes.io~local-volume/local-pv-2 rw shared:2 - devtmpfs devtmpfs rw\n",
+	})
+	results := []knownMatcherResult{
+		// true
+		{
+			matcherMap: matcherMap, matcher: matcher, host: host,
+			dev:         internal.BlockDevice{KName: "sdd"},
+			expectMatch: true,
+		},
+		// false
+		{
+			matcherMap: matcherMap, matcher: matcher, host: host,
+			dev:         internal.BlockDevice{KName: "sdb"},
+			expectMatch: false,
+		},
+		{
+			matcherMap: matcherMap, matcher: matcher, host: host,
+			dev:         internal.BlockDevice{KName: "sdc"},
+			expectMatch: false,
+		},
+	}
+	assertAll(t, results)
+}
+
+func TestCanOpenExclusively(t *testing.T) {
+	matcherMap := filterMap
+	matcher := canOpenExclusively
+	host := internal.NewReplayHost(&internal.Snapshot{BusyDevices: []string{"/dev/sdb"}})
+	results := []knownMatcherResult{
+		// true
+		{
+			matcherMap: matcherMap, matcher: matcher, host: host,
+			dev:         internal.BlockDevice{KName: "sdc"},
+			expectMatch: true,
+		},
+		// false
+		{
+			matcherMap: matcherMap, matcher: matcher, host: host,
+			dev:         internal.BlockDevice{KName: "sdb"},
+			expectMatch: false,
+		},
+	}
+	assertAll(t, results)
+}
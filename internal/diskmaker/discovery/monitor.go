@@ -11,13 +11,13 @@ import (
 )
 
 var (
-	udevExclusionFilter = []string{"(?i)dm-[0-9]+", "(?i)rbd[0-9]", "(?i)nbd[0-9]+"}
-	udevEventMatch      = []string{"(?i)add", "(?i)remove"}
+	defaultUdevExclusionFilter = []string{"(?i)dm-[0-9]+", "(?i)rbd[0-9]", "(?i)nbd[0-9]+"}
+	udevEventMatch             = []string{"(?i)add", "(?i)remove"}
 )
 
 // Monitors udev for block device changes, and collapses these events such that
 // only one event is emitted per period in order to deal with flapping.
-func udevBlockMonitor(c chan string, period time.Duration) {
+func udevBlockMonitor(c chan string, period time.Duration, exclusions []string) {
 	defer close(c)
 
 	// return any add or remove events, but none that match device mapper
@@ -25,9 +25,9 @@ func udevBlockMonitor(c chan string, period time.Duration) {
 	events := make(chan string)
 
 	klog.Infof("regex for matching udev events - %q", udevEventMatch)
-	klog.Infof("regex for list of devices to be ignored for udev events - %q", udevExclusionFilter)
+	klog.Infof("regex for list of devices to be ignored for udev events - %q", exclusions)
 
-	go rawUdevBlockMonitor(events, udevEventMatch, udevExclusionFilter)
+	go rawUdevBlockMonitor(events, udevEventMatch, exclusions)
 
 	for {
 		event, ok := <-events
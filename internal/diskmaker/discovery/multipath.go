@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+const (
+	policyFailover    = "failover"
+	policyMultibus    = "multibus"
+	policyGroupByPrio = "group_by_prio"
+)
+
+// getMultipathDevices returns the multipath devices found among the children of the block devices.
+// lsblk lists a multipath device once under each of its paths and without a WWN, so the devices are
+// deduplicated on their kernel name and get the WWN of their paths.
+func getMultipathDevices(blockDevices []diskutil.BlockDevice) []diskutil.BlockDevice {
+	devices := []diskutil.BlockDevice{}
+	seen := sets.NewString()
+	for _, parent := range blockDevices {
+		for _, child := range parent.Children {
+			if child.Type != diskutil.MultipathType || seen.Has(child.KName) {
+				continue
+			}
+			seen.Insert(child.KName)
+			if child.WWN == "" {
+				child.WWN = parent.WWN
+			}
+			devices = append(devices, child)
+		}
+	}
+	return devices
+}
+
+// setMultipathTopology attaches the path topology to the discovered multipath devices
+// and reports a warning event for devices that have lost paths
+func (discovery *DeviceDiscovery) setMultipathTopology(devices []v1alpha1.DiscoveredDevice) {
+	hasMultipath := false
+	for _, device := range devices {
+		if device.Type == v1alpha1.MultiPathType {
+			hasMultipath = true
+			break
+		}
+	}
+	if !hasMultipath {
+		return
+	}
+
+	maps, err := diskutil.GetMultipathMaps(discovery.host)
+	if err != nil {
+		klog.Warningf("failed to get multipath topology: %v", err)
+		return
+	}
+
+	if discovery.expectedPaths == nil {
+		discovery.expectedPaths = map[string]int{}
+	}
+	for i := range devices {
+		if devices[i].Type != v1alpha1.MultiPathType {
+			continue
+		}
+		m, ok := maps[filepath.Base(devices[i].Path)]
+		if !ok {
+			klog.Warningf("no multipath map found for device %q", devices[i].Path)
+			continue
+		}
+		topology := getMultipathTopology(m)
+		if expected := discovery.expectedPaths[devices[i].WWN]; expected > topology.ExpectedPaths {
+			topology.ExpectedPaths = expected
+		}
+		discovery.expectedPaths[devices[i].WWN] = topology.ExpectedPaths
+		topology.Degraded = topology.HealthyPaths < topology.ExpectedPaths
+		if topology.Degraded {
+			message := fmt.Sprintf("multipath device %q (WWN %s) has %d of %d paths healthy", devices[i].Path, devices[i].WWN, topology.HealthyPaths, topology.ExpectedPaths)
+			klog.Warning(message)
+			if discovery.eventSync != nil {
+				e := diskmaker.NewEvent(diskmaker.MultipathDegraded, message, devices[i].Path)
+				discovery.eventSync.Report(e, discovery.localVolumeDiscovery)
+			}
+		}
+		devices[i].Multipath = topology
+	}
+}
+
+// getMultipathTopology converts a device-mapper multipath map to its API representation
+func getMultipathTopology(m diskutil.MultipathMap) *v1alpha1.MultipathTopology {
+	topology := &v1alpha1.MultipathTopology{
+		PathGroupPolicy: getPathGroupPolicy(m.PathGroups),
+	}
+	for _, group := range m.PathGroups {
+		if topology.PathSelector == "" {
+			topology.PathSelector = group.Selector
+		}
+		for _, path := range group.Paths {
+			topology.Paths = append(topology.Paths, v1alpha1.MultipathPath{
+				Name:        path.Name,
+				HCTL:        path.HCTL,
+				HostAdapter: path.HostAdapter(),
+				State:       v1alpha1.MultipathPathState(path.State),
+			})
+			if path.State != diskutil.PathStateFailed {
+				topology.HealthyPaths++
+			}
+		}
+	}
+	topology.ExpectedPaths = len(topology.Paths)
+
+	return topology
+}
+
+// getPathGroupPolicy infers the multipath path_grouping_policy from the layout of the path groups
+func getPathGroupPolicy(groups []diskutil.MultipathPathGroup) string {
+	switch {
+	case len(groups) == 0:
+		return ""
+	case len(groups) == 1:
+		return policyMultibus
+	}
+	for _, group := range groups {
+		if len(group.Paths) != 1 {
+			return policyGroupByPrio
+		}
+	}
+	return policyFailover
+}
@@ -0,0 +1,147 @@
+package discovery
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+)
+
+func TestGetPathGroupPolicy(t *testing.T) {
+	testcases := []struct {
+		label    string
+		groups   []diskutils.MultipathPathGroup
+		expected string
+	}{
+		{
+			label:    "case 1", // single group with all paths
+			groups:   []diskutils.MultipathPathGroup{{Paths: []diskutils.MultipathPath{{DevT: "8:32"}, {DevT: "8:48"}}}},
+			expected: policyMultibus,
+		},
+		{
+			label:    "case 2", // one path per group
+			groups:   []diskutils.MultipathPathGroup{{Paths: []diskutils.MultipathPath{{DevT: "8:32"}}}, {Paths: []diskutils.MultipathPath{{DevT: "8:48"}}}},
+			expected: policyFailover,
+		},
+		{
+			label: "case 3", // several paths per group
+			groups: []diskutils.MultipathPathGroup{
+				{Paths: []diskutils.MultipathPath{{DevT: "8:32"}, {DevT: "8:48"}}},
+				{Paths: []diskutils.MultipathPath{{DevT: "8:64"}, {DevT: "8:80"}}},
+			},
+			expected: policyGroupByPrio,
+		},
+		{
+			label:    "case 4", // no groups
+			expected: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.Equalf(t, tc.expected, getPathGroupPolicy(tc.groups), "[%s] invalid path group policy", tc.label)
+	}
+}
+
+func TestSetMultipathTopology(t *testing.T) {
+	table := "mpatha: 0 104857600 multipath 0 0 1 1 service-time 0 2 1 8:32 1 8:80 1"
+	healthy := "mpatha: 0 104857600 multipath 2 0 0 0 1 1 A 0 2 1 8:32 A 0 0 8:80 A 0 0"
+	oneFailed := "mpatha: 0 104857600 multipath 2 0 0 0 1 1 A 0 2 1 8:32 A 0 0 8:80 F 1 0"
+
+	host := &diskutils.MockHost{}
+	host.MockEvalSymlinks = func(path string) (string, error) {
+		switch path {
+		case filepath.Join(diskutils.DiskDMDir, "mpatha"):
+			return "/dev/dm-0", nil
+		case filepath.Join(diskutils.SysDevBlockDir, "8:32"):
+			return "/sys/block/sdc", nil
+		case filepath.Join(diskutils.SysDevBlockDir, "8:80"):
+			return "/sys/block/sdf", nil
+		}
+		return "", fmt.Errorf("unexpected path %q", path)
+	}
+
+	dd := getFakeDeviceDiscovery()
+	dd.host = host
+	devices := []v1alpha1.DiscoveredDevice{
+		{Path: "/dev/sda", Type: v1alpha1.DiskType, WWN: "0x5000c500a1b2c3d4"},
+		{Path: "/dev/dm-0", Type: v1alpha1.MultiPathType, WWN: "0x6005076810810261f800000000000a1b"},
+	}
+
+	host.MockExecute = (&mockCmdExec{stdout: []string{table, healthy}}).Execute
+	dd.setMultipathTopology(devices)
+	assert.Nil(t, devices[0].Multipath)
+	assert.NotNil(t, devices[1].Multipath)
+	assert.Equal(t, policyMultibus, devices[1].Multipath.PathGroupPolicy)
+	assert.Equal(t, "service-time", devices[1].Multipath.PathSelector)
+	assert.Equal(t, 2, devices[1].Multipath.HealthyPaths)
+	assert.Equal(t, 2, devices[1].Multipath.ExpectedPaths)
+	assert.False(t, devices[1].Multipath.Degraded)
+
+	// a failed path degrades the device
+	host.MockExecute = (&mockCmdExec{stdout: []string{table, oneFailed}}).Execute
+	dd.setMultipathTopology(devices)
+	assert.Equal(t, v1alpha1.PathFailed, devices[1].Multipath.Paths[1].State)
+	assert.Equal(t, 1, devices[1].Multipath.HealthyPaths)
+	assert.True(t, devices[1].Multipath.Degraded)
+
+	// a path that disappeared from the map still counts as expected
+	host.MockExecute = (&mockCmdExec{stdout: []string{
+		"mpatha: 0 104857600 multipath 0 0 1 1 service-time 0 1 1 8:32 1",
+		"mpatha: 0 104857600 multipath 2 0 0 0 1 1 A 0 1 1 8:32 A 0 0",
+	}}).Execute
+	dd.setMultipathTopology(devices)
+	assert.Equal(t, 1, devices[1].Multipath.HealthyPaths)
+	assert.Equal(t, 2, devices[1].Multipath.ExpectedPaths)
+	assert.True(t, devices[1].Multipath.Degraded)
+
+	// the paths lost while the daemon was restarting are still expected
+	restarted := getFakeDeviceDiscovery()
+	restarted.host = host
+	restarted.getKnownDevices(&v1alpha1.LocalVolumeDiscoveryResult{
+		Status: v1alpha1.LocalVolumeDiscoveryResultStatus{DiscoveredDevices: devices},
+	})
+	devices[1].Multipath = nil
+	host.MockExecute = (&mockCmdExec{stdout: []string{
+		"mpatha: 0 104857600 multipath 0 0 1 1 service-time 0 1 1 8:32 1",
+		"mpatha: 0 104857600 multipath 2 0 0 0 1 1 A 0 1 1 8:32 A 0 0",
+	}}).Execute
+	restarted.setMultipathTopology(devices)
+	assert.Equal(t, 1, devices[1].Multipath.HealthyPaths)
+	assert.Equal(t, 2, devices[1].Multipath.ExpectedPaths)
+	assert.True(t, devices[1].Multipath.Degraded)
+}
+
+func TestMultipathReplay(t *testing.T) {
+	// lsblk lists the multipath devices under each of their paths
+	host, err := diskutils.NewReplayHostFromPath(filepath.Join(snapshotsDir, "mpath-node"))
+	assert.NoError(t, err)
+
+	inventory, err := GetInventory(host)
+	assert.NoError(t, err)
+	multipathDevices := []v1alpha1.DiscoveredDevice{}
+	for _, device := range inventory.Devices {
+		if device.Type == v1alpha1.MultiPathType {
+			multipathDevices = append(multipathDevices, device)
+		}
+	}
+	assert.Len(t, multipathDevices, 3)
+
+	mpatha := multipathDevices[0]
+	assert.Equal(t, "/dev/dm-0", mpatha.Path)
+	assert.Equal(t, "0x60014053a37f792d97e41faab6e498e6", mpatha.WWN)
+	assert.NotNil(t, mpatha.Multipath)
+	assert.Equal(t, []v1alpha1.MultipathPath{
+		{Name: "sdc", HCTL: "7:0:0:0", HostAdapter: "host7", State: v1alpha1.MultipathPathState(diskutils.PathStateActive)},
+		{Name: "sdf", HCTL: "8:0:0:0", HostAdapter: "host8", State: v1alpha1.MultipathPathState(diskutils.PathStateActive)},
+	}, mpatha.Multipath.Paths)
+	assert.False(t, mpatha.Multipath.Degraded)
+
+	// a path of mpathb has failed
+	mpathb := multipathDevices[1]
+	assert.Equal(t, 1, mpathb.Multipath.HealthyPaths)
+	assert.Equal(t, 2, mpathb.Multipath.ExpectedPaths)
+	assert.True(t, mpathb.Multipath.Degraded)
+}
@@ -0,0 +1,288 @@
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// prepareRequestInterval is how often the DiskPrepareRequests of the node are looked up
+	prepareRequestInterval = 30 * time.Second
+	// prepareClaimTimeout is the time after which a request still in progress is considered interrupted
+	prepareClaimTimeout = 10 * time.Minute
+)
+
+// prepareOutcome is the result of handling a single DiskPrepareRequest
+type prepareOutcome struct {
+	phase           v1alpha1.DiskPreparePhase
+	message         string
+	devicePath      string
+	wipedSignatures []string
+}
+
+// processPrepareRequests wipes the devices of the pending DiskPrepareRequests of this node and runs
+// the discovery again when a device has been wiped, so that it shows up as available. Several diskmakers
+// can run on the node, a request is claimed before its device is wiped so that only one of them wipes it.
+func (discovery *DeviceDiscovery) processPrepareRequests() error {
+	nodeName := os.Getenv("MY_NODE_NAME")
+	podName := os.Getenv("POD_NAME")
+	requests, err := discovery.apiClient.ListDiskPrepareRequests(discovery.localVolumeDiscovery.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list DiskPrepareRequests: %w", err)
+	}
+
+	pending := []v1alpha1.DiskPrepareRequest{}
+	for i := range requests.Items {
+		request := &requests.Items[i]
+		if request.Spec.NodeName != nodeName {
+			continue
+		}
+		switch request.Status.Phase {
+		case v1alpha1.DiskPreparePending:
+			pending = append(pending, *request)
+		case v1alpha1.DiskPrepareInProgress:
+			discovery.failInterruptedPrepareRequest(request, podName)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	localDiskDevices, err := discovery.apiClient.ListLocalDiskDevices(nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to list the Storage Scale LocalDisks: %w", err)
+	}
+	validDevices, _, err := getBlockDevices(discovery.host)
+	if err != nil {
+		return fmt.Errorf("failed to list the block devices: %w", err)
+	}
+
+	localDisks := resolveLocalDiskDevices(discovery.host, localDiskDevices)
+	wiped := false
+	for i := range pending {
+		request := &pending[i]
+		claim := &v1alpha1.DiskPrepareClaim{NodeName: nodeName, PodName: podName, ClaimTime: metav1.Now()}
+		if !discovery.claimPrepareRequest(request, claim) {
+			continue
+		}
+		outcome := prepareDevice(discovery.host, validDevices, localDisks, request)
+		klog.Infof("DiskPrepareRequest %q for WWN %q: %s %s", request.Name, request.Spec.WWN, outcome.phase, outcome.message)
+
+		request.Status = v1alpha1.DiskPrepareRequestStatus{
+			Phase:           outcome.phase,
+			Message:         outcome.message,
+			ClaimedBy:       claim,
+			DevicePath:      outcome.devicePath,
+			WipedSignatures: outcome.wipedSignatures,
+			CompletionTime:  &metav1.Time{Time: time.Now()},
+		}
+		if err := discovery.apiClient.UpdateDiskPrepareRequestStatus(request); err != nil {
+			klog.Errorf("failed to update the status of DiskPrepareRequest %q: %v", request.Name, err)
+		}
+
+		switch outcome.phase {
+		case v1alpha1.DiskPrepareSucceeded:
+			wiped = true
+			e := diskmaker.NewSuccessEvent(diskmaker.DiskPrepared, outcome.message, outcome.devicePath)
+			discovery.eventSync.ReportChange(e, request)
+		case v1alpha1.DiskPrepareRejected:
+			e := diskmaker.NewEvent(diskmaker.DiskPrepareRejected, outcome.message, outcome.devicePath)
+			discovery.eventSync.ReportChange(e, request)
+		default:
+			e := diskmaker.NewEvent(diskmaker.ErrorPreparingDisk, outcome.message, outcome.devicePath)
+			discovery.eventSync.ReportChange(e, request)
+		}
+	}
+
+	if wiped {
+		return discovery.discoverDevices()
+	}
+	return nil
+}
+
+// claimPrepareRequest marks the request as in progress by the diskmaker. The status update carries the
+// resourceVersion the request was listed with, so it fails when another diskmaker claimed it first.
+func (discovery *DeviceDiscovery) claimPrepareRequest(request *v1alpha1.DiskPrepareRequest, claim *v1alpha1.DiskPrepareClaim) bool {
+	request.Status = v1alpha1.DiskPrepareRequestStatus{
+		Phase:     v1alpha1.DiskPrepareInProgress,
+		Message:   fmt.Sprintf("claimed by pod %s", claim.PodName),
+		ClaimedBy: claim,
+	}
+	err := discovery.apiClient.UpdateDiskPrepareRequestStatus(request)
+	if kerrors.IsConflict(err) {
+		klog.Infof("DiskPrepareRequest %q was claimed by another diskmaker", request.Name)
+		return false
+	}
+	if err != nil {
+		klog.Errorf("failed to claim DiskPrepareRequest %q: %v", request.Name, err)
+		return false
+	}
+	return true
+}
+
+// failInterruptedPrepareRequest fails the request in progress when the diskmaker that claimed it stopped before
+// recording the outcome: the claim is from a previous run of this pod, or it timed out. The device is not wiped
+// again, a new request must be created once it has been checked.
+func (discovery *DeviceDiscovery) failInterruptedPrepareRequest(request *v1alpha1.DiskPrepareRequest, podName string) {
+	claim := request.Status.ClaimedBy
+	if claim != nil && claim.PodName != podName && time.Since(claim.ClaimTime.Time) < prepareClaimTimeout {
+		return
+	}
+	claimedBy := "an unknown diskmaker"
+	if claim != nil {
+		claimedBy = fmt.Sprintf("pod %s", claim.PodName)
+	}
+	request.Status.Phase = v1alpha1.DiskPrepareFailed
+	request.Status.Message = fmt.Sprintf("the wipe by %s was interrupted, check the device and create a new request", claimedBy)
+	request.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	if err := discovery.apiClient.UpdateDiskPrepareRequestStatus(request); err != nil {
+		if !kerrors.IsConflict(err) {
+			klog.Errorf("failed to update the status of DiskPrepareRequest %q: %v", request.Name, err)
+		}
+		return
+	}
+	klog.Warningf("DiskPrepareRequest %q: %s", request.Name, request.Status.Message)
+}
+
+// prepareDevice checks that the device of the request is safe to wipe and wipes it
+func prepareDevice(host diskutil.Host, devices []diskutil.BlockDevice, localDisks sets.Set[string],
+	request *v1alpha1.DiskPrepareRequest) prepareOutcome {
+	if !request.Spec.ConfirmWipe {
+		return prepareOutcome{phase: v1alpha1.DiskPrepareRejected, message: "confirmWipe is not set"}
+	}
+
+	targets := findPrepareTargets(devices, request.Spec.WWN)
+	if len(targets) == 0 {
+		return prepareOutcome{phase: v1alpha1.DiskPrepareFailed, message: fmt.Sprintf("no device with WWN %q found", request.Spec.WWN)}
+	}
+	if len(targets) > 1 {
+		knames := []string{}
+		for _, target := range targets {
+			knames = append(knames, target.KName)
+		}
+		return prepareOutcome{
+			phase:   v1alpha1.DiskPrepareRejected,
+			message: fmt.Sprintf("WWN %q matches several devices: %s", request.Spec.WWN, strings.Join(knames, ", ")),
+		}
+	}
+
+	target := targets[0]
+	path, err := target.GetDevPath()
+	if err != nil {
+		return prepareOutcome{phase: v1alpha1.DiskPrepareFailed, message: err.Error()}
+	}
+	outcome := prepareOutcome{devicePath: path}
+	reason, err := getWipeRejection(host, target, localDisks)
+	if err != nil {
+		outcome.phase = v1alpha1.DiskPrepareFailed
+		outcome.message = err.Error()
+		return outcome
+	}
+	if reason != "" {
+		outcome.phase = v1alpha1.DiskPrepareRejected
+		outcome.message = reason
+		return outcome
+	}
+
+	outcome.wipedSignatures, err = target.WipeDevice(host)
+	if err != nil {
+		outcome.phase = v1alpha1.DiskPrepareFailed
+		outcome.message = fmt.Sprintf("failed to wipe %s: %v", path, err)
+		return outcome
+	}
+	outcome.phase = v1alpha1.DiskPrepareSucceeded
+	outcome.message = fmt.Sprintf("wiped %s", path)
+	return outcome
+}
+
+// findPrepareTargets returns the devices with the WWN. The paths of a multipath device share its WWN,
+// so the multipath device is returned instead of its paths.
+func findPrepareTargets(devices []diskutil.BlockDevice, wwn string) []diskutil.BlockDevice {
+	targets := []diskutil.BlockDevice{}
+	seen := sets.New[string]()
+	for _, dev := range devices {
+		if wwn == "" || dev.WWN != wwn {
+			continue
+		}
+		target := dev
+		for _, child := range dev.Children {
+			if child.Type == diskutil.MultipathType {
+				target = child
+				break
+			}
+		}
+		if !seen.Has(target.KName) {
+			seen.Insert(target.KName)
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// resolveLocalDiskDevices returns the device paths of the LocalDisks of the node along with the devices
+// they link to, like /dev/disk/by-id/wwn-... to /dev/sdb
+func resolveLocalDiskDevices(host diskutil.Host, paths []string) sets.Set[string] {
+	devices := sets.New[string]()
+	for _, path := range paths {
+		devices.Insert(path)
+		resolved, err := host.EvalSymlinks(path)
+		if err != nil {
+			klog.Warningf("failed to resolve the device %q of a LocalDisk: %v", path, err)
+			continue
+		}
+		devices.Insert(resolved)
+	}
+	return devices
+}
+
+// getWipeRejection returns why the device must not be wiped, or an empty string when it can be.
+// The device must not be mounted, held by another process or be an NSD that may belong to a LocalDisk
+// of the node. The identity of an NSD can't be read from the device, so an NSD is only wiped when the
+// node has no LocalDisk.
+func getWipeRejection(host diskutil.Host, dev diskutil.BlockDevice, localDisks sets.Set[string]) (string, error) {
+	for _, d := range append([]diskutil.BlockDevice{dev}, dev.Children...) {
+		mounted, mountPoint, err := d.HasBindMounts(host)
+		if err != nil {
+			return "", fmt.Errorf("failed to check the mounts of %q: %w", d.KName, err)
+		}
+		if mounted {
+			return fmt.Sprintf("%s is mounted at %q", d.KName, mountPoint), nil
+		}
+
+		path, err := d.GetDevPath()
+		if err != nil {
+			return "", err
+		}
+		if localDisks.Has(path) {
+			return fmt.Sprintf("%s is the device of a current LocalDisk", d.KName), nil
+		}
+		canOpen, err := host.CanOpenExclusively(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open %q: %w", path, err)
+		}
+		if !canOpen {
+			return fmt.Sprintf("%s is in use", d.KName), nil
+		}
+	}
+
+	owned, err := dev.IsStorageScaleOwned(host)
+	if owned && err != nil {
+		return fmt.Sprintf("%s has a Storage Scale partition whose NSD descriptor can not be read: %v", dev.KName, err), nil
+	}
+	if owned && localDisks.Len() > 0 {
+		return fmt.Sprintf("%s is a Storage Scale NSD and the node has LocalDisks, it may be the NSD of one of them", dev.KName), nil
+	}
+
+	return "", nil
+}
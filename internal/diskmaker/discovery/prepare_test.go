@@ -0,0 +1,313 @@
+package discovery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeCommand returns a fixed output
+type fakeCommand struct {
+	output string
+	err    error
+}
+
+func (c fakeCommand) CombinedOutput() ([]byte, error) {
+	return []byte(c.output), c.err
+}
+
+// buildNSDHeader returns a device header holding an NSD descriptor
+func buildNSDHeader() []byte {
+	header := make([]byte, 1024)
+	copy(header[512:], "NSD desc")
+	return header
+}
+
+func TestPrepareDevice(t *testing.T) {
+	devices := []diskutils.BlockDevice{
+		{Name: "sda", KName: "sda", Type: "disk", WWN: "0x5000c500155a3456"},
+		{Name: "sdb", KName: "sdb", Type: "disk", WWN: "0x5000c500155a3457",
+			Children: []diskutils.BlockDevice{{Name: "sdb1", KName: "sdb1", Type: "part"}}},
+		{Name: "sdc", KName: "sdc", Type: "disk", WWN: "0x5000c50015ea7599",
+			Children: []diskutils.BlockDevice{{Name: "mpatha", KName: "dm-0", Type: "mpath"}}},
+		{Name: "sdd", KName: "sdd", Type: "disk", WWN: "0x5000c50015ea7599",
+			Children: []diskutils.BlockDevice{{Name: "mpatha", KName: "dm-0", Type: "mpath"}}},
+		{Name: "sde", KName: "sde", Type: "disk", WWN: "0x5000c500155a3458"},
+		{Name: "sdf", KName: "sdf", Type: "disk", WWN: "0x5000c500155a3458"},
+	}
+
+	testcases := []struct {
+		label           string
+		spec            v1alpha1.DiskPrepareRequestSpec
+		snapshot        diskutils.Snapshot
+		localDisks      []string
+		wipefsErr       error
+		expectedPhase   v1alpha1.DiskPreparePhase
+		expectedMessage string
+		expectedPath    string
+		expectedWiped   []string
+	}{
+		{
+			label:           "case 1", // available device is wiped
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			expectedPhase:   v1alpha1.DiskPrepareSucceeded,
+			expectedMessage: "wiped /dev/sda",
+			expectedPath:    "/dev/sda",
+			expectedWiped:   []string{"sda: xfs"},
+		},
+		{
+			label:           "case 2", // wipe not confirmed
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456"},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: "confirmWipe is not set",
+		},
+		{
+			label:           "case 3", // unknown WWN
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a0000", ConfirmWipe: true},
+			expectedPhase:   v1alpha1.DiskPrepareFailed,
+			expectedMessage: `no device with WWN "0x5000c500155a0000" found`,
+		},
+		{
+			label:           "case 4", // multipath device is wiped instead of its paths
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c50015ea7599", ConfirmWipe: true},
+			expectedPhase:   v1alpha1.DiskPrepareSucceeded,
+			expectedMessage: "wiped /dev/dm-0",
+			expectedPath:    "/dev/dm-0",
+			expectedWiped:   []string{"dm-0: xfs"},
+		},
+		{
+			label:           "case 5", // WWN shared by devices that are not multipathed
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3458", ConfirmWipe: true},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: `WWN "0x5000c500155a3458" matches several devices: sde, sdf`,
+		},
+		{
+			label: "case 6", // partition is mounted
+			spec:  v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3457", ConfirmWipe: true},
+			snapshot: diskutils.Snapshot{
+				MountInfo: "3000 2966 8:17 / /var/lib/data rw,relatime shared:1 - xfs /dev/sdb1 rw",
+			},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: `sdb1 is mounted at "/var/lib/data"`,
+			expectedPath:    "/dev/sdb",
+		},
+		{
+			label:           "case 7", // device is held by another process
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			snapshot:        diskutils.Snapshot{BusyDevices: []string{"/dev/sda"}},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: "sda is in use",
+			expectedPath:    "/dev/sda",
+		},
+		{
+			label: "case 8", // device is the device of a current LocalDisk
+			spec:  v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			snapshot: diskutils.Snapshot{
+				Headers:  map[string][]byte{"/dev/sda": buildNSDHeader()},
+				Symlinks: map[string]string{"/dev/disk/by-id/wwn-0x5000c500155a3456": "/dev/sda"},
+			},
+			localDisks:      []string{"/dev/disk/by-id/wwn-0x5000c500155a3456"},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: "sda is the device of a current LocalDisk",
+			expectedPath:    "/dev/sda",
+		},
+		{
+			label:           "case 9", // device is a stale NSD on a node without LocalDisks
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			snapshot:        diskutils.Snapshot{Headers: map[string][]byte{"/dev/sda": buildNSDHeader()}},
+			expectedPhase:   v1alpha1.DiskPrepareSucceeded,
+			expectedMessage: "wiped /dev/sda",
+			expectedPath:    "/dev/sda",
+			expectedWiped:   []string{"sda: xfs"},
+		},
+		{
+			label:           "case 10", // NSD on a node with LocalDisks, its LocalDisk may have been created with another path
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			snapshot:        diskutils.Snapshot{Headers: map[string][]byte{"/dev/sda": buildNSDHeader()}},
+			localDisks:      []string{"/dev/sdb"},
+			expectedPhase:   v1alpha1.DiskPrepareRejected,
+			expectedMessage: "sda is a Storage Scale NSD and the node has LocalDisks, it may be the NSD of one of them",
+			expectedPath:    "/dev/sda",
+		},
+		{
+			label:           "case 11", // device without NSD on a node with LocalDisks
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			localDisks:      []string{"/dev/sdb"},
+			expectedPhase:   v1alpha1.DiskPrepareSucceeded,
+			expectedMessage: "wiped /dev/sda",
+			expectedPath:    "/dev/sda",
+			expectedWiped:   []string{"sda: xfs"},
+		},
+		{
+			label:           "case 12", // wipefs fails
+			spec:            v1alpha1.DiskPrepareRequestSpec{WWN: "0x5000c500155a3456", ConfirmWipe: true},
+			wipefsErr:       errors.New("exit status 1"),
+			expectedPhase:   v1alpha1.DiskPrepareFailed,
+			expectedMessage: `failed to wipe /dev/sda: failed to run wipefs on "/dev/sda": exit status 1, output: probing initialization failed`,
+			expectedPath:    "/dev/sda",
+			expectedWiped:   []string{},
+		},
+	}
+
+	for _, tc := range testcases {
+		host := &diskutils.MockHost{
+			Snapshot: tc.snapshot,
+			MockExecute: func(name string, args ...string) diskutils.Command {
+				switch {
+				case name == "wipefs" && tc.wipefsErr != nil:
+					return fakeCommand{output: "probing initialization failed", err: tc.wipefsErr}
+				case name == "wipefs":
+					path := args[len(args)-1]
+					return fakeCommand{output: path + ": 4 bytes were erased at offset 0x00000000 (xfs): 58 46 53 42"}
+				}
+				return fakeCommand{}
+			},
+		}
+		request := &v1alpha1.DiskPrepareRequest{Spec: tc.spec}
+
+		outcome := prepareDevice(host, devices, resolveLocalDiskDevices(host, tc.localDisks), request)
+		assert.Equalf(t, tc.expectedPhase, outcome.phase, "[%s] invalid phase", tc.label)
+		assert.Equalf(t, tc.expectedMessage, outcome.message, "[%s] invalid message", tc.label)
+		assert.Equalf(t, tc.expectedPath, outcome.devicePath, "[%s] invalid device path", tc.label)
+		assert.Equalf(t, tc.expectedWiped, outcome.wipedSignatures, "[%s] invalid wiped signatures", tc.label)
+	}
+}
+
+func TestProcessPrepareRequests(t *testing.T) {
+	setEnv()
+	defer unsetEnv()
+	lsblkOut := `{"blockdevices": [
+		{"name": "sdb", "rota": false, "type": "disk", "size": 62914560000, "model": "VBOX HARDDISK", "vendor": "ATA", "ro": false, "rm": false, "state": "running", "kname": "sdb", "serial": "", "partlabel": null, "wwn": "0x5000c500155a3457"}]}`
+
+	requests := &v1alpha1.DiskPrepareRequestList{Items: []v1alpha1.DiskPrepareRequest{
+		{ObjectMeta: metav1.ObjectMeta{Name: "wipe-sdb"},
+			Spec: v1alpha1.DiskPrepareRequestSpec{NodeName: "node1", WWN: "0x5000c500155a3457", ConfirmWipe: true}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-node"},
+			Spec: v1alpha1.DiskPrepareRequestSpec{NodeName: "node2", WWN: "0x5000c500155a3457", ConfirmWipe: true}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "done"},
+			Spec:   v1alpha1.DiskPrepareRequestSpec{NodeName: "node1", WWN: "0x5000c500155a3457", ConfirmWipe: true},
+			Status: v1alpha1.DiskPrepareRequestStatus{Phase: v1alpha1.DiskPrepareSucceeded}},
+	}}
+
+	wiped := false
+	updated := []*v1alpha1.DiskPrepareRequest{}
+	var discovered []v1alpha1.DiscoveredDevice
+	dd := getFakeDeviceDiscovery()
+	dd.host = &diskutils.MockHost{MockExecute: func(name string, args ...string) diskutils.Command {
+		switch name {
+		case "lsblk":
+			return fakeCommand{output: lsblkOut}
+		case "blkid":
+			if wiped {
+				return fakeCommand{}
+			}
+			return fakeCommand{output: `/dev/sdb: TYPE="xfs"`}
+		case "wipefs":
+			wiped = true
+			return fakeCommand{output: "/dev/sdb: 4 bytes were erased at offset 0x00000000 (xfs): 58 46 53 42"}
+		}
+		return fakeCommand{}
+	}}
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockListDiskPrepareRequests: func(namespace string) (*v1alpha1.DiskPrepareRequestList, error) {
+			return requests, nil
+		},
+		MockUpdateDiskPrepareRequestStatus: func(dpr *v1alpha1.DiskPrepareRequest) error {
+			updated = append(updated, dpr.DeepCopy())
+			return nil
+		},
+		MockApplyDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			discovered = lvdr.Status.DiscoveredDevices
+			return nil
+		},
+	}
+	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
+
+	err := dd.processPrepareRequests()
+	assert.NoError(t, err)
+	// the request is claimed before the device is wiped
+	assert.Len(t, updated, 2)
+	assert.Equal(t, "wipe-sdb", updated[0].Name)
+	assert.Equal(t, v1alpha1.DiskPrepareInProgress, updated[0].Status.Phase)
+	assert.Equal(t, "node1", updated[0].Status.ClaimedBy.NodeName)
+	assert.Equal(t, "diskmaker-discovery-abcde", updated[0].Status.ClaimedBy.PodName)
+	assert.Equal(t, "wipe-sdb", updated[1].Name)
+	assert.Equal(t, v1alpha1.DiskPrepareSucceeded, updated[1].Status.Phase)
+	assert.Equal(t, "/dev/sdb", updated[1].Status.DevicePath)
+	assert.Equal(t, []string{"sdb: xfs"}, updated[1].Status.WipedSignatures)
+	assert.NotNil(t, updated[1].Status.CompletionTime)
+	assert.Equal(t, updated[0].Status.ClaimedBy, updated[1].Status.ClaimedBy)
+	assert.True(t, strings.HasPrefix(updated[1].Status.Message, "wiped"))
+
+	// the discovery runs again and reports the device as available
+	assert.Len(t, discovered, 1)
+	assert.Equal(t, v1alpha1.Available, discovered[0].Status.State)
+}
+
+func TestProcessPrepareRequestsClaim(t *testing.T) {
+	setEnv()
+	defer unsetEnv()
+	lsblkOut := `{"blockdevices": [
+		{"name": "sdb", "rota": false, "type": "disk", "size": 62914560000, "model": "VBOX HARDDISK", "vendor": "ATA", "ro": false, "rm": false, "state": "running", "kname": "sdb", "serial": "", "partlabel": null, "wwn": "0x5000c500155a3457"}]}`
+	claimedAt := func(podName string, age time.Duration) v1alpha1.DiskPrepareRequestStatus {
+		return v1alpha1.DiskPrepareRequestStatus{
+			Phase:     v1alpha1.DiskPrepareInProgress,
+			ClaimedBy: &v1alpha1.DiskPrepareClaim{NodeName: "node1", PodName: podName, ClaimTime: metav1.NewTime(time.Now().Add(-age))},
+		}
+	}
+	spec := v1alpha1.DiskPrepareRequestSpec{NodeName: "node1", WWN: "0x5000c500155a3457", ConfirmWipe: true}
+	requests := &v1alpha1.DiskPrepareRequestList{Items: []v1alpha1.DiskPrepareRequest{
+		{ObjectMeta: metav1.ObjectMeta{Name: "claimed-by-other"}, Spec: spec},
+		{ObjectMeta: metav1.ObjectMeta{Name: "in-progress"}, Spec: spec, Status: claimedAt("diskmaker-discovery-fghij", time.Minute)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "timed-out"}, Spec: spec, Status: claimedAt("diskmaker-discovery-fghij", prepareClaimTimeout+time.Minute)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "restarted"}, Spec: spec, Status: claimedAt("diskmaker-discovery-abcde", time.Minute)},
+	}}
+
+	wiped := false
+	updated := map[string]*v1alpha1.DiskPrepareRequest{}
+	dd := getFakeDeviceDiscovery()
+	dd.host = &diskutils.MockHost{MockExecute: func(name string, args ...string) diskutils.Command {
+		switch name {
+		case "lsblk":
+			return fakeCommand{output: lsblkOut}
+		case "wipefs", "dd":
+			wiped = true
+		}
+		return fakeCommand{}
+	}}
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockListDiskPrepareRequests: func(namespace string) (*v1alpha1.DiskPrepareRequestList, error) {
+			return requests, nil
+		},
+		MockUpdateDiskPrepareRequestStatus: func(dpr *v1alpha1.DiskPrepareRequest) error {
+			// another diskmaker of the node updated the request since it was listed
+			if dpr.Name == "claimed-by-other" {
+				return kerrors.NewConflict(v1alpha1.GroupVersion.WithResource("diskpreparerequests").GroupResource(), dpr.Name, errors.New("modified"))
+			}
+			updated[dpr.Name] = dpr.DeepCopy()
+			return nil
+		},
+	}
+	dd.eventSync = diskmaker.NewEventReporter(dd.apiClient)
+
+	err := dd.processPrepareRequests()
+	assert.NoError(t, err)
+	assert.False(t, wiped)
+	assert.NotContains(t, updated, "claimed-by-other")
+	// the claim of another running diskmaker is left alone
+	assert.NotContains(t, updated, "in-progress")
+	// the interrupted wipes are failed and not retried
+	assert.Equal(t, v1alpha1.DiskPrepareFailed, updated["timed-out"].Status.Phase)
+	assert.Equal(t, "the wipe by pod diskmaker-discovery-fghij was interrupted, check the device and create a new request",
+		updated["timed-out"].Status.Message)
+	assert.Equal(t, v1alpha1.DiskPrepareFailed, updated["restarted"].Status.Phase)
+}
@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	"k8s.io/klog/v2"
+)
+
+// discoverySettings are the tunables of the discovery loop, set from the LocalVolumeDiscovery spec
+type discoverySettings struct {
+	probeInterval         time.Duration
+	udevEventPeriod       time.Duration
+	udevMonitoringEnabled bool
+	udevExclusionFilter   []string
+}
+
+// getDiscoverySettings reads the discovery settings from the environment.
+// Invalid values are logged and replaced by their defaults.
+func getDiscoverySettings() discoverySettings {
+	settings := discoverySettings{
+		probeInterval:         defaultProbeInterval,
+		udevEventPeriod:       defaultUdevEventPeriod,
+		udevMonitoringEnabled: true,
+		udevExclusionFilter:   defaultUdevExclusionFilter,
+	}
+
+	if value := os.Getenv(common.ProbeIntervalEnv); value != "" {
+		if d, err := parsePositiveDuration(value); err != nil {
+			klog.Warningf("ignoring invalid %s %q: %v", common.ProbeIntervalEnv, value, err)
+		} else {
+			settings.probeInterval = d
+		}
+	}
+
+	if value := os.Getenv(common.UdevEventPeriodEnv); value != "" {
+		if d, err := parsePositiveDuration(value); err != nil {
+			klog.Warningf("ignoring invalid %s %q: %v", common.UdevEventPeriodEnv, value, err)
+		} else {
+			settings.udevEventPeriod = d
+		}
+	}
+
+	if value := os.Getenv(common.UdevMonitoringEnabledEnv); value != "" {
+		if enabled, err := strconv.ParseBool(value); err != nil {
+			klog.Warningf("ignoring invalid %s %q: %v", common.UdevMonitoringEnabledEnv, value, err)
+		} else {
+			settings.udevMonitoringEnabled = enabled
+		}
+	}
+
+	if value := os.Getenv(common.UdevExclusionFilterEnv); value != "" {
+		if filter, err := parseRegexList(value); err != nil {
+			klog.Warningf("ignoring invalid %s %q: %v", common.UdevExclusionFilterEnv, value, err)
+		} else {
+			settings.udevExclusionFilter = filter
+		}
+	}
+
+	return settings
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+// parseRegexList parses a JSON list of regular expressions and checks that they compile
+func parseRegexList(value string) ([]string, error) {
+	list := []string{}
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return nil, err
+	}
+	for _, expr := range list {
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+)
+
+func TestGetDiscoverySettings(t *testing.T) {
+	testcases := []struct {
+		label    string
+		env      map[string]string
+		expected discoverySettings
+	}{
+		{
+			label: "Case 1: defaults without env",
+			env:   map[string]string{},
+			expected: discoverySettings{
+				probeInterval:         defaultProbeInterval,
+				udevEventPeriod:       defaultUdevEventPeriod,
+				udevMonitoringEnabled: true,
+				udevExclusionFilter:   defaultUdevExclusionFilter,
+			},
+		},
+		{
+			label: "Case 2: all settings from env",
+			env: map[string]string{
+				common.ProbeIntervalEnv:         "30m0s",
+				common.UdevEventPeriodEnv:       "1s",
+				common.UdevMonitoringEnabledEnv: "false",
+				common.UdevExclusionFilterEnv:   `["(?i)dm-[0-9]+","(?i)loop[0-9]+"]`,
+			},
+			expected: discoverySettings{
+				probeInterval:         30 * time.Minute,
+				udevEventPeriod:       time.Second,
+				udevMonitoringEnabled: false,
+				udevExclusionFilter:   []string{"(?i)dm-[0-9]+", "(?i)loop[0-9]+"},
+			},
+		},
+		{
+			label: "Case 3: invalid values fall back to defaults",
+			env: map[string]string{
+				common.ProbeIntervalEnv:         "-5m",
+				common.UdevEventPeriodEnv:       "soon",
+				common.UdevMonitoringEnabledEnv: "maybe",
+				common.UdevExclusionFilterEnv:   `["(?i)dm-[0-9"]`,
+			},
+			expected: discoverySettings{
+				probeInterval:         defaultProbeInterval,
+				udevEventPeriod:       defaultUdevEventPeriod,
+				udevMonitoringEnabled: true,
+				udevExclusionFilter:   defaultUdevExclusionFilter,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		for key, value := range tc.env {
+			os.Setenv(key, value)
+		}
+		assert.Equalf(t, tc.expected, getDiscoverySettings(), "[%s] invalid discovery settings", tc.label)
+		for key := range tc.env {
+			os.Unsetenv(key)
+		}
+	}
+}
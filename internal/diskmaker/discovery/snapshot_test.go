@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+)
+
+// snapshotsDir holds node snapshots, each with the inventory expected from them in inventory.json
+const snapshotsDir = "../../../test/data/snapshots"
+
+func TestSnapshots(t *testing.T) {
+	snapshots, err := os.ReadDir(snapshotsDir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, snapshots)
+
+	for _, snapshot := range snapshots {
+		dir := filepath.Join(snapshotsDir, snapshot.Name())
+		host, err := diskutils.NewReplayHostFromPath(dir)
+		if !assert.NoErrorf(t, err, "[%s] failed to load snapshot", snapshot.Name()) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "inventory.json"))
+		if !assert.NoErrorf(t, err, "[%s] failed to read expected inventory", snapshot.Name()) {
+			continue
+		}
+		expected := &Inventory{}
+		assert.NoError(t, json.Unmarshal(data, expected))
+
+		actual, err := GetInventory(host)
+		assert.NoErrorf(t, err, "[%s] failed to get inventory", snapshot.Name())
+		assert.Equalf(t, expected, actual, "[%s] inventory didn't match", snapshot.Name())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
@@ -12,18 +14,46 @@ import (
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 )
 
+// getResultNameFormat returns the format of the name of the result of a discovery on a node, or of its
+// shard when shard is not 0. The results are named after the discovery and the node, so that discoveries
+// selecting the same node don't overwrite each other's results. Node and discovery names may contain
+// dashes, so the names end with a hash of the discovery, the node and the shard: otherwise discovery foo
+// on node bar and auto-discover-devices on node foo-bar would share a name. The primary results of
+// auto-discover-devices keep their historical name.
+func getResultNameFormat(discoveryName, nodeName string, shard int) string {
+	if discoveryName == common.LocalVolumeDiscoveryName && shard == 0 {
+		return resultCRName
+	}
+	format := resultCRName
+	if discoveryName != common.LocalVolumeDiscoveryName {
+		format = fmt.Sprintf(discoveryResultCRName, discoveryName)
+	}
+	if shard != 0 {
+		format = fmt.Sprintf("%s-shard-%d", format, shard)
+	}
+	return fmt.Sprintf("%s-%s", format, hash(fmt.Sprintf("%s/%s/%d", discoveryName, nodeName, shard))[:8])
+}
+
+// getResultName returns the name of the result of a discovery on a node, or of its shard when shard is not 0
+func getResultName(discoveryName, nodeName string, shard int) string {
+	return truncateNodeName(getResultNameFormat(discoveryName, nodeName, shard), nodeName)
+}
+
 // newDiscoveryResultInstance creates spec for the LocalVolumeDiscoveryResult
 func newDiscoveryResultInstance(nodeName, namespace, parentObjName, parentObjUID string) *v1alpha1.LocalVolumeDiscoveryResult {
-	truncatedNodeName := truncateNodeName(resultCRName, nodeName)
+	truncatedNodeName := getResultName(parentObjName, nodeName, 0)
 	labels := map[string]string{}
 	labels[common.DiscoveryNodeLabel] = nodeName
+	labels[common.DiscoveryNameLabel] = parentObjName
 	cr := &v1alpha1.LocalVolumeDiscoveryResult{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      truncatedNodeName,
@@ -56,7 +86,29 @@ func (discovery *DeviceDiscovery) ensureDiscoveryResultCR() error {
 		return errors.New("failed to create LocalVolumeDiscoveryResult resource. missing required env variables")
 	}
 	newCR := newDiscoveryResultInstance(nodeName, namespace, parentObjName, parentObjUID)
-	_, err := discovery.apiClient.GetDiscoveryResult(newCR.Name, newCR.Namespace)
+	newCR.Spec.Topology = discovery.getNodeTopology(nodeName)
+	existingCR, err := discovery.apiClient.GetDiscoveryResult(newCR.Name, newCR.Namespace)
+	if err == nil && existingCR != nil {
+		if name, ok := existingCR.Labels[common.DiscoveryNameLabel]; ok && name != parentObjName {
+			return fmt.Errorf("LocalVolumeDiscoveryResult %q belongs to LocalVolumeDiscovery %q", existingCR.Name, name)
+		}
+		// the LocalVolumeDiscovery was recreated, or the result predates the discovery name label
+		if !isOwnedBy(existingCR, parentObjName, parentObjUID) {
+			klog.Infof("LocalVolumeDiscoveryResult %q is now owned by LocalVolumeDiscovery %q", existingCR.Name, parentObjName)
+			existingCR.OwnerReferences = newCR.OwnerReferences
+			if existingCR.Labels == nil {
+				existingCR.Labels = map[string]string{}
+			}
+			existingCR.Labels[common.DiscoveryNameLabel] = parentObjName
+			err = discovery.apiClient.UpdateDiscoveryResult(existingCR)
+			if err != nil {
+				return errors.Wrapf(err, "failed to update the owner of LocalVolumeDiscoveryResult resource")
+			}
+		}
+		// continue tracking the devices known before the restart of the daemon
+		discovery.disks = discovery.getKnownDevices(existingCR)
+		return nil
+	}
 
 	if kerrors.IsNotFound(err) {
 		err = discovery.apiClient.CreateDiscoveryResult(newCR)
@@ -73,30 +125,178 @@ func (discovery *DeviceDiscovery) ensureDiscoveryResultCR() error {
 	return err
 }
 
-// updateStatus updates the LocalVolumeDiscoveryResult resource status
+// isOwnedBy returns true if the result is labeled with the name of the LocalVolumeDiscovery and owned by its UID
+func isOwnedBy(result *v1alpha1.LocalVolumeDiscoveryResult, name, uid string) bool {
+	if result.Labels[common.DiscoveryNameLabel] != name {
+		return false
+	}
+	for _, ref := range result.OwnerReferences {
+		if ref.Kind == "LocalVolumeDiscovery" && string(ref.UID) == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStatus updates the LocalVolumeDiscoveryResult resources of the node with the discovered devices.
+// The devices are split in shards of maxDevicesPerResult, the first one is stored in the primary result
+// with the summary of the node and the others in additional results named after the primary one.
 func (discovery *DeviceDiscovery) updateStatus() error {
-	truncatedNodeName := truncateNodeName(resultCRName, os.Getenv("MY_NODE_NAME"))
+	truncatedNodeName := getResultName(os.Getenv("DISCOVERY_OBJECT_NAME"), os.Getenv("MY_NODE_NAME"), 0)
 	resultCR, err := discovery.apiClient.GetDiscoveryResult(truncatedNodeName, os.Getenv("WATCH_NAMESPACE"))
-	if err != nil {
-		if kerrors.IsNotFound(err) {
-			klog.Warning("result resource not found. Ignoring since object must be deleted.")
-			return nil
+	if kerrors.IsNotFound(err) {
+		// the operator deletes the results that were not refreshed for their TTL, the daemon is running again
+		klog.Warning("result resource not found. Creating it again.")
+		err = discovery.ensureDiscoveryResultCR()
+		if err != nil {
+			return err
 		}
+		resultCR, err = discovery.apiClient.GetDiscoveryResult(truncatedNodeName, os.Getenv("WATCH_NAMESPACE"))
+	}
+	if err != nil {
 		return errors.Wrapf(err, "failed to retrieve LocalVolumeDiscoveryResult resource to update status")
 	}
 
-	// Update discovered devce list and discovery time
-	resultCR.Status.DiscoveredDevices = discovery.disks
-	resultCR.Status.DiscoveredTimeStamp = time.Now().UTC().Format(time.RFC3339)
+	// the labels of the node can change while the daemon runs
+	if topology := discovery.getNodeTopology(resultCR.Spec.NodeName); topology != nil && !reflect.DeepEqual(resultCR.Spec.Topology, topology) {
+		resultCR.Spec.Topology = topology
+		err = discovery.apiClient.ApplyDiscoveryResult(resultCR)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the topology in the LocalVolumeDiscoveryResult resource")
+		}
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	shards := splitDevices(discovery.disks, maxDevicesPerResult)
+
+	// write the shards first, so that the shards listed in the primary result always exist
+	shardNames := []string{}
+	for i, devices := range shards[1:] {
+		shard := newDiscoveryResultShard(resultCR, os.Getenv("DISCOVERY_OBJECT_NAME"), i+1)
+		err = discovery.apiClient.ApplyDiscoveryResult(shard)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply LocalVolumeDiscoveryResult shard %q", shard.Name)
+		}
+		shard.Status = v1alpha1.LocalVolumeDiscoveryResultStatus{
+			DiscoveredTimeStamp: timestamp,
+			DiscoveredDevices:   devices,
+		}
+		err = discovery.apiClient.ApplyDiscoveryResultStatus(shard)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the device status in the LocalVolumeDiscoveryResult shard %q", shard.Name)
+		}
+		shardNames = append(shardNames, shard.Name)
+	}
+
+	// the paths of a multipath device are counted once, with the multipath device
+	summary := &v1alpha1.DiscoverySummary{}
+	for _, device := range v1alpha1.UniqueDevicesByWWN(discovery.disks) {
+		summary.AddDevice(device)
+	}
+	staleShards := sets.New(resultCR.Status.Shards...).Delete(shardNames...)
 
-	err = discovery.apiClient.UpdateDiscoveryResultStatus(resultCR)
+	// Update discovered devce list and discovery time
+	resultCR.Status = v1alpha1.LocalVolumeDiscoveryResultStatus{
+		DiscoveredTimeStamp: timestamp,
+		DiscoveredDevices:   shards[0],
+		Summary:             summary,
+		Shards:              shardNames,
+		IgnoredDevices:      discovery.ignoredDevices,
+		Initiators:          discovery.initiators,
+	}
+	err = discovery.apiClient.ApplyDiscoveryResultStatus(resultCR)
 	if err != nil {
 		return errors.Wrapf(err, "failed to update the device status in the LocalVolumeDiscoveryResult resource")
 	}
 
+	for _, name := range sets.List(staleShards) {
+		err = discovery.apiClient.DeleteDiscoveryResult(name, resultCR.Namespace)
+		if err != nil {
+			klog.Warningf("failed to delete stale LocalVolumeDiscoveryResult shard %q: %v", name, err)
+		}
+	}
+
 	return nil
 }
 
+// getNodeTopology returns the failure domain and the architecture of the node, or nil when the node can't be read
+func (discovery *DeviceDiscovery) getNodeTopology(nodeName string) *v1alpha1.NodeTopology {
+	node, err := discovery.apiClient.GetNode(nodeName)
+	if err != nil {
+		klog.Warningf("failed to get node %q to read its topology: %v", nodeName, err)
+		return nil
+	}
+	return newNodeTopology(node)
+}
+
+// newNodeTopology reads the topology labels and the architecture of a node
+func newNodeTopology(node *corev1.Node) *v1alpha1.NodeTopology {
+	architecture := node.Status.NodeInfo.Architecture
+	if architecture == "" {
+		architecture = node.Labels[corev1.LabelArchStable]
+	}
+	return &v1alpha1.NodeTopology{
+		Region:       node.Labels[corev1.LabelTopologyRegion],
+		Zone:         node.Labels[corev1.LabelTopologyZone],
+		Rack:         node.Labels[common.RackLabel],
+		Architecture: architecture,
+	}
+}
+
+// getKnownDevices returns the devices stored in a primary result and its shards. The expected paths of
+// their multipath devices are restored too, so that the paths lost while the daemon was down are reported.
+func (discovery *DeviceDiscovery) getKnownDevices(resultCR *v1alpha1.LocalVolumeDiscoveryResult) []v1alpha1.DiscoveredDevice {
+	devices := append([]v1alpha1.DiscoveredDevice{}, resultCR.Status.DiscoveredDevices...)
+	for _, name := range resultCR.Status.Shards {
+		shard, err := discovery.apiClient.GetDiscoveryResult(name, resultCR.Namespace)
+		if err != nil {
+			klog.Warningf("failed to get LocalVolumeDiscoveryResult shard %q: %v", name, err)
+			continue
+		}
+		devices = append(devices, shard.Status.DiscoveredDevices...)
+	}
+
+	if discovery.expectedPaths == nil {
+		discovery.expectedPaths = map[string]int{}
+	}
+	for _, device := range devices {
+		if device.Multipath != nil && device.WWN != "" && device.Multipath.ExpectedPaths > discovery.expectedPaths[device.WWN] {
+			discovery.expectedPaths[device.WWN] = device.Multipath.ExpectedPaths
+		}
+	}
+	return devices
+}
+
+// newDiscoveryResultShard returns the shard with the given index of a primary result of the discovery
+func newDiscoveryResultShard(primary *v1alpha1.LocalVolumeDiscoveryResult, discoveryName string, index int) *v1alpha1.LocalVolumeDiscoveryResult {
+	labels := map[string]string{}
+	for key, value := range primary.Labels {
+		labels[key] = value
+	}
+	labels[common.DiscoveryNameLabel] = discoveryName
+	labels[common.DiscoveryShardLabel] = strconv.Itoa(index)
+
+	return &v1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            getResultName(discoveryName, primary.Spec.NodeName, index),
+			Namespace:       primary.Namespace,
+			Labels:          labels,
+			OwnerReferences: primary.OwnerReferences,
+		},
+		Spec: primary.Spec,
+	}
+}
+
+// splitDevices splits the devices in chunks of at most size devices. It always returns at least one chunk.
+func splitDevices(devices []v1alpha1.DiscoveredDevice, size int) [][]v1alpha1.DiscoveredDevice {
+	chunks := [][]v1alpha1.DiscoveredDevice{}
+	for len(devices) > size {
+		chunks = append(chunks, devices[:size])
+		devices = devices[size:]
+	}
+	return append(chunks, devices)
+}
+
 // hash stableName computes a stable pseudorandom string suitable for inclusion in a Kubernetes object name from the given seed string.
 func hash(s string) string {
 	h := sha256.Sum256([]byte(s))
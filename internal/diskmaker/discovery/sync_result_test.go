@@ -6,10 +6,14 @@ import (
 	"testing"
 
 	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
 	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestEnsureDiscoveryResult(t *testing.T) {
@@ -20,6 +24,84 @@ func TestEnsureDiscoveryResult(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestEnsureDiscoveryResultOwner(t *testing.T) {
+	testcases := []struct {
+		label         string
+		discoveryName string
+		ownerUID      string
+		expectUpdated bool
+		expectErr     bool
+	}{
+		{
+			label:         "case 1", // the result is owned by the LocalVolumeDiscovery of the daemon
+			discoveryName: "auto-discover-devices",
+			ownerUID:      "uid",
+		},
+		{
+			label:         "case 2", // the LocalVolumeDiscovery was recreated
+			discoveryName: "auto-discover-devices",
+			ownerUID:      "other-uid",
+			expectUpdated: true,
+		},
+		{
+			label:         "case 3", // the result predates the discovery name label
+			ownerUID:      "uid",
+			expectUpdated: true,
+		},
+		{
+			label:         "case 4", // the result of another LocalVolumeDiscovery is never taken over
+			discoveryName: "other",
+			ownerUID:      "other-uid",
+			expectErr:     true,
+		},
+	}
+
+	setEnv()
+	defer unsetEnv()
+	for _, tc := range testcases {
+		var updated *v1alpha1.LocalVolumeDiscoveryResult
+		dd := getFakeDeviceDiscovery()
+		dd.apiClient = &diskmaker.MockAPIUpdater{
+			MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+				result := &v1alpha1.LocalVolumeDiscoveryResult{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: namespace,
+						OwnerReferences: []metav1.OwnerReference{
+							{Kind: "LocalVolumeDiscovery", Name: "other", UID: types.UID(tc.ownerUID)},
+						},
+					},
+				}
+				if tc.discoveryName != "" {
+					result.Labels = map[string]string{common.DiscoveryNameLabel: tc.discoveryName}
+				}
+				return result, nil
+			},
+			MockUpdateDiscoveryResult: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+				updated = lvdr
+				return nil
+			},
+		}
+
+		err := dd.ensureDiscoveryResultCR()
+		if tc.expectErr {
+			assert.Errorf(t, err, "[%s] expected error", tc.label)
+			assert.Nilf(t, updated, "[%s] the result must not be updated", tc.label)
+			continue
+		}
+		assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		if !tc.expectUpdated {
+			assert.Nilf(t, updated, "[%s] the result must not be updated", tc.label)
+			continue
+		}
+		assert.NotNilf(t, updated, "[%s] the result must be updated", tc.label)
+		assert.Lenf(t, updated.OwnerReferences, 1, "[%s] invalid owners", tc.label)
+		assert.Equalf(t, "auto-discover-devices", updated.OwnerReferences[0].Name, "[%s] invalid owner", tc.label)
+		assert.Equalf(t, types.UID("uid"), updated.OwnerReferences[0].UID, "[%s] invalid owner", tc.label)
+		assert.Equalf(t, "auto-discover-devices", updated.Labels[common.DiscoveryNameLabel], "[%s] invalid label", tc.label)
+	}
+}
+
 func TestEnsureDiscoveryResultNoEnv(t *testing.T) {
 	// failed to ensure discovery result due to missing env variables.
 	dd := getFakeDeviceDiscovery()
@@ -52,6 +134,31 @@ func TestUpdateStatus(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateStatusRecreatesResult(t *testing.T) {
+	// the result was deleted by the operator after its TTL
+	created := false
+	mockClient := &diskmaker.MockAPIUpdater{
+		MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+			if !created {
+				return nil, kerrors.NewNotFound(v1alpha1.GroupVersion.WithResource("localvolumediscoveryresults").GroupResource(), name)
+			}
+			return &v1alpha1.LocalVolumeDiscoveryResult{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}, nil
+		},
+		MockCreateDiscoveryResult: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			created = true
+			return nil
+		},
+	}
+	dd := getFakeDeviceDiscovery()
+	dd.apiClient = mockClient
+	dd.eventSync = diskmaker.NewEventReporter(mockClient)
+	setEnv()
+	defer unsetEnv()
+	err := dd.updateStatus()
+	assert.NoError(t, err)
+	assert.True(t, created)
+}
+
 func TestUpdateStatusFail(t *testing.T) {
 	// failed to get discovery result
 	mockClient := &diskmaker.MockAPIUpdater{
@@ -68,7 +175,7 @@ func TestUpdateStatusFail(t *testing.T) {
 
 	// failed to update discovery result status
 	mockClient = &diskmaker.MockAPIUpdater{
-		MockUpdateDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+		MockApplyDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
 			return fmt.Errorf("failed to update status")
 		},
 	}
@@ -80,6 +187,114 @@ func TestUpdateStatusFail(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestUpdateStatusShards(t *testing.T) {
+	devices := []v1alpha1.DiscoveredDevice{}
+	for i := 0; i < 2*maxDevicesPerResult+1; i++ {
+		devices = append(devices, v1alpha1.DiscoveredDevice{
+			Path:   fmt.Sprintf("/dev/dm-%d", i),
+			Size:   100,
+			Status: v1alpha1.DeviceStatus{State: v1alpha1.Available},
+		})
+	}
+	devices[0].Status.State = v1alpha1.NotAvailable
+
+	primary := &v1alpha1.LocalVolumeDiscoveryResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery-result-node1", Namespace: "ns"},
+		Spec:       v1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: "node1"},
+		Status: v1alpha1.LocalVolumeDiscoveryResultStatus{
+			Shards: []string{"discovery-result-node1-shard-1-c98ee258", "discovery-result-node1-shard-2-d35e79bd", "discovery-result-node1-shard-3-7bc1469c"},
+		},
+	}
+	applied := map[string]*v1alpha1.LocalVolumeDiscoveryResult{}
+	deleted := []string{}
+	mockClient := &diskmaker.MockAPIUpdater{
+		MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+			return primary.DeepCopy(), nil
+		},
+		MockApplyDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			applied[lvdr.Name] = lvdr.DeepCopy()
+			return nil
+		},
+		MockDeleteDiscoveryResult: func(name, namespace string) error {
+			deleted = append(deleted, name)
+			return nil
+		},
+	}
+
+	dd := getFakeDeviceDiscovery()
+	dd.apiClient = mockClient
+	dd.disks = devices
+	dd.ignoredDevices = []v1alpha1.IgnoredDevice{{Name: "loop0", Filter: supportedType, Reason: `unsupported type "loop"`}}
+	setEnv()
+	defer unsetEnv()
+	err := dd.updateStatus()
+	assert.NoError(t, err)
+
+	assert.Len(t, applied, 3)
+	result := applied["discovery-result-node1"]
+	assert.Len(t, result.Status.DiscoveredDevices, maxDevicesPerResult)
+	assert.Equal(t, []string{"discovery-result-node1-shard-1-c98ee258", "discovery-result-node1-shard-2-d35e79bd"}, result.Status.Shards)
+	assert.Equal(t, &v1alpha1.DiscoverySummary{
+		TotalDevices:        2*maxDevicesPerResult + 1,
+		AvailableDevices:    2 * maxDevicesPerResult,
+		NotAvailableDevices: 1,
+		TotalCapacity:       100 * (2*maxDevicesPerResult + 1),
+		AvailableCapacity:   100 * 2 * maxDevicesPerResult,
+	}, result.Status.Summary)
+	assert.Equal(t, dd.ignoredDevices, result.Status.IgnoredDevices)
+
+	shard := applied["discovery-result-node1-shard-2-d35e79bd"]
+	assert.Len(t, shard.Status.DiscoveredDevices, 1)
+	assert.Nil(t, shard.Status.Summary)
+	assert.Nil(t, shard.Status.IgnoredDevices)
+	assert.Equal(t, "2", shard.Labels[common.DiscoveryShardLabel])
+	assert.Equal(t, "node1", shard.Spec.NodeName)
+
+	// the shard that is no longer needed is deleted
+	assert.Equal(t, []string{"discovery-result-node1-shard-3-7bc1469c"}, deleted)
+}
+
+func TestUpdateStatusSummary(t *testing.T) {
+	const wwn = "0x6005076812808104c800000000000001"
+	dd := getFakeDeviceDiscovery()
+	dd.disks = []v1alpha1.DiscoveredDevice{
+		{Path: "/dev/sdb", WWN: wwn, Type: v1alpha1.DiskType, Size: 1000,
+			Status: v1alpha1.DeviceStatus{State: v1alpha1.NotAvailable}},
+		{Path: "/dev/sdc", WWN: wwn, Type: v1alpha1.DiskType, Size: 1000,
+			Status: v1alpha1.DeviceStatus{State: v1alpha1.NotAvailable}},
+		{Path: "/dev/dm-0", WWN: wwn, Type: v1alpha1.MultiPathType, Size: 1000,
+			Status: v1alpha1.DeviceStatus{State: v1alpha1.Available}},
+		{Path: "/dev/sdd", WWN: "0x5000c500a1b2c3d4", Type: v1alpha1.DiskType, Size: 500,
+			Status: v1alpha1.DeviceStatus{State: v1alpha1.Missing}},
+	}
+	var applied *v1alpha1.LocalVolumeDiscoveryResult
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+			return &v1alpha1.LocalVolumeDiscoveryResult{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}, nil
+		},
+		MockApplyDiscoveryResultStatus: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			applied = lvdr.DeepCopy()
+			return nil
+		},
+	}
+	setEnv()
+	defer unsetEnv()
+	err := dd.updateStatus()
+	assert.NoError(t, err)
+
+	// the paths of the multipath device are counted with it, the size of the missing device is not counted
+	assert.Len(t, applied.Status.DiscoveredDevices, 4)
+	assert.Equal(t, &v1alpha1.DiscoverySummary{
+		TotalDevices:      2,
+		AvailableDevices:  1,
+		MissingDevices:    1,
+		TotalCapacity:     1000,
+		AvailableCapacity: 1000,
+		DiskDevices:       1,
+		MultipathDevices:  1,
+	}, applied.Status.Summary)
+}
+
 func TestNewDiscoveryResultInstance(t *testing.T) {
 	testCases := []struct {
 		label            string
@@ -97,9 +312,9 @@ func TestNewDiscoveryResultInstance(t *testing.T) {
 			parentObjectUID:  "f288b336-434e-4939-b742-9d8fd232a56c",
 			expected: v1alpha1.LocalVolumeDiscoveryResult{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "discovery-result-node1",
+					Name:      "discovery-result-diskmaker-discvoery-123-node1-b68b88fd",
 					Namespace: "local-storage",
-					Labels:    map[string]string{"discovery-result-node": "node1"},
+					Labels:    map[string]string{"discovery-result-node": "node1", "discovery-result-discovery": "diskmaker-discvoery-123"},
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							Name: "diskmaker-discvoery-123",
@@ -120,9 +335,9 @@ func TestNewDiscoveryResultInstance(t *testing.T) {
 			parentObjectUID:  "f288b336-434e-4939-b742-9d8fd232a56c",
 			expected: v1alpha1.LocalVolumeDiscoveryResult{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "discovery-result-d57ec549800941f89ed17bbfcd013459",
+					Name:      "discovery-result-diskmaker-discvoery-456-d57ec549800941f89ed17bbfcd013459-9a0435a1",
 					Namespace: "default",
-					Labels:    map[string]string{"discovery-result-node": "192.168.1.27.ec2.internal.node-name-greater-than-253-characters-1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890"},
+					Labels:    map[string]string{"discovery-result-node": "192.168.1.27.ec2.internal.node-name-greater-than-253-characters-1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890.1234567890", "discovery-result-discovery": "diskmaker-discvoery-456"},
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							Name: "diskmaker-discvoery-456",
@@ -135,6 +350,29 @@ func TestNewDiscoveryResultInstance(t *testing.T) {
 				},
 			},
 		},
+		{
+			label:            "Case 3: the results of auto-discover-devices keep their historical name",
+			nodeName:         "node1",
+			namespace:        "local-storage",
+			parentObjectName: "auto-discover-devices",
+			parentObjectUID:  "f288b336-434e-4939-b742-9d8fd232a56c",
+			expected: v1alpha1.LocalVolumeDiscoveryResult{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "discovery-result-node1",
+					Namespace: "local-storage",
+					Labels:    map[string]string{"discovery-result-node": "node1", "discovery-result-discovery": "auto-discover-devices"},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							Name: "auto-discover-devices",
+							UID:  "f288b336-434e-4939-b742-9d8fd232a56c",
+						},
+					},
+				},
+				Spec: v1alpha1.LocalVolumeDiscoveryResultSpec{
+					NodeName: "node1",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -148,6 +386,37 @@ func TestNewDiscoveryResultInstance(t *testing.T) {
 	}
 }
 
+func TestGetResultName(t *testing.T) {
+	// node and discovery names may contain dashes
+	names := map[string]string{}
+	for _, result := range []struct {
+		discoveryName string
+		nodeName      string
+		shard         int
+	}{
+		{discoveryName: "foo", nodeName: "bar"},
+		{discoveryName: common.LocalVolumeDiscoveryName, nodeName: "foo-bar"},
+		{discoveryName: "foo-bar", nodeName: "baz"},
+		{discoveryName: "foo", nodeName: "bar-baz"},
+		{discoveryName: common.LocalVolumeDiscoveryName, nodeName: "foo-bar-baz"},
+		{discoveryName: common.LocalVolumeDiscoveryName, nodeName: "node1", shard: 1},
+		{discoveryName: common.LocalVolumeDiscoveryName, nodeName: "node1-shard-1"},
+		{discoveryName: "foo", nodeName: "node1", shard: 1},
+		{discoveryName: "foo", nodeName: "node1-shard-1"},
+		{discoveryName: "foo-node1", nodeName: "shard-1"},
+	} {
+		name := getResultName(result.discoveryName, result.nodeName, result.shard)
+		key := fmt.Sprintf("%s/%s/%d", result.discoveryName, result.nodeName, result.shard)
+		other, ok := names[name]
+		assert.Falsef(t, ok, "results %s and %s share name %q", key, other, name)
+		names[name] = key
+	}
+
+	// the primary results of the default discovery keep their historical name
+	assert.Equal(t, "discovery-result-foo-bar", getResultName(common.LocalVolumeDiscoveryName, "foo-bar", 0))
+	assert.Equal(t, "discovery-result-foo-bar-58c1b281", getResultName("foo", "bar", 0))
+}
+
 func TestTruncateNodeName(t *testing.T) {
 	testcases := []struct {
 		label    string
@@ -181,3 +450,40 @@ func TestTruncateNodeName(t *testing.T) {
 		assert.Equalf(t, tc.expected, actual, "[%s]: failed to truncate node name", tc.label)
 	}
 }
+
+func TestUpdateStatusTopology(t *testing.T) {
+	dd := getFakeDeviceDiscovery()
+	setEnv()
+	defer unsetEnv()
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{
+			corev1.LabelTopologyRegion: "eu",
+			corev1.LabelTopologyZone:   "eu-1a",
+			common.RackLabel:           "r1",
+			corev1.LabelArchStable:     "arm64",
+		}},
+	}
+	applied := []*v1alpha1.LocalVolumeDiscoveryResult{}
+	dd.apiClient = &diskmaker.MockAPIUpdater{
+		MockGetDiscoveryResult: func(name, namespace string) (*v1alpha1.LocalVolumeDiscoveryResult, error) {
+			return &v1alpha1.LocalVolumeDiscoveryResult{Spec: v1alpha1.LocalVolumeDiscoveryResultSpec{NodeName: "node1"}}, nil
+		},
+		MockGetNode: func(name string) (*corev1.Node, error) {
+			return node, nil
+		},
+		MockApplyDiscoveryResult: func(lvdr *v1alpha1.LocalVolumeDiscoveryResult) error {
+			applied = append(applied, lvdr)
+			return nil
+		},
+	}
+
+	err := dd.updateStatus()
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, &v1alpha1.NodeTopology{Region: "eu", Zone: "eu-1a", Rack: "r1", Architecture: "arm64"}, applied[0].Spec.Topology)
+
+	// the architecture reported by the kubelet wins over the label
+	node.Status.NodeInfo.Architecture = "amd64"
+	assert.Equal(t, "amd64", newNodeTopology(node).Architecture)
+}
@@ -26,9 +26,19 @@ const (
 	ErrorCreatingDiscoveryResultObject = "ErrorCreatingDiscoveryResultObject"
 	ErrorUpdatingDiscoveryResultObject = "ErrorUpdatingDiscoveryResultObject"
 	ErrorListingBlockDevices           = "ErrorListingBlockDevices"
+	MultipathDegraded                  = "MultipathDegraded"
+	DeviceMissing                      = "DeviceMissing"
+	DeviceReplaced                     = "DeviceReplaced"
+	ErrorPreparingDisk                 = "ErrorPreparingDisk"
+	DiskPrepareRejected                = "DiskPrepareRejected"
 
 	CreatedDiscoveryResultObject = "CreatedDiscoveryResultObject"
 	UpdatedDiscoveredDeviceList  = "UpdatedDiscoveredDeviceList"
+	DeviceAdded                  = "DeviceAdded"
+	DeviceRemoved                = "DeviceRemoved"
+	DeviceResized                = "DeviceResized"
+	DeviceStateChanged           = "DeviceStateChanged"
+	DiskPrepared                 = "DiskPrepared"
 )
 
 // DiskEvent is instance of a single event
@@ -75,3 +85,11 @@ func (reporter *EventReporter) Report(e *DiskEvent, obj runtime.Object) {
 	reporter.apiClient.recordEvent(obj, e)
 	reporter.reportedEvents.Insert(eventKey)
 }
+
+// ReportChange records an event without deduplication. It is meant for events describing a
+// change that can happen several times for the same disk, like a state transition.
+func (reporter *EventReporter) ReportChange(e *DiskEvent, obj runtime.Object) {
+	reporter.mux.Lock()
+	defer reporter.mux.Unlock()
+	reporter.apiClient.recordEvent(obj, e)
+}
@@ -0,0 +1,228 @@
+package netcheck
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// probeInterval is the time between two rounds of probes to the peers
+	probeInterval = 30 * time.Second
+	// dialTimeout is the time a port has to accept a connection
+	dialTimeout = 3 * time.Second
+	// probeWorkers bounds the number of connections opened at once to a peer
+	probeWorkers = 64
+)
+
+// DialFunc opens a TCP connection to an address, like net.DialTimeout
+type DialFunc func(address string, timeout time.Duration) error
+
+// NetworkChecker listens on the ports of the Storage Scale daemons and probes the other nodes of a NetworkCheck on them
+type NetworkChecker struct {
+	apiClient diskmaker.ApiUpdater
+	name      string
+	namespace string
+	nodeName  string
+	ports     []common.PortRange
+	dial      DialFunc
+}
+
+// NewNetworkChecker returns the checker of the NetworkCheck for this node
+func NewNetworkChecker(name, namespace, nodeName string) (*NetworkChecker, error) {
+	scheme := scheme.Scheme
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add scheme: %w", err)
+	}
+	apiClient, err := diskmaker.NewAPIUpdater(scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new APIUpdater: %w", err)
+	}
+	return &NetworkChecker{
+		apiClient: apiClient,
+		name:      name,
+		namespace: namespace,
+		nodeName:  nodeName,
+		ports:     common.ScalePorts,
+		dial:      dialTCP,
+	}, nil
+}
+
+func dialTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Start listens on the ports and probes the peers until the process is stopped
+func (c *NetworkChecker) Start() error {
+	listeners, err := Listen(c.ports)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+	klog.Infof("listening on ports %s", strings.Join(portRangeStrings(c.ports), ", "))
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, syscall.SIGINT)
+	for {
+		if err := c.probePeers(); err != nil {
+			klog.Errorf("failed to probe the peers: %v", err)
+		}
+		select {
+		case <-sigc:
+			klog.Info("exiting")
+			return nil
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+// Listen listens on all the ports of the ranges and closes the connections as soon as they are accepted
+func Listen(ports []common.PortRange) ([]net.Listener, error) {
+	listeners := []net.Listener{}
+	for _, portRange := range ports {
+		for port := portRange.First; port <= portRange.Last; port++ {
+			listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+			if err != nil {
+				for _, l := range listeners {
+					l.Close()
+				}
+				return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+			}
+			listeners = append(listeners, listener)
+			go accept(listener)
+		}
+	}
+	return listeners, nil
+}
+
+func accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}
+}
+
+// probePeers probes the ready peers of the check and applies the outcome to its matrix
+func (c *NetworkChecker) probePeers() error {
+	check, err := c.apiClient.GetNetworkCheck(c.name, c.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get NetworkCheck %q: %w", c.name, err)
+	}
+	// the daemons are removed once the check completes
+	if check.Status.Phase == v1alpha1.NetworkCheckPassed || check.Status.Phase == v1alpha1.NetworkCheckFailed {
+		return nil
+	}
+
+	probes := []v1alpha1.NetworkProbe{}
+	for _, peer := range check.Status.Peers {
+		if peer.NodeName == c.nodeName || !peer.Ready || peer.Address == "" {
+			continue
+		}
+		probe := c.probePeer(peer)
+		klog.Infof("probe to %s (%s): %s %s", peer.NodeName, peer.Address, probe.Result, probe.Message)
+		probes = append(probes, probe)
+	}
+	if len(probes) == 0 {
+		return nil
+	}
+	return c.apiClient.ApplyNetworkCheckProbes(c.name, c.namespace, c.nodeName, probes)
+}
+
+// probePeer opens a connection to each port of the peer
+func (c *NetworkChecker) probePeer(peer v1alpha1.NetworkCheckPeer) v1alpha1.NetworkProbe {
+	ports := make(chan int)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	failed := []int{}
+	var firstErr error
+	for i := 0; i < probeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range ports {
+				err := c.dial(net.JoinHostPort(peer.Address, strconv.Itoa(port)), dialTimeout)
+				if err == nil {
+					continue
+				}
+				lock.Lock()
+				failed = append(failed, port)
+				if firstErr == nil {
+					firstErr = err
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, portRange := range c.ports {
+		for port := portRange.First; port <= portRange.Last; port++ {
+			ports <- port
+		}
+	}
+	close(ports)
+	wg.Wait()
+
+	probe := v1alpha1.NetworkProbe{
+		Source:    c.nodeName,
+		Target:    peer.NodeName,
+		Result:    v1alpha1.NetworkProbePassed,
+		Message:   fmt.Sprintf("reached %s on all the ports", peer.Address),
+		ProbeTime: metav1.Now(),
+	}
+	if len(failed) > 0 {
+		probe.Result = v1alpha1.NetworkProbeFailed
+		probe.FailedPorts = FormatPorts(failed)
+		probe.Message = fmt.Sprintf("failed to reach %s on %d ports: %v", peer.Address, len(failed), firstErr)
+	}
+	return probe
+}
+
+// FormatPorts sorts the ports and collapses the consecutive ones in ranges
+func FormatPorts(ports []int) []string {
+	sorted := append([]int{}, ports...)
+	sort.Ints(sorted)
+	ranges := []common.PortRange{}
+	for _, port := range sorted {
+		last := len(ranges) - 1
+		if last >= 0 && port <= ranges[last].Last+1 {
+			ranges[last].Last = max(ranges[last].Last, port)
+			continue
+		}
+		ranges = append(ranges, common.PortRange{First: port, Last: port})
+	}
+	return portRangeStrings(ranges)
+}
+
+func portRangeStrings(ranges []common.PortRange) []string {
+	formatted := []string{}
+	for _, portRange := range ranges {
+		formatted = append(formatted, portRange.String())
+	}
+	return formatted
+}
@@ -0,0 +1,139 @@
+package netcheck
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+)
+
+func TestFormatPorts(t *testing.T) {
+	testcases := []struct {
+		label    string
+		ports    []int
+		expected []string
+	}{
+		{label: "case 1", ports: []int{}, expected: []string{}},
+		{label: "case 2", ports: []int{1191}, expected: []string{"1191"}},
+		{label: "case 3", ports: []int{60002, 60000, 60001, 12345, 1191, 60005}, expected: []string{"1191", "12345", "60000-60002", "60005"}},
+		{label: "case 4", ports: []int{60000, 60000, 60001}, expected: []string{"60000-60001"}},
+	}
+	for _, tc := range testcases {
+		assert.Equalf(t, tc.expected, FormatPorts(tc.ports), "[%s]", tc.label)
+	}
+}
+
+func TestProbePeer(t *testing.T) {
+	// the firewall of the peer blocks part of the ephemeral range
+	dial := func(address string, timeout time.Duration) error {
+		_, port, err := net.SplitHostPort(address)
+		assert.NoError(t, err)
+		number, _ := strconv.Atoi(port)
+		if number >= 60500 {
+			return fmt.Errorf("dial tcp %s: i/o timeout", address)
+		}
+		return nil
+	}
+	checker := &NetworkChecker{nodeName: "worker-0", ports: common.ScalePorts, dial: dial}
+
+	probe := checker.probePeer(v1alpha1.NetworkCheckPeer{NodeName: "worker-1", Address: "10.0.0.2", Ready: true})
+	assert.Equal(t, "worker-0", probe.Source)
+	assert.Equal(t, "worker-1", probe.Target)
+	assert.Equal(t, v1alpha1.NetworkProbeFailed, probe.Result)
+	assert.Equal(t, []string{"60500-61000"}, probe.FailedPorts)
+	assert.Contains(t, probe.Message, "failed to reach 10.0.0.2 on 501 ports: dial tcp 10.0.0.2:")
+}
+
+func TestProbePeerListening(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go accept(listener)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// the port next to the listener is closed
+	checker := &NetworkChecker{nodeName: "worker-0", ports: []common.PortRange{{First: port, Last: port}, {First: port + 1, Last: port + 1}}, dial: dialTCP}
+	probe := checker.probePeer(v1alpha1.NetworkCheckPeer{NodeName: "worker-1", Address: "127.0.0.1", Ready: true})
+	assert.Equal(t, v1alpha1.NetworkProbeFailed, probe.Result)
+	assert.Equal(t, []string{strconv.Itoa(port + 1)}, probe.FailedPorts)
+
+	checker.ports = checker.ports[:1]
+	probe = checker.probePeer(v1alpha1.NetworkCheckPeer{NodeName: "worker-1", Address: "127.0.0.1", Ready: true})
+	assert.Equal(t, v1alpha1.NetworkProbePassed, probe.Result)
+	assert.Empty(t, probe.FailedPorts)
+}
+
+func TestProbePeers(t *testing.T) {
+	testcases := []struct {
+		label           string
+		phase           v1alpha1.NetworkCheckPhase
+		peers           []v1alpha1.NetworkCheckPeer
+		expectedTargets []string
+	}{
+		{
+			label: "case 1", // the node itself and the peers that are not ready are skipped
+			phase: v1alpha1.NetworkCheckRunning,
+			peers: []v1alpha1.NetworkCheckPeer{
+				{NodeName: "worker-0", Address: "10.0.0.1", Ready: true},
+				{NodeName: "worker-1", Address: "10.0.0.2", Ready: true},
+				{NodeName: "worker-2", Address: "10.0.0.3", Ready: false},
+				{NodeName: "worker-3", Address: "10.0.0.4", Ready: true},
+			},
+			expectedTargets: []string{"worker-1", "worker-3"},
+		},
+		{
+			label: "case 2", // no peer ready yet
+			phase: v1alpha1.NetworkCheckRunning,
+			peers: []v1alpha1.NetworkCheckPeer{
+				{NodeName: "worker-0", Address: "10.0.0.1", Ready: true},
+				{NodeName: "worker-1", Ready: false},
+			},
+		},
+		{
+			label: "case 3", // completed check
+			phase: v1alpha1.NetworkCheckPassed,
+			peers: []v1alpha1.NetworkCheckPeer{
+				{NodeName: "worker-0", Address: "10.0.0.1", Ready: true},
+				{NodeName: "worker-1", Address: "10.0.0.2", Ready: true},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		var targets []string
+		apiClient := &diskmaker.MockAPIUpdater{
+			MockGetNetworkCheck: func(name, namespace string) (*v1alpha1.NetworkCheck, error) {
+				check := &v1alpha1.NetworkCheck{}
+				check.Name = name
+				check.Status.Phase = tc.phase
+				check.Status.Peers = tc.peers
+				return check, nil
+			},
+			MockApplyNetworkCheckProbes: func(name, namespace, nodeName string, probes []v1alpha1.NetworkProbe) error {
+				assert.Equalf(t, "storage-nodes", name, "[%s]", tc.label)
+				assert.Equalf(t, "worker-0", nodeName, "[%s]", tc.label)
+				for _, probe := range probes {
+					assert.Equalf(t, v1alpha1.NetworkProbePassed, probe.Result, "[%s]", tc.label)
+					targets = append(targets, probe.Target)
+				}
+				return nil
+			},
+		}
+		checker := &NetworkChecker{
+			apiClient: apiClient,
+			name:      "storage-nodes",
+			namespace: "purple-storage",
+			nodeName:  "worker-0",
+			ports:     common.ScalePorts,
+			dial:      func(address string, timeout time.Duration) error { return nil },
+		}
+		assert.NoErrorf(t, checker.probePeers(), "[%s]", tc.label)
+		assert.Equalf(t, tc.expectedTargets, targets, "[%s]", tc.label)
+	}
+}
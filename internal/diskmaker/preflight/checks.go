@@ -0,0 +1,356 @@
+package preflight
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/common"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// hostRoot is the root filesystem of the host, reached through the root of the host PID 1.
+	// HostPID should be set to true inside the POD spec.
+	hostRoot = "/proc/1/root"
+	// KernelReleaseFile is the release of the running kernel
+	KernelReleaseFile = "/proc/sys/kernel/osrelease"
+	// MemInfoFile is the memory usage of the node
+	MemInfoFile = "/proc/meminfo"
+	// RouteFile is the IPv4 routing table of the host network namespace
+	RouteFile = "/proc/1/net/route"
+	// KernelSourcesDir is the directory the kernel-devel packages install the kernel headers to
+	KernelSourcesDir = hostRoot + "/usr/src/kernels"
+	// NetClassDir is the sysfs class directory of the network interfaces of the host
+	NetClassDir = hostRoot + "/sys/class/net"
+
+	// minimumMTU is the MTU below which the daemon traffic gets fragmented
+	minimumMTU = 1500
+	// tcpListen is the state of a listening socket in /proc/net/tcp
+	tcpListen = "0A"
+)
+
+// TCPFiles are the TCP sockets of the host network namespace
+var TCPFiles = []string{"/proc/1/net/tcp", "/proc/1/net/tcp6"}
+
+var elReleaseRegexp = regexp.MustCompile(`\.el(\d+)`)
+
+// RunChecks runs all the checks of the node for the release and pagepool of the spec, with the
+// requirements of the release in the support matrix
+func RunChecks(host diskutil.Host, spec *v1alpha1.PreflightReportSpec, matrix utils.SupportMatrix) []v1alpha1.PreflightCheck {
+	data, exists := matrix.GetStorageScaleData(spec.IbmCnsaVersion)
+	var scaleData *utils.StorageScaleData
+	if exists {
+		scaleData = &data
+	}
+	pagepool := resource.MustParse("1Gi")
+	if spec.PagepoolSize != nil {
+		pagepool = *spec.PagepoolSize
+	}
+
+	return []v1alpha1.PreflightCheck{
+		checkArchitecture(host, spec.IbmCnsaVersion, scaleData),
+		checkKernel(host, spec.IbmCnsaVersion, scaleData),
+		checkKernelDevel(host),
+		checkPorts(host),
+		checkMTU(host),
+		checkMemory(host, pagepool),
+		checkHugePages(host),
+		checkTimeSync(host),
+	}
+}
+
+// GetPhase returns Failed when any check failed
+func GetPhase(checks []v1alpha1.PreflightCheck) v1alpha1.PreflightPhase {
+	for _, check := range checks {
+		if check.Result == v1alpha1.PreflightCheckFailed {
+			return v1alpha1.PreflightFailed
+		}
+	}
+	return v1alpha1.PreflightPassed
+}
+
+func newCheck(name string, result v1alpha1.PreflightCheckResult, format string, args ...interface{}) v1alpha1.PreflightCheck {
+	return v1alpha1.PreflightCheck{Name: name, Result: result, Message: fmt.Sprintf(format, args...)}
+}
+
+// checkArchitecture checks that the release supports the architecture of the node
+func checkArchitecture(host diskutil.Host, version string, data *utils.StorageScaleData) v1alpha1.PreflightCheck {
+	const name = "architecture"
+	output, err := host.Execute("uname", "-m").CombinedOutput()
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to get the architecture: %v", err)
+	}
+	arch := strings.TrimSpace(string(output))
+	if data == nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "no support data for version %s, architecture %s not checked", version, arch)
+	}
+	if !slices.Contains(data.Architecture, arch) {
+		return newCheck(name, v1alpha1.PreflightCheckFailed, "architecture %s is not supported by version %s, supported: %s",
+			arch, version, strings.Join(data.Architecture, ", "))
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "architecture %s is supported", arch)
+}
+
+// checkKernel checks that the kernel is built for a RHEL release of the OpenShift levels of the release.
+// OpenShift 4.13 and later run on RHEL 9, the earlier levels on RHEL 8.
+func checkKernel(host diskutil.Host, version string, data *utils.StorageScaleData) v1alpha1.PreflightCheck {
+	const name = "kernel"
+	release, err := getKernelRelease(host)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "%v", err)
+	}
+	if data == nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "no support data for version %s, kernel %s not checked", version, release)
+	}
+	match := elReleaseRegexp.FindStringSubmatch(release)
+	if match == nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "can not tell the RHEL release of kernel %s", release)
+	}
+
+	supported := getSupportedRHELReleases(data.OpenShiftLevels)
+	if !supported.Has(match[1]) {
+		return newCheck(name, v1alpha1.PreflightCheckFailed, "kernel %s is not a RHEL %s kernel as required by version %s",
+			release, strings.Join(sets.List(supported), " or "), version)
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "kernel %s is supported", release)
+}
+
+// getSupportedRHELReleases returns the major RHEL releases of the OpenShift levels
+func getSupportedRHELReleases(openShiftLevels []string) sets.Set[string] {
+	releases := sets.New[string]()
+	for _, level := range openShiftLevels {
+		parts := strings.Split(level, ".")
+		if len(parts) < 2 {
+			continue
+		}
+		minor, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		if parts[0] == "4" && minor <= 12 {
+			releases.Insert("8")
+		} else {
+			releases.Insert("9")
+		}
+	}
+	return releases
+}
+
+func getKernelRelease(host diskutil.Host) (string, error) {
+	data, err := host.ReadFile(KernelReleaseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %v", KernelReleaseFile, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// checkKernelDevel checks that the headers of the running kernel are installed, the daemons build
+// their kernel modules against them
+func checkKernelDevel(host diskutil.Host) v1alpha1.PreflightCheck {
+	const name = "kernel-devel"
+	release, err := getKernelRelease(host)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "%v", err)
+	}
+	_, err = host.ReadFile(filepath.Join(KernelSourcesDir, release, "Makefile"))
+	if os.IsNotExist(err) {
+		return newCheck(name, v1alpha1.PreflightCheckFailed, "kernel-devel is not installed for kernel %s, enable mco_config to install it", release)
+	}
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to check the kernel headers of kernel %s: %v", release, err)
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "kernel-devel is installed for kernel %s", release)
+}
+
+// checkPorts checks that no process of the host listens on the ports of the daemons
+func checkPorts(host diskutil.Host) v1alpha1.PreflightCheck {
+	const name = "ports"
+	listening := sets.New[int]()
+	for _, file := range TCPFiles {
+		data, err := host.ReadFile(file)
+		// IPv6 may be disabled
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to read file %s: %v", file, err)
+		}
+		listening.Insert(parseListeningPorts(data)...)
+	}
+
+	used := []string{}
+	for _, port := range sets.List(listening) {
+		for _, portRange := range common.ScalePorts {
+			if portRange.Contains(port) {
+				used = append(used, strconv.Itoa(port))
+				break
+			}
+		}
+	}
+	ranges := []string{}
+	for _, portRange := range common.ScalePorts {
+		ranges = append(ranges, portRange.String())
+	}
+	if len(used) > 0 {
+		return newCheck(name, v1alpha1.PreflightCheckFailed, "ports %s are already in use", strings.Join(used, ", "))
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "ports %s are free", strings.Join(ranges, ", "))
+}
+
+// parseListeningPorts returns the local ports of the listening sockets of a /proc/net/tcp file
+func parseListeningPorts(data []byte) []int {
+	ports := []int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != tcpListen {
+			continue
+		}
+		index := strings.LastIndex(fields[1], ":")
+		if index < 0 {
+			continue
+		}
+		port, err := strconv.ParseInt(fields[1][index+1:], 16, 32)
+		if err != nil {
+			continue
+		}
+		ports = append(ports, int(port))
+	}
+	return ports
+}
+
+// checkMTU checks the MTU of the interface of the default route
+func checkMTU(host diskutil.Host) v1alpha1.PreflightCheck {
+	const name = "mtu"
+	data, err := host.ReadFile(RouteFile)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to read file %s: %v", RouteFile, err)
+	}
+	iface := getDefaultRouteInterface(data)
+	if iface == "" {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "no default route found")
+	}
+
+	mtuFile := filepath.Join(NetClassDir, iface, "mtu")
+	data, err = host.ReadFile(mtuFile)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to read file %s: %v", mtuFile, err)
+	}
+	mtu, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to parse the MTU of %s: %v", iface, err)
+	}
+	if mtu < minimumMTU {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "MTU of %s is %d, the daemon traffic is fragmented below %d", iface, mtu, minimumMTU)
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "MTU of %s is %d", iface, mtu)
+}
+
+// getDefaultRouteInterface returns the interface of the default route of a /proc/net/route file
+func getDefaultRouteInterface(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 8 && fields[1] == "00000000" && fields[7] == "00000000" {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// checkMemory checks that the available memory of the node can hold the pagepool
+func checkMemory(host diskutil.Host, pagepool resource.Quantity) v1alpha1.PreflightCheck {
+	const name = "memory"
+	meminfo, err := getMemInfo(host)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "%v", err)
+	}
+	available, ok := meminfo["MemAvailable"]
+	if !ok {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "MemAvailable not found in %s", MemInfoFile)
+	}
+	availableQuantity := resource.NewQuantity(available*1024, resource.BinarySI)
+	if availableQuantity.Cmp(pagepool) < 0 {
+		return newCheck(name, v1alpha1.PreflightCheckFailed, "%s of memory available, the pagepool needs %s", availableQuantity, pagepool.String())
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "%s of memory available for a pagepool of %s", availableQuantity, pagepool.String())
+}
+
+// checkHugePages warns when hugepages are reserved, the memory they hold is not available to the pagepool
+func checkHugePages(host diskutil.Host) v1alpha1.PreflightCheck {
+	const name = "hugepages"
+	meminfo, err := getMemInfo(host)
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "%v", err)
+	}
+	total := meminfo["HugePages_Total"]
+	if total > 0 {
+		size := resource.NewQuantity(total*meminfo["Hugepagesize"]*1024, resource.BinarySI)
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "%d hugepages reserved (%s), this memory is not available to the pagepool", total, size)
+	}
+	return newCheck(name, v1alpha1.PreflightCheckPassed, "no hugepages reserved")
+}
+
+// getMemInfo returns the values of /proc/meminfo, in kB for the sizes
+func getMemInfo(host diskutil.Host) (map[string]int64, error) {
+	data, err := host.ReadFile(MemInfoFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", MemInfoFile, err)
+	}
+	meminfo := map[string]int64{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// MemAvailable:   12345678 kB
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		number, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		meminfo[key] = number
+	}
+	return meminfo, nil
+}
+
+// checkTimeSync checks that chrony synchronizes the clock of the host
+func checkTimeSync(host diskutil.Host) v1alpha1.PreflightCheck {
+	const name = "time-sync"
+	output, err := host.Execute("chroot", hostRoot, "chronyc", "-n", "tracking").CombinedOutput()
+	if err != nil {
+		return newCheck(name, v1alpha1.PreflightCheckWarning, "failed to get the chrony tracking: %v", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		// Leap status     : Normal
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || strings.TrimSpace(key) != "Leap status" {
+			continue
+		}
+		status := strings.TrimSpace(value)
+		if status == "Not synchronised" {
+			return newCheck(name, v1alpha1.PreflightCheckFailed, "clock is not synchronised")
+		}
+		return newCheck(name, v1alpha1.PreflightCheckPassed, "clock is synchronised, leap status %s", status)
+	}
+	return newCheck(name, v1alpha1.PreflightCheckWarning, "leap status not found in the chrony tracking")
+}
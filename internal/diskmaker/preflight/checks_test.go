@@ -0,0 +1,244 @@
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	kernelRelease = "5.14.0-427.50.1.el9_4.x86_64"
+	tcpHeader     = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	routeTable    = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"br-ex\t00000000\t0102A8C0\t0003\t0\t0\t48\t00000000\t0\t0\t0\n" +
+		"br-ex\t0002A8C0\t00000000\t0001\t0\t0\t48\t00FFFFFF\t0\t0\t0\n"
+	chronyTracking = "Reference ID    : C0A80201 (192.168.2.1)\nStratum         : 3\nLeap status     : Normal\n"
+)
+
+// newPreflightSnapshot returns a node that passes all the checks of version v5.2.2.0
+func newPreflightSnapshot() *diskutil.Snapshot {
+	snapshot := diskutil.NewSnapshot()
+	snapshot.Commands[diskutil.CommandKey("uname", "-m")] = "x86_64\n"
+	snapshot.Commands[diskutil.CommandKey("chroot", hostRoot, "chronyc", "-n", "tracking")] = chronyTracking
+	snapshot.HostFiles[KernelReleaseFile] = []byte(kernelRelease + "\n")
+	snapshot.HostFiles[KernelSourcesDir+"/"+kernelRelease+"/Makefile"] = []byte("")
+	snapshot.HostFiles[TCPFiles[0]] = []byte(tcpHeader +
+		"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 20971 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0100007F:04A7 0100007F:EA60 01 00000000:00000000 00:00000000 00000000     0        0 20972 1 0000000000000000 20 4 30 10 -1\n")
+	snapshot.HostFiles[RouteFile] = []byte(routeTable)
+	snapshot.HostFiles[NetClassDir+"/br-ex/mtu"] = []byte("9000\n")
+	snapshot.HostFiles[MemInfoFile] = []byte("MemTotal:       32594412 kB\nMemAvailable:   24594412 kB\nHugePages_Total:       0\nHugepagesize:       2048 kB\n")
+	return snapshot
+}
+
+func TestRunChecks(t *testing.T) {
+	pagepool := resource.MustParse("4Gi")
+	matrix, err := utils.GetSupportMatrix(nil)
+	assert.NoError(t, err)
+	checks := RunChecks(diskutil.NewReplayHost(newPreflightSnapshot()), &v1alpha1.PreflightReportSpec{
+		NodeName:       "worker-0",
+		IbmCnsaVersion: "v5.2.2.0",
+		PagepoolSize:   &pagepool,
+	}, matrix)
+
+	names := []string{}
+	for _, check := range checks {
+		names = append(names, check.Name)
+		assert.Equalf(t, v1alpha1.PreflightCheckPassed, check.Result, "%s: %s", check.Name, check.Message)
+	}
+	assert.Equal(t, []string{"architecture", "kernel", "kernel-devel", "ports", "mtu", "memory", "hugepages", "time-sync"}, names)
+	assert.Equal(t, v1alpha1.PreflightPassed, GetPhase(checks))
+}
+
+func TestChecks(t *testing.T) {
+	// v5.1.9.1 is not shipped anymore, its support matrix comes from the ConfigMap
+	matrix, err := utils.GetSupportMatrix(&corev1.ConfigMap{Data: map[string]string{"v5.1.9.1": `schema_version: 1
+csi_version: "2.10.0"
+architecture: [x86_64, ppc64le, s390x]
+openshift_levels: ["4.12", "4.13", "4.14"]
+`}})
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		label   string
+		version string
+		mutate  func(snapshot *diskutil.Snapshot)
+		check   string
+		result  v1alpha1.PreflightCheckResult
+		message string
+	}{
+		{
+			label:   "case 1", // architecture not supported
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.Commands[diskutil.CommandKey("uname", "-m")] = "aarch64\n"
+			},
+			check:   "architecture",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "architecture aarch64 is not supported by version v5.2.2.0, supported: x86_64, ppc64le, s390x",
+		},
+		{
+			label:   "case 2", // version without support data
+			version: "v9.9.9.9",
+			check:   "architecture",
+			result:  v1alpha1.PreflightCheckWarning,
+			message: "no support data for version v9.9.9.9, architecture x86_64 not checked",
+		},
+		{
+			label:   "case 3", // RHEL 8 kernel for a release of OpenShift levels on RHEL 9
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[KernelReleaseFile] = []byte("4.18.0-372.73.1.el8_6.x86_64\n")
+			},
+			check:   "kernel",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "kernel 4.18.0-372.73.1.el8_6.x86_64 is not a RHEL 9 kernel as required by version v5.2.2.0",
+		},
+		{
+			label:   "case 4", // RHEL 8 kernel for a release that also supports OpenShift 4.12
+			version: "v5.1.9.1",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[KernelReleaseFile] = []byte("4.18.0-372.73.1.el8_6.x86_64\n")
+				snapshot.HostFiles[KernelSourcesDir+"/4.18.0-372.73.1.el8_6.x86_64/Makefile"] = []byte("")
+			},
+			check:   "kernel",
+			result:  v1alpha1.PreflightCheckPassed,
+			message: "kernel 4.18.0-372.73.1.el8_6.x86_64 is supported",
+		},
+		{
+			label:   "case 5", // kernel headers missing
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				delete(snapshot.HostFiles, KernelSourcesDir+"/"+kernelRelease+"/Makefile")
+			},
+			check:   "kernel-devel",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "kernel-devel is not installed for kernel " + kernelRelease + ", enable mco_config to install it",
+		},
+		{
+			label:   "case 6", // daemon port and a port of the ephemeral range in use, the connected socket is ignored
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[TCPFiles[1]] = []byte(tcpHeader +
+					"   0: 00000000000000000000000000000000:04A7 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0\n" +
+					"   1: 00000000000000000000000000000000:EA61 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0\n")
+			},
+			check:   "ports",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "ports 1191, 60001 are already in use",
+		},
+		{
+			label:   "case 7", // small MTU
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[NetClassDir+"/br-ex/mtu"] = []byte("1400\n")
+			},
+			check:   "mtu",
+			result:  v1alpha1.PreflightCheckWarning,
+			message: "MTU of br-ex is 1400, the daemon traffic is fragmented below 1500",
+		},
+		{
+			label:   "case 8", // not enough memory for the pagepool
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[MemInfoFile] = []byte("MemAvailable:    2097152 kB\n")
+			},
+			check:   "memory",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "2Gi of memory available, the pagepool needs 4Gi",
+		},
+		{
+			label:   "case 9", // hugepages reserved
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.HostFiles[MemInfoFile] = []byte("MemAvailable:   24594412 kB\nHugePages_Total:     512\nHugepagesize:       2048 kB\n")
+			},
+			check:   "hugepages",
+			result:  v1alpha1.PreflightCheckWarning,
+			message: "512 hugepages reserved (1Gi), this memory is not available to the pagepool",
+		},
+		{
+			label:   "case 10", // clock not synchronised
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				snapshot.Commands[diskutil.CommandKey("chroot", hostRoot, "chronyc", "-n", "tracking")] = "Reference ID    : 00000000 ()\nLeap status     : Not synchronised\n"
+			},
+			check:   "time-sync",
+			result:  v1alpha1.PreflightCheckFailed,
+			message: "clock is not synchronised",
+		},
+		{
+			label:   "case 11", // chrony not available
+			version: "v5.2.2.0",
+			mutate: func(snapshot *diskutil.Snapshot) {
+				delete(snapshot.Commands, diskutil.CommandKey("chroot", hostRoot, "chronyc", "-n", "tracking"))
+			},
+			check:  "time-sync",
+			result: v1alpha1.PreflightCheckWarning,
+		},
+	}
+
+	pagepool := resource.MustParse("4Gi")
+	for _, tc := range testcases {
+		snapshot := newPreflightSnapshot()
+		if tc.mutate != nil {
+			tc.mutate(snapshot)
+		}
+		checks := RunChecks(diskutil.NewReplayHost(snapshot), &v1alpha1.PreflightReportSpec{
+			NodeName:       "worker-0",
+			IbmCnsaVersion: tc.version,
+			PagepoolSize:   &pagepool,
+		}, matrix)
+
+		var found *v1alpha1.PreflightCheck
+		for i := range checks {
+			if checks[i].Name == tc.check {
+				found = &checks[i]
+			}
+		}
+		if !assert.NotNilf(t, found, "[%s] check %s", tc.label, tc.check) {
+			continue
+		}
+		assert.Equalf(t, tc.result, found.Result, "[%s] %s", tc.label, found.Message)
+		if tc.message != "" {
+			assert.Equalf(t, tc.message, found.Message, "[%s]", tc.label)
+		}
+		expectedPhase := v1alpha1.PreflightPassed
+		if tc.result == v1alpha1.PreflightCheckFailed {
+			expectedPhase = v1alpha1.PreflightFailed
+		}
+		assert.Equalf(t, expectedPhase, GetPhase(checks), "[%s]", tc.label)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	var updated *v1alpha1.PreflightReport
+	apiClient := &diskmaker.MockAPIUpdater{
+		MockGetPreflightReport: func(name, namespace string) (*v1alpha1.PreflightReport, error) {
+			report := &v1alpha1.PreflightReport{Spec: v1alpha1.PreflightReportSpec{NodeName: "worker-0", IbmCnsaVersion: "v5.2.2.0"}}
+			report.Name = name
+			report.Namespace = namespace
+			return report, nil
+		},
+		MockUpdatePreflightReportStatus: func(report *v1alpha1.PreflightReport) error {
+			updated = report
+			return nil
+		},
+	}
+
+	err := runReport(diskutil.NewReplayHost(newPreflightSnapshot()), apiClient, "preflight-worker-0", "purple-storage")
+	assert.NoError(t, err)
+	if assert.NotNil(t, updated) {
+		assert.Equal(t, "preflight-worker-0", updated.Name)
+		assert.Equal(t, v1alpha1.PreflightPassed, updated.Status.Phase)
+		assert.Len(t, updated.Status.Checks, 8)
+		assert.NotNil(t, updated.Status.CompletionTime)
+	}
+}
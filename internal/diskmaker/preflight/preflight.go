@@ -0,0 +1,65 @@
+package preflight
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/api/v1alpha1"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/diskmaker"
+	diskutil "github.com/validatedpatterns/purple-storage-rh-operator/internal/diskutils"
+	"github.com/validatedpatterns/purple-storage-rh-operator/internal/utils"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+// Run runs the checks of the PreflightReport on the node and stores their outcome in its status
+func Run(name, namespace string) error {
+	scheme := scheme.Scheme
+	err := v1alpha1.AddToScheme(scheme)
+	if err != nil {
+		return fmt.Errorf("failed to add scheme: %w", err)
+	}
+	apiClient, err := diskmaker.NewAPIUpdater(scheme)
+	if err != nil {
+		return fmt.Errorf("failed to create new APIUpdater: %w", err)
+	}
+	return runReport(diskutil.NewLiveHost(), apiClient, name, namespace)
+}
+
+func runReport(host diskutil.Host, apiClient diskmaker.ApiUpdater, name, namespace string) error {
+	report, err := apiClient.GetPreflightReport(name, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get PreflightReport %q: %w", name, err)
+	}
+
+	// the support matrix of the version can be overridden in the namespace of the report
+	configMap, err := apiClient.GetConfigMap(utils.SupportMatrixConfigMap, namespace)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get ConfigMap %q: %w", utils.SupportMatrixConfigMap, err)
+		}
+		configMap = nil
+	}
+	matrix, err := utils.GetSupportMatrix(configMap)
+	if err != nil {
+		return err
+	}
+
+	checks := RunChecks(host, &report.Spec, matrix)
+	for _, check := range checks {
+		klog.Infof("preflight check %s: %s %s", check.Name, check.Result, check.Message)
+	}
+	report.Status = v1alpha1.PreflightReportStatus{
+		Phase:          GetPhase(checks),
+		Checks:         checks,
+		CompletionTime: &metav1.Time{Time: time.Now()},
+	}
+	err = apiClient.UpdatePreflightReportStatus(report)
+	if err != nil {
+		return fmt.Errorf("failed to update the status of PreflightReport %q: %w", name, err)
+	}
+	return nil
+}
@@ -13,24 +13,17 @@ import (
 	"github.com/pkg/errors"
 )
 
-var (
-	ExecCommand          CommandExecutor
-	FilePathGlob         = filepath.Glob
-	FilePathEvalSymLinks = filepath.EvalSymlinks
-	mountFile            = "/proc/1/mountinfo"
-)
-
-func init() {
-	ExecCommand = CmdExec{}
-}
-
 const (
 	// StateSuspended is a possible value of BlockDevice.State
 	StateSuspended = "suspended"
+	// MultipathType is the BlockDevice.Type of the device-mapper multipath devices
+	MultipathType = "mpath"
 	// DiskByIDDir is the path for symlinks to the device by id.
 	DiskByIDDir = "/dev/disk/by-id/"
 	// DiskDMDir is the path for symlinks of device mapper disks (e.g. mpath)
 	DiskDMDir = "/dev/mapper/"
+	// DiskByPathDir is the path for symlinks to the device by the physical path it is attached through
+	DiskByPathDir = "/dev/disk/by-path/"
 )
 
 type CommandExecutor interface {
@@ -78,17 +71,17 @@ type BlockDevice struct {
 	FSType     string        `json:"fstype,omitempty"`
 	Serial     string        `json:"serial,omitempty"`
 	PartLabel  string        `json:"partlabel,omitempty"`
+	PartType   string        `json:"parttype,omitempty"`
 	PathByID   string        `json:"pathByID,omitempty"` // Fetched from introspecting /dev
 	WWN        string        `json:"WWN,omitempty"`      // Purple unicorn storage fields
 	Children   []BlockDevice `json:"children,omitempty"`
 }
 
 // HasBindMounts checks for bind mounts and returns mount point for a device by parsing `proc/1/mountinfo`.
-// HostPID should be set to true inside the POD spec to get details of host's mount points inside `proc/1/mountinfo`.
-func (b *BlockDevice) HasBindMounts() (bool, string, error) {
-	data, err := os.ReadFile(mountFile)
+func (b *BlockDevice) HasBindMounts(host Host) (bool, string, error) {
+	data, err := host.MountInfo()
 	if err != nil {
-		return false, "", fmt.Errorf("failed to read file %s: %v", mountFile, err)
+		return false, "", err
 	}
 
 	mountString := string(data)
@@ -120,16 +113,16 @@ func (b BlockDevice) GetDevPath() (path string, err error) {
 }
 
 // GetPathByID check on BlockDevice
-func (b *BlockDevice) GetPathByID(existingDeviceID string) (string, error) {
+func (b *BlockDevice) GetPathByID(host Host, existingDeviceID string) (string, error) {
 	// return if previously populated value is valid
 	if len(b.PathByID) > 0 && strings.HasPrefix(b.PathByID, DiskByIDDir) {
-		evalsCorrectly, err := PathEvalsToDiskLabel(b.PathByID, b.KName)
+		evalsCorrectly, err := PathEvalsToDiskLabel(host, b.PathByID, b.KName)
 		if err == nil && evalsCorrectly {
 			return b.PathByID, nil
 		}
 	}
 	b.PathByID = ""
-	allDisks, err := FilePathGlob(filepath.Join(DiskByIDDir, "/*"))
+	allDisks, err := host.Glob(filepath.Join(DiskByIDDir, "/*"))
 	if err != nil {
 		return "", fmt.Errorf("error listing files in %s: %v", DiskByIDDir, err)
 	}
@@ -145,7 +138,7 @@ func (b *BlockDevice) GetPathByID(existingDeviceID string) (string, error) {
 	for _, path := range allDisks {
 		symLinkName := filepath.Base(path)
 		if existingDeviceID != "" && symLinkName == existingDeviceID {
-			isMatch, err := PathEvalsToDiskLabel(path, b.KName)
+			isMatch, err := PathEvalsToDiskLabel(host, path, b.KName)
 			if err != nil {
 				return "", err
 			}
@@ -165,7 +158,7 @@ func (b *BlockDevice) GetPathByID(existingDeviceID string) (string, error) {
 
 	for _, groupedLink := range sortedSymlinks {
 		for _, path := range groupedLink {
-			isMatch, err := PathEvalsToDiskLabel(path, b.KName)
+			isMatch, err := PathEvalsToDiskLabel(host, path, b.KName)
 			if err != nil {
 				return "", err
 			}
@@ -184,9 +177,29 @@ func (b *BlockDevice) GetPathByID(existingDeviceID string) (string, error) {
 	return devPath, IDPathNotFoundError{DeviceName: b.KName}
 }
 
+// GetPathBySlot returns the symlink in /dev/disk/by-path/ of the device, which stays the same when the
+// device is swapped for another one in the same slot. It returns an empty string for devices without
+// a physical slot, like multipath devices.
+func (b BlockDevice) GetPathBySlot(host Host) (string, error) {
+	allPaths, err := host.Glob(filepath.Join(DiskByPathDir, "/*"))
+	if err != nil {
+		return "", fmt.Errorf("error listing files in %s: %v", DiskByPathDir, err)
+	}
+	for _, path := range allPaths {
+		isMatch, err := PathEvalsToDiskLabel(host, path, b.KName)
+		if err != nil {
+			return "", err
+		}
+		if isMatch {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
 // PathEvalsToDiskLabel checks if the path is a symplink to a file devName
-func PathEvalsToDiskLabel(path, devName string) (bool, error) {
-	devPath, err := FilePathEvalSymLinks(path)
+func PathEvalsToDiskLabel(host Host, path, devName string) (bool, error) {
+	devPath, err := host.EvalSymlinks(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -200,18 +213,18 @@ func PathEvalsToDiskLabel(path, devName string) (bool, error) {
 }
 
 // ListBlockDevices using the lsblk command
-func ListBlockDevices(devices []string) ([]BlockDevice, []BlockDevice, error) {
+func ListBlockDevices(host Host, devices []string) ([]BlockDevice, []BlockDevice, error) {
 	// var output bytes.Buffer
 	var blockDevices []BlockDevice
 
-	deviceFSMap, err := GetDeviceFSMap(devices)
+	deviceFSMap, err := GetDeviceFSMap(host, devices)
 	if err != nil {
 		return []BlockDevice{}, []BlockDevice{}, errors.Wrap(err, "failed to list block devices")
 	}
 
-	columns := "NAME,ROTA,TYPE,SIZE,MODEL,VENDOR,RO,RM,STATE,KNAME,SERIAL,PARTLABEL,WWN"
+	columns := "NAME,ROTA,TYPE,SIZE,MODEL,VENDOR,RO,RM,STATE,KNAME,SERIAL,PARTLABEL,PARTTYPE,WWN"
 	args := []string{"--json", "-b", "-o", columns}
-	cmd := ExecCommand.Execute("lsblk", args...)
+	cmd := host.Execute("lsblk", args...)
 	klog.Infof("Executing command: %#v", cmd)
 	output, err := executeCmdWithCombinedOutput(cmd)
 	if err != nil {
@@ -246,6 +259,15 @@ func ListBlockDevices(devices []string) ([]BlockDevice, []BlockDevice, error) {
 		if fs, ok := deviceFSMap[fmt.Sprintf("/dev/%s", row.Name)]; ok {
 			row.FSType = fs
 		}
+		// multipath devices are listed as children of each of their paths, blkid reports them by their map name
+		for i := range row.Children {
+			if row.Children[i].Type != MultipathType {
+				continue
+			}
+			if fs, ok := deviceFSMap[filepath.Join(DiskDMDir, row.Children[i].Name)]; ok {
+				row.Children[i].FSType = fs
+			}
+		}
 		blockDevices = append(blockDevices, row)
 	}
 
@@ -261,10 +283,10 @@ func ListBlockDevices(devices []string) ([]BlockDevice, []BlockDevice, error) {
 // `/dev/sdc: TYPE="ext4"
 // /dev/sdd: TYPE="ext2"`
 // If devices is empty, it scans all disks, otherwise only devices.
-func GetDeviceFSMap(devices []string) (map[string]string, error) {
+func GetDeviceFSMap(host Host, devices []string) (map[string]string, error) {
 	m := map[string]string{}
 	args := append([]string{"-s", "TYPE"}, devices...)
-	cmd := ExecCommand.Execute("blkid", args...)
+	cmd := host.Execute("blkid", args...)
 	output, err := executeCmdWithCombinedOutput(cmd)
 	if err != nil {
 		// According to blkid man page, exit status 2 is returned
@@ -4,7 +4,6 @@ package diskutils
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -165,8 +164,8 @@ func TestListBlockDevices(t *testing.T) {
 	for _, tc := range testcases {
 		lsblkOut = tc.lsblkOutput
 		blkidOut = tc.blkIDOutput
-		ExecCommand = &mockCmdExec{stdout: []string{blkidOut, lsblkOut}}
-		blockDevices, badRows, err := ListBlockDevices([]string{})
+		host := &MockHost{MockExecute: (&mockCmdExec{stdout: []string{blkidOut, lsblkOut}}).Execute}
+		blockDevices, badRows, err := ListBlockDevices(host, []string{})
 		assert.NoError(t, err, "[%q: Device]: invalid json", tc.label)
 		assert.Equalf(t, tc.totalBadRows, len(badRows), "[%s] total bad rows list didn't match", tc.label)
 		assert.Equalf(t, tc.totalBlockDevices, len(blockDevices), "[%s] total block device list didn't match", tc.label)
@@ -254,14 +253,9 @@ func TestGetPathByID(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		FilePathGlob = tc.fakeGlobfunc
-		FilePathEvalSymLinks = tc.fakeEvalSymlinkfunc
-		defer func() {
-			FilePathGlob = filepath.Glob
-			FilePathEvalSymLinks = filepath.EvalSymlinks
-		}()
+		host := &MockHost{MockGlob: tc.fakeGlobfunc, MockEvalSymlinks: tc.fakeEvalSymlinkfunc}
 
-		actual, err := tc.blockDevice.GetPathByID(tc.existingDeviceId)
+		actual, err := tc.blockDevice.GetPathByID(host, tc.existingDeviceId)
 		assert.NoError(t, err)
 		assert.Equalf(t, tc.expected, actual, "[%s] failed to get device path by ID", tc.label)
 	}
@@ -301,15 +295,28 @@ func TestGetPathByIDFail(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		FilePathGlob = tc.fakeGlobfunc
-		FilePathEvalSymLinks = tc.fakeEvalSymlinkfunc
-		defer func() {
-			FilePathGlob = filepath.Glob
-			FilePathEvalSymLinks = filepath.EvalSymlinks
-		}()
+		host := &MockHost{MockGlob: tc.fakeGlobfunc, MockEvalSymlinks: tc.fakeEvalSymlinkfunc}
 
-		actual, err := tc.blockDevice.GetPathByID("" /*existing symlinkpath */)
+		actual, err := tc.blockDevice.GetPathByID(host, "" /*existing symlinkpath */)
 		assert.Error(t, err)
 		assert.Equalf(t, tc.expected, actual, "[%s] failed to get device path by ID", tc.label)
 	}
 }
+
+func TestGetPathBySlot(t *testing.T) {
+	host := NewReplayHost(&Snapshot{
+		Symlinks: map[string]string{
+			"/dev/disk/by-path/pci-0000:3b:00.0-sas-phy4-lun-0":       "/dev/sdb",
+			"/dev/disk/by-path/pci-0000:3b:00.0-sas-phy4-lun-0-part1": "/dev/sdb1",
+			"/dev/disk/by-path/pci-0000:3b:00.0-sas-phy5-lun-0":       "/dev/sdc",
+		},
+	})
+
+	slot, err := BlockDevice{Name: "sdb", KName: "sdb"}.GetPathBySlot(host)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:3b:00.0-sas-phy4-lun-0", slot)
+
+	slot, err = BlockDevice{Name: "mpatha", KName: "dm-0"}.GetPathBySlot(host)
+	assert.NoError(t, err)
+	assert.Empty(t, slot)
+}
@@ -0,0 +1,103 @@
+package diskutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// MountInfoFile is the mountinfo of the host mount namespace.
+// HostPID should be set to true inside the POD spec to get details of host's mount points.
+const MountInfoFile = "/proc/1/mountinfo"
+
+// Host is the access to the node that the discovery pipeline goes through: commands, symlinks,
+// mount points and the devices themselves. The live implementation talks to the node it runs on,
+// the replay implementation serves a snapshot captured on a node.
+type Host interface {
+	CommandExecutor
+	// Glob returns the names of all files matching pattern, like filepath.Glob
+	Glob(pattern string) ([]string, error)
+	// EvalSymlinks returns the path name after the evaluation of any symbolic links, like filepath.EvalSymlinks
+	EvalSymlinks(path string) (string, error)
+	// MountInfo returns the content of the mountinfo of the host mount namespace
+	MountInfo() ([]byte, error)
+	// CanOpenExclusively returns false when the device is in use
+	CanOpenExclusively(path string) (bool, error)
+	// ReadDeviceHeader reads the first size bytes of a device
+	ReadDeviceHeader(path string, size int) ([]byte, error)
+	// ReadFile returns the content of a file of the node, like os.ReadFile
+	ReadFile(path string) ([]byte, error)
+}
+
+// LiveHost is the Host the diskmaker runs on
+type LiveHost struct {
+	CmdExec
+}
+
+var _ Host = LiveHost{}
+
+// NewLiveHost returns the Host the diskmaker runs on
+func NewLiveHost() Host {
+	return LiveHost{}
+}
+
+// Glob calls filepath.Glob
+func (h LiveHost) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+// EvalSymlinks calls filepath.EvalSymlinks
+func (h LiveHost) EvalSymlinks(path string) (string, error) {
+	return filepath.EvalSymlinks(path)
+}
+
+// MountInfo reads MountInfoFile
+func (h LiveHost) MountInfo() ([]byte, error) {
+	data, err := os.ReadFile(MountInfoFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", MountInfoFile, err)
+	}
+	return data, nil
+}
+
+// CanOpenExclusively opens the device with O_EXCL, which fails with EBUSY when the device is in use
+func (h LiveHost) CanOpenExclusively(path string) (bool, error) {
+	fd, errno := unix.Open(path, unix.O_RDONLY|unix.O_EXCL, 0)
+	// If the device is in use, open will return an invalid fd.
+	// When this happens, it is expected that Close will fail and throw an error.
+	defer unix.Close(fd)
+	if errno == nil {
+		// device not in use
+		return true, nil
+	} else if errno == unix.EBUSY {
+		// device is in use
+		return false, nil
+	}
+	// error during call to Open
+	return false, fmt.Errorf("pathname: %q: %w", path, errno)
+}
+
+// ReadDeviceHeader reads the first size bytes of a device
+func (h LiveHost) ReadDeviceHeader(path string, size int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	header := make([]byte, size)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return header[:n], nil
+}
+
+// ReadFile calls os.ReadFile
+func (h LiveHost) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
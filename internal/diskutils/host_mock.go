@@ -0,0 +1,78 @@
+package diskutils
+
+// MockHost mocks the Host. The functions that are not mocked replay the Snapshot
+type MockHost struct {
+	Snapshot               Snapshot
+	MockExecute            func(name string, args ...string) Command
+	MockGlob               func(pattern string) ([]string, error)
+	MockEvalSymlinks       func(path string) (string, error)
+	MockMountInfo          func() ([]byte, error)
+	MockCanOpenExclusively func(path string) (bool, error)
+	MockReadDeviceHeader   func(path string, size int) ([]byte, error)
+	MockReadFile           func(path string) ([]byte, error)
+}
+
+var _ Host = &MockHost{}
+
+// Execute mocks Execute
+func (f *MockHost) Execute(name string, args ...string) Command {
+	if f.MockExecute != nil {
+		return f.MockExecute(name, args...)
+	}
+
+	return NewReplayHost(&f.Snapshot).Execute(name, args...)
+}
+
+// Glob mocks Glob
+func (f *MockHost) Glob(pattern string) ([]string, error) {
+	if f.MockGlob != nil {
+		return f.MockGlob(pattern)
+	}
+
+	return NewReplayHost(&f.Snapshot).Glob(pattern)
+}
+
+// EvalSymlinks mocks EvalSymlinks
+func (f *MockHost) EvalSymlinks(path string) (string, error) {
+	if f.MockEvalSymlinks != nil {
+		return f.MockEvalSymlinks(path)
+	}
+
+	return NewReplayHost(&f.Snapshot).EvalSymlinks(path)
+}
+
+// MountInfo mocks MountInfo
+func (f *MockHost) MountInfo() ([]byte, error) {
+	if f.MockMountInfo != nil {
+		return f.MockMountInfo()
+	}
+
+	return NewReplayHost(&f.Snapshot).MountInfo()
+}
+
+// CanOpenExclusively mocks CanOpenExclusively
+func (f *MockHost) CanOpenExclusively(path string) (bool, error) {
+	if f.MockCanOpenExclusively != nil {
+		return f.MockCanOpenExclusively(path)
+	}
+
+	return NewReplayHost(&f.Snapshot).CanOpenExclusively(path)
+}
+
+// ReadDeviceHeader mocks ReadDeviceHeader
+func (f *MockHost) ReadDeviceHeader(path string, size int) ([]byte, error) {
+	if f.MockReadDeviceHeader != nil {
+		return f.MockReadDeviceHeader(path, size)
+	}
+
+	return NewReplayHost(&f.Snapshot).ReadDeviceHeader(path, size)
+}
+
+// ReadFile mocks ReadFile
+func (f *MockHost) ReadFile(path string) ([]byte, error) {
+	if f.MockReadFile != nil {
+		return f.MockReadFile(path)
+	}
+
+	return NewReplayHost(&f.Snapshot).ReadFile(path)
+}
@@ -0,0 +1,87 @@
+package diskutils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FCHostDir is the sysfs class directory of the Fibre Channel host adapters
+	FCHostDir = "/sys/class/fc_host/"
+	// ISCSIInitiatorNameFile is the iSCSI initiator name file of the host, reached through the root of the
+	// host PID 1. HostPID should be set to true inside the POD spec.
+	ISCSIInitiatorNameFile = "/proc/1/root/etc/iscsi/initiatorname.iscsi"
+)
+
+// FCHost is a Fibre Channel host adapter port as reported by sysfs
+type FCHost struct {
+	// Name of the SCSI host, for eg. host7
+	Name string
+	// PortName is the WWPN of the port, for eg. 0x10000090fa5b1c2d
+	PortName string
+	// NodeName is the WWNN of the adapter
+	NodeName  string
+	PortState string
+	Speed     string
+}
+
+// GetFCHosts returns the Fibre Channel host adapters of the node
+func GetFCHosts(host Host) ([]FCHost, error) {
+	hosts, err := host.Glob(filepath.Join(FCHostDir, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing files in %s: %v", FCHostDir, err)
+	}
+
+	fcHosts := []FCHost{}
+	for _, dir := range hosts {
+		fcHost := FCHost{Name: filepath.Base(dir)}
+		attributes := []struct {
+			name  string
+			value *string
+		}{
+			{name: "port_name", value: &fcHost.PortName},
+			{name: "node_name", value: &fcHost.NodeName},
+			{name: "port_state", value: &fcHost.PortState},
+			{name: "speed", value: &fcHost.Speed},
+		}
+		for _, attribute := range attributes {
+			data, err := host.ReadFile(filepath.Join(dir, attribute.name))
+			if err != nil {
+				// not every driver exposes every attribute
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to read %s of %s: %v", attribute.name, fcHost.Name, err)
+			}
+			*attribute.value = strings.TrimSpace(string(data))
+		}
+		fcHosts = append(fcHosts, fcHost)
+	}
+	return fcHosts, nil
+}
+
+// GetISCSIInitiatorName returns the iSCSI qualified name of the node, or an empty string when the
+// iSCSI initiator is not configured
+func GetISCSIInitiatorName(host Host) (string, error) {
+	data, err := host.ReadFile(ISCSIInitiatorNameFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read file %s: %v", ISCSIInitiatorNameFile, err)
+	}
+
+	// the file holds a single InitiatorName=iqn.... line, along with comments
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if name, found := strings.CutPrefix(line, "InitiatorName="); found {
+			return strings.TrimSpace(name), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,61 @@
+package diskutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFCHosts(t *testing.T) {
+	host := NewReplayHost(&Snapshot{
+		Symlinks: map[string]string{
+			"/sys/class/fc_host/host7": "/sys/devices/pci0000:00/0000:00:03.0/0000:08:00.0/host7/fc_host/host7",
+			"/sys/class/fc_host/host8": "/sys/devices/pci0000:00/0000:00:03.0/0000:08:00.1/host8/fc_host/host8",
+		},
+		HostFiles: map[string][]byte{
+			"/sys/class/fc_host/host7/port_name":  []byte("0x10000090fa5b1c2d\n"),
+			"/sys/class/fc_host/host7/node_name":  []byte("0x20000090fa5b1c2d\n"),
+			"/sys/class/fc_host/host7/port_state": []byte("Online\n"),
+			"/sys/class/fc_host/host7/speed":      []byte("16 Gbit\n"),
+			"/sys/class/fc_host/host8/port_name":  []byte("0x10000090fa5b1c2e\n"),
+			"/sys/class/fc_host/host8/port_state": []byte("Linkdown\n"),
+		},
+	})
+
+	fcHosts, err := GetFCHosts(host)
+	assert.NoError(t, err)
+	assert.Equal(t, []FCHost{
+		{Name: "host7", PortName: "0x10000090fa5b1c2d", NodeName: "0x20000090fa5b1c2d", PortState: "Online", Speed: "16 Gbit"},
+		{Name: "host8", PortName: "0x10000090fa5b1c2e", PortState: "Linkdown"},
+	}, fcHosts)
+
+	fcHosts, err = GetFCHosts(NewReplayHost(NewSnapshot()))
+	assert.NoError(t, err)
+	assert.Empty(t, fcHosts)
+}
+
+func TestGetISCSIInitiatorName(t *testing.T) {
+	testcases := []struct {
+		label    string
+		files    map[string][]byte
+		expected string
+	}{
+		{
+			label: "case 1", // initiator name after a comment
+			files: map[string][]byte{
+				ISCSIInitiatorNameFile: []byte("## DO NOT EDIT OR REMOVE THIS FILE!\nInitiatorName=iqn.1994-05.com.redhat:5e6f7a8b9c0d\n"),
+			},
+			expected: "iqn.1994-05.com.redhat:5e6f7a8b9c0d",
+		},
+		{
+			label: "case 2", // iSCSI is not configured
+			files: map[string][]byte{},
+		},
+	}
+
+	for _, tc := range testcases {
+		name, err := GetISCSIInitiatorName(NewReplayHost(&Snapshot{HostFiles: tc.files}))
+		assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		assert.Equalf(t, tc.expected, name, "[%s] invalid initiator name", tc.label)
+	}
+}
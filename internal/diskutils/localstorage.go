@@ -0,0 +1,28 @@
+package diskutils
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// LocalStorageDir is where the Local Storage Operator links the devices of its PVs, one directory per storage class
+const LocalStorageDir = "/mnt/local-storage/"
+
+// GetLocalStorageLinks returns the Local Storage Operator symlinks keyed by the kernel name of the device they resolve to
+func GetLocalStorageLinks(host Host) (map[string]string, error) {
+	links, err := host.Glob(filepath.Join(LocalStorageDir, "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing files in %s: %v", LocalStorageDir, err)
+	}
+
+	devices := map[string]string{}
+	for _, link := range links {
+		devPath, err := host.EvalSymlinks(link)
+		if err != nil {
+			// dangling links are left behind by LSO when a device goes away
+			continue
+		}
+		devices[filepath.Base(devPath)] = link
+	}
+	return devices, nil
+}
@@ -0,0 +1,334 @@
+package diskutils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SysDevBlockDir is the sysfs directory with symlinks to block devices by major:minor number
+	SysDevBlockDir = "/sys/dev/block/"
+
+	// PathStateActive is a healthy path in the active path group
+	PathStateActive = "active"
+	// PathStateFailed is a path that device-mapper has marked as failed
+	PathStateFailed = "failed"
+	// PathStateGhost is a healthy path that sits in a standby path group
+	PathStateGhost = "ghost"
+
+	dmPathGroupActive = "A"
+	dmPathFailed      = "F"
+)
+
+// MultipathMap is a device-mapper multipath map as reported by `dmsetup table` and `dmsetup status`
+type MultipathMap struct {
+	// Name of the map, for eg. mpatha
+	Name string
+	// KName of the dm device backing the map, for eg. dm-0
+	KName      string
+	PathGroups []MultipathPathGroup
+}
+
+// MultipathPathGroup is a priority group of paths inside a multipath map
+type MultipathPathGroup struct {
+	// Selector is the path selector with its arguments, for eg. "service-time"
+	Selector string
+	// State is the device-mapper state of the group: A(ctive), E(nabled) or D(isabled)
+	State string
+	Paths []MultipathPath
+}
+
+// MultipathPath is a single path of a multipath map
+type MultipathPath struct {
+	// DevT is the major:minor number of the path device
+	DevT string
+	// Name is the kernel name of the path device, for eg. sdc
+	Name string
+	// HCTL is the SCSI address of the path device
+	HCTL string
+	// State is one of PathStateActive, PathStateFailed or PathStateGhost
+	State string
+}
+
+// HostAdapter returns the SCSI host the path is attached to, derived from its HCTL
+func (p MultipathPath) HostAdapter() string {
+	if p.HCTL == "" {
+		return ""
+	}
+	return "host" + strings.SplitN(p.HCTL, ":", 2)[0]
+}
+
+// GetMultipathMaps returns the multipath maps present on the node keyed by their dm kernel name
+func GetMultipathMaps(host Host) (map[string]MultipathMap, error) {
+	table, err := executeCmdWithCombinedOutput(host.Execute("dmsetup", "table", "--target", "multipath"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run dmsetup table: %v, output: %s", err, table)
+	}
+	status, err := executeCmdWithCombinedOutput(host.Execute("dmsetup", "status", "--target", "multipath"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run dmsetup status: %v, output: %s", err, status)
+	}
+
+	maps, err := parseMultipathMaps(table, status)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]MultipathMap, len(maps))
+	for _, m := range maps {
+		dmPath, err := host.EvalSymlinks(filepath.Join(DiskDMDir, m.Name))
+		if err != nil {
+			klog.Warningf("failed to resolve multipath map %q: %v", m.Name, err)
+			continue
+		}
+		m.KName = filepath.Base(dmPath)
+		for i := range m.PathGroups {
+			for j := range m.PathGroups[i].Paths {
+				resolveMultipathPath(host, &m.PathGroups[i].Paths[j])
+			}
+		}
+		result[m.KName] = m
+	}
+
+	return result, nil
+}
+
+// resolveMultipathPath fills in the kernel name and SCSI address of a path using sysfs
+func resolveMultipathPath(host Host, p *MultipathPath) {
+	devPath, err := host.EvalSymlinks(filepath.Join(SysDevBlockDir, p.DevT))
+	if err != nil {
+		klog.Warningf("failed to resolve multipath path %q: %v", p.DevT, err)
+		p.Name = p.DevT
+		return
+	}
+	p.Name = filepath.Base(devPath)
+
+	// for SCSI devices the device link points to the H:C:T:L directory
+	scsiPath, err := host.EvalSymlinks(filepath.Join(SysDevBlockDir, p.DevT, "device"))
+	if err == nil && strings.Count(filepath.Base(scsiPath), ":") == 3 {
+		p.HCTL = filepath.Base(scsiPath)
+	}
+}
+
+// parseMultipathMaps combines the output of `dmsetup table` and `dmsetup status` for multipath targets.
+// Sample output format
+// `mpatha: 0 104857600 multipath 1 queue_if_no_path 0 2 1 service-time 0 1 1 8:32 1 service-time 0 1 1 8:80 1`
+// `mpatha: 0 104857600 multipath 2 0 0 0 2 1 A 0 1 2 8:32 A 0 0 1 E 0 1 2 8:80 A 0 0 1`
+func parseMultipathMaps(table, status string) ([]MultipathMap, error) {
+	maps := []MultipathMap{}
+	for name, fields := range splitDMOutput(table) {
+		groups, err := parseMultipathTable(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dmsetup table of %q: %w", name, err)
+		}
+		maps = append(maps, MultipathMap{Name: name, PathGroups: groups})
+	}
+
+	statuses := splitDMOutput(status)
+	for i := range maps {
+		fields, ok := statuses[maps[i].Name]
+		if !ok {
+			continue
+		}
+		if err := applyMultipathStatus(maps[i].PathGroups, fields); err != nil {
+			return nil, fmt.Errorf("failed to parse dmsetup status of %q: %w", maps[i].Name, err)
+		}
+	}
+
+	return maps, nil
+}
+
+// splitDMOutput returns the target parameters of each line of dmsetup output keyed by map name
+func splitDMOutput(output string) map[string][]string {
+	result := map[string][]string{}
+	for _, line := range strings.Split(output, "\n") {
+		name, params, found := strings.Cut(line, ": ")
+		if !found {
+			// "No devices found"
+			continue
+		}
+		fields := strings.Fields(params)
+		// <start> <length> multipath <params...>
+		if len(fields) < 3 || fields[2] != "multipath" {
+			continue
+		}
+		result[name] = fields[3:]
+	}
+	return result
+}
+
+// dmFields walks over the space separated parameters of a device-mapper target
+type dmFields struct {
+	fields []string
+	pos    int
+}
+
+func (d *dmFields) next() (string, error) {
+	if d.pos >= len(d.fields) {
+		return "", fmt.Errorf("unexpected end of parameters %q", strings.Join(d.fields, " "))
+	}
+	d.pos++
+	return d.fields[d.pos-1], nil
+}
+
+func (d *dmFields) nextInt() (int, error) {
+	s, err := d.next()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
+
+// skipCounted skips a "<#args> <args...>" block and returns the skipped args
+func (d *dmFields) skipCounted() ([]string, error) {
+	n, err := d.nextInt()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{}
+	for i := 0; i < n; i++ {
+		arg, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// parseMultipathTable parses the table line of a multipath target:
+// <#features> <features...> <#hw_handler args> <hw_handler...> <#groups> <initial group>
+// and for each group: <selector> <#selector args> <args...> <#paths> <#path args> [<dev> <path args...>]...
+func parseMultipathTable(fields []string) ([]MultipathPathGroup, error) {
+	d := &dmFields{fields: fields}
+	if _, err := d.skipCounted(); err != nil {
+		return nil, err
+	}
+	if _, err := d.skipCounted(); err != nil {
+		return nil, err
+	}
+	numGroups, err := d.nextInt()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.next(); err != nil {
+		return nil, err
+	}
+
+	groups := make([]MultipathPathGroup, 0, numGroups)
+	for g := 0; g < numGroups; g++ {
+		selector, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		selectorArgs, err := d.skipCounted()
+		if err != nil {
+			return nil, err
+		}
+		numPaths, err := d.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		numPathArgs, err := d.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		group := MultipathPathGroup{Selector: strings.Join(append([]string{selector}, selectorArgs...), " ")}
+		for p := 0; p < numPaths; p++ {
+			devT, err := d.next()
+			if err != nil {
+				return nil, err
+			}
+			for a := 0; a < numPathArgs; a++ {
+				if _, err := d.next(); err != nil {
+					return nil, err
+				}
+			}
+			group.Paths = append(group.Paths, MultipathPath{DevT: devT})
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// applyMultipathStatus parses the status line of a multipath target and sets the group and path states:
+// <#features> <features...> <#hw_handler args> <hw_handler...> <#groups> <next group>
+// and for each group: <state> <#group args> <args...> <#paths> <#selector args> [<dev> <A|F> <fail count> <selector args...>]...
+func applyMultipathStatus(groups []MultipathPathGroup, fields []string) error {
+	d := &dmFields{fields: fields}
+	if _, err := d.skipCounted(); err != nil {
+		return err
+	}
+	if _, err := d.skipCounted(); err != nil {
+		return err
+	}
+	numGroups, err := d.nextInt()
+	if err != nil {
+		return err
+	}
+	if numGroups != len(groups) {
+		return fmt.Errorf("status reports %d path groups, table has %d", numGroups, len(groups))
+	}
+	if _, err := d.next(); err != nil {
+		return err
+	}
+
+	for g := range groups {
+		state, err := d.next()
+		if err != nil {
+			return err
+		}
+		if _, err := d.skipCounted(); err != nil {
+			return err
+		}
+		numPaths, err := d.nextInt()
+		if err != nil {
+			return err
+		}
+		numSelectorArgs, err := d.nextInt()
+		if err != nil {
+			return err
+		}
+		groups[g].State = state
+		for p := 0; p < numPaths; p++ {
+			devT, err := d.next()
+			if err != nil {
+				return err
+			}
+			pathState, err := d.next()
+			if err != nil {
+				return err
+			}
+			// fail count followed by the selector args
+			for a := 0; a < numSelectorArgs+1; a++ {
+				if _, err := d.next(); err != nil {
+					return err
+				}
+			}
+			setPathState(&groups[g], devT, pathState)
+		}
+	}
+
+	return nil
+}
+
+func setPathState(group *MultipathPathGroup, devT, dmState string) {
+	for i := range group.Paths {
+		if group.Paths[i].DevT != devT {
+			continue
+		}
+		switch {
+		case dmState == dmPathFailed:
+			group.Paths[i].State = PathStateFailed
+		case group.State == dmPathGroupActive:
+			group.Paths[i].State = PathStateActive
+		default:
+			group.Paths[i].State = PathStateGhost
+		}
+	}
+}
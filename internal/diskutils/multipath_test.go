@@ -0,0 +1,109 @@
+//nolint:lll
+package diskutils
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	dmsetupTableFailover   = `mpatha: 0 104857600 multipath 1 queue_if_no_path 1 alua 2 1 service-time 0 1 1 8:32 1 service-time 0 1 1 8:80 1`
+	dmsetupStatusFailover  = `mpatha: 0 104857600 multipath 2 0 0 1 alua 2 1 A 0 1 2 8:32 A 0 0 1 E 0 1 2 8:80 A 0 0 1`
+	dmsetupTableMultibus   = `mpathb: 0 209715200 multipath 0 0 1 1 round-robin 0 2 1 8:48 1 8:96 1`
+	dmsetupStatusMultibus  = `mpathb: 0 209715200 multipath 2 0 0 0 1 1 A 0 2 0 8:48 A 0 8:96 F 3`
+	dmsetupNoDevicesOutput = `No devices found`
+)
+
+func TestParseMultipathMaps(t *testing.T) {
+	testcases := []struct {
+		label    string
+		table    string
+		status   string
+		expected []MultipathMap
+		hasError bool
+	}{
+		{
+			label:  "case 1", // failover with a standby path group
+			table:  dmsetupTableFailover,
+			status: dmsetupStatusFailover,
+			expected: []MultipathMap{
+				{
+					Name: "mpatha",
+					PathGroups: []MultipathPathGroup{
+						{Selector: "service-time", State: "A", Paths: []MultipathPath{{DevT: "8:32", State: PathStateActive}}},
+						{Selector: "service-time", State: "E", Paths: []MultipathPath{{DevT: "8:80", State: PathStateGhost}}},
+					},
+				},
+			},
+		},
+		{
+			label:  "case 2", // multibus with a failed path
+			table:  dmsetupTableMultibus,
+			status: dmsetupStatusMultibus,
+			expected: []MultipathMap{
+				{
+					Name: "mpathb",
+					PathGroups: []MultipathPathGroup{
+						{Selector: "round-robin", State: "A", Paths: []MultipathPath{{DevT: "8:48", State: PathStateActive}, {DevT: "8:96", State: PathStateFailed}}},
+					},
+				},
+			},
+		},
+		{
+			label:    "case 3", // no multipath devices
+			table:    dmsetupNoDevicesOutput,
+			status:   dmsetupNoDevicesOutput,
+			expected: []MultipathMap{},
+		},
+		{
+			label:    "case 4", // truncated table
+			table:    "mpatha: 0 104857600 multipath 0 0 2 1 service-time 0 1 1",
+			status:   dmsetupNoDevicesOutput,
+			hasError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		maps, err := parseMultipathMaps(tc.table, tc.status)
+		if tc.hasError {
+			assert.Errorf(t, err, "[%s] expected error", tc.label)
+			continue
+		}
+		assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		assert.Equalf(t, tc.expected, maps, "[%s] multipath maps didn't match", tc.label)
+	}
+}
+
+func TestGetMultipathMaps(t *testing.T) {
+	host := &MockHost{MockExecute: (&mockCmdExec{stdout: []string{dmsetupTableFailover, dmsetupStatusFailover}}).Execute}
+	host.MockEvalSymlinks = func(path string) (string, error) {
+		switch path {
+		case filepath.Join(DiskDMDir, "mpatha"):
+			return "/dev/dm-0", nil
+		case filepath.Join(SysDevBlockDir, "8:32"):
+			return "/sys/devices/pci0000:00/0000:00:10.0/host2/target2:0:0/2:0:0:1/block/sdc", nil
+		case filepath.Join(SysDevBlockDir, "8:32", "device"):
+			return "/sys/devices/pci0000:00/0000:00:10.0/host2/target2:0:0/2:0:0:1", nil
+		case filepath.Join(SysDevBlockDir, "8:80"):
+			return "/sys/devices/pci0000:00/0000:00:11.0/host3/target3:0:0/3:0:0:1/block/sdf", nil
+		case filepath.Join(SysDevBlockDir, "8:80", "device"):
+			return "/sys/devices/pci0000:00/0000:00:11.0/host3/target3:0:0/3:0:0:1", nil
+		}
+		return "", fmt.Errorf("unexpected path %q", path)
+	}
+
+	maps, err := GetMultipathMaps(host)
+	assert.NoError(t, err)
+	assert.Len(t, maps, 1)
+	m, ok := maps["dm-0"]
+	assert.True(t, ok)
+	assert.Equal(t, "mpatha", m.Name)
+	assert.Equal(t, "sdc", m.PathGroups[0].Paths[0].Name)
+	assert.Equal(t, "2:0:0:1", m.PathGroups[0].Paths[0].HCTL)
+	assert.Equal(t, "host2", m.PathGroups[0].Paths[0].HostAdapter())
+	assert.Equal(t, "sdf", m.PathGroups[1].Paths[0].Name)
+	assert.Equal(t, "host3", m.PathGroups[1].Paths[0].HostAdapter())
+}
@@ -0,0 +1,59 @@
+package diskutils
+
+import (
+	"bytes"
+)
+
+const (
+	// GPFSPartitionType is the GPT partition type GUID used by Storage Scale for NSD partitions
+	GPFSPartitionType = "37affc90-ef7d-4e96-91c3-2d7ae055b174"
+
+	// nsdHeaderSize is the amount of data read from the start of a device to look for an NSD descriptor
+	nsdHeaderSize = 8 * 1024
+	// nsdDescOffset is the offset of the NSD descriptor written by mmcrnsd. Storage Scale writes the NSD
+	// volume ID in sector 2 of the disk, see "NSD disk discovery" in the IBM Storage Scale documentation
+	nsdDescOffset = 512
+	// nsdDescMagic marks the start of the NSD descriptor
+	nsdDescMagic = "NSD desc"
+)
+
+// HasNSDDescriptor tells whether a device header holds an NSD descriptor at its offset.
+// The magic found elsewhere, like in the data of a file, is not a descriptor.
+// The layout of the descriptor past its magic is not documented, so the NSD is not identified.
+func HasNSDDescriptor(header []byte) bool {
+	start := nsdDescOffset
+	return len(header) >= start+len(nsdDescMagic) && bytes.Equal(header[start:start+len(nsdDescMagic)], []byte(nsdDescMagic))
+}
+
+// IsStorageScaleOwned tells whether the device or one of its partitions holds an NSD descriptor, or
+// whether the device has a GPFS partition. The device is owned when it has a GPFS partition even if
+// the error tells that its descriptor could not be read.
+func (b BlockDevice) IsStorageScaleOwned(host Host) (bool, error) {
+	candidates := []BlockDevice{b}
+	hasGPFSPartition := false
+	for _, child := range b.Children {
+		if child.PartType == GPFSPartitionType {
+			hasGPFSPartition = true
+			candidates = append(candidates, child)
+		}
+	}
+
+	var readErr error
+	for _, candidate := range candidates {
+		path, err := candidate.GetDevPath()
+		if err != nil {
+			readErr = err
+			continue
+		}
+		header, err := host.ReadDeviceHeader(path, nsdHeaderSize)
+		if err != nil {
+			readErr = err
+			continue
+		}
+		if HasNSDDescriptor(header) {
+			return true, nil
+		}
+	}
+
+	return hasGPFSPartition, readErr
+}
@@ -0,0 +1,99 @@
+package diskutils
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildNSDHeader returns a device header with an NSD descriptor in the second sector
+func buildNSDHeader() []byte {
+	header := make([]byte, nsdHeaderSize)
+	copy(header[nsdDescOffset:], nsdDescMagic)
+	return header
+}
+
+func TestHasNSDDescriptor(t *testing.T) {
+	testcases := []struct {
+		label    string
+		header   []byte
+		expected bool
+	}{
+		{
+			label:    "case 1", // descriptor in the second sector
+			header:   buildNSDHeader(),
+			expected: true,
+		},
+		{
+			label:  "case 2", // zeroed header
+			header: make([]byte, nsdHeaderSize),
+		},
+		{
+			label:    "case 3", // truncated header only holds the magic
+			header:   append(make([]byte, nsdDescOffset), nsdDescMagic...),
+			expected: true,
+		},
+		{
+			label:  "case 4", // the magic outside of the descriptor sector, like in the data of a file
+			header: append(make([]byte, 4096), buildNSDHeader()...),
+		},
+		{
+			label:  "case 5", // header shorter than the descriptor offset
+			header: []byte(nsdDescMagic),
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.Equalf(t, tc.expected, HasNSDDescriptor(tc.header), "[%s] invalid descriptor detection", tc.label)
+	}
+}
+
+func TestIsStorageScaleOwned(t *testing.T) {
+	lsblkGPFS, err := os.ReadFile("../../test/data/gpfs-json.txt")
+	assert.NoError(t, err)
+	host := &MockHost{MockExecute: (&mockCmdExec{stdout: []string{"", string(lsblkGPFS)}}).Execute}
+	blockDevices, _, err := ListBlockDevices(host, []string{})
+	assert.NoError(t, err)
+
+	devices := map[string]BlockDevice{}
+	for _, dev := range blockDevices {
+		devices[dev.Name] = dev
+	}
+	assert.Equal(t, GPFSPartitionType, devices["sdd"].Children[0].PartType)
+
+	headers := map[string][]byte{
+		"/dev/sdd1": buildNSDHeader(),
+		"/dev/sdf":  buildNSDHeader(),
+	}
+	host.MockReadDeviceHeader = func(path string, size int) ([]byte, error) {
+		if header, ok := headers[path]; ok {
+			return header, nil
+		}
+		return make([]byte, size), nil
+	}
+
+	// descriptor on the GPFS partition
+	owned, err := devices["sdd"].IsStorageScaleOwned(host)
+	assert.NoError(t, err)
+	assert.True(t, owned)
+
+	// plain disk
+	owned, err = devices["sde"].IsStorageScaleOwned(host)
+	assert.NoError(t, err)
+	assert.False(t, owned)
+
+	// descriptor on a disk without a partition table
+	owned, err = BlockDevice{Name: "sdf", KName: "sdf"}.IsStorageScaleOwned(host)
+	assert.NoError(t, err)
+	assert.True(t, owned)
+
+	// GPFS partition without a readable descriptor
+	host.MockReadDeviceHeader = func(path string, size int) ([]byte, error) {
+		return nil, fmt.Errorf("permission denied")
+	}
+	owned, err = devices["sdd"].IsStorageScaleOwned(host)
+	assert.Error(t, err)
+	assert.True(t, owned)
+}
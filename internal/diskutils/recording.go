@@ -0,0 +1,117 @@
+package diskutils
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+)
+
+// RecordingHost is a Host that records in a Snapshot everything read from the Host it wraps,
+// so the same reads can be replayed later with a ReplayHost
+type RecordingHost struct {
+	host     Host
+	snapshot *Snapshot
+}
+
+var _ Host = &RecordingHost{}
+
+// NewRecordingHost returns a Host that records the reads from host
+func NewRecordingHost(host Host) *RecordingHost {
+	return &RecordingHost{host: host, snapshot: NewSnapshot()}
+}
+
+// Snapshot returns what has been recorded so far
+func (h *RecordingHost) Snapshot() *Snapshot {
+	return h.snapshot
+}
+
+// recordingCommand records the output of a command when it runs
+type recordingCommand struct {
+	cmd      Command
+	key      string
+	snapshot *Snapshot
+}
+
+func (c recordingCommand) CombinedOutput() ([]byte, error) {
+	output, err := c.cmd.CombinedOutput()
+	// a command that could not be started, for eg. because it is not installed, is left out
+	// of the snapshot so that it fails the same way on replay
+	var exitErr *exec.ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		c.snapshot.Commands[c.key] = string(output)
+	}
+	return output, err
+}
+
+// Execute runs the command on the wrapped host and records its output
+func (h *RecordingHost) Execute(name string, args ...string) Command {
+	return recordingCommand{
+		cmd:      h.host.Execute(name, args...),
+		key:      CommandKey(name, args...),
+		snapshot: h.snapshot,
+	}
+}
+
+// Glob lists the files on the wrapped host and records where they resolve to
+func (h *RecordingHost) Glob(pattern string) ([]string, error) {
+	matches, err := h.host.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		if target, err := h.host.EvalSymlinks(match); err == nil {
+			h.snapshot.Symlinks[match] = target
+		}
+	}
+	return matches, nil
+}
+
+// EvalSymlinks resolves the path on the wrapped host and records the result
+func (h *RecordingHost) EvalSymlinks(path string) (string, error) {
+	target, err := h.host.EvalSymlinks(path)
+	if err == nil {
+		h.snapshot.Symlinks[path] = target
+	}
+	return target, err
+}
+
+// MountInfo reads the mountinfo of the wrapped host and records it
+func (h *RecordingHost) MountInfo() ([]byte, error) {
+	data, err := h.host.MountInfo()
+	if err == nil {
+		h.snapshot.MountInfo = string(data)
+	}
+	return data, err
+}
+
+// CanOpenExclusively checks the device on the wrapped host and records it when it is busy
+func (h *RecordingHost) CanOpenExclusively(path string) (bool, error) {
+	canOpen, err := h.host.CanOpenExclusively(path)
+	if err == nil && !canOpen {
+		for _, busy := range h.snapshot.BusyDevices {
+			if busy == path {
+				return canOpen, err
+			}
+		}
+		h.snapshot.BusyDevices = append(h.snapshot.BusyDevices, path)
+	}
+	return canOpen, err
+}
+
+// ReadDeviceHeader reads the device header on the wrapped host and records it
+func (h *RecordingHost) ReadDeviceHeader(path string, size int) ([]byte, error) {
+	header, err := h.host.ReadDeviceHeader(path, size)
+	if err == nil {
+		h.snapshot.Headers[path] = header
+	}
+	return header, err
+}
+
+// ReadFile reads the file on the wrapped host and records its content
+func (h *RecordingHost) ReadFile(path string) ([]byte, error) {
+	data, err := h.host.ReadFile(path)
+	if err == nil {
+		h.snapshot.HostFiles[filepath.Clean(path)] = data
+	}
+	return data, err
+}
@@ -0,0 +1,107 @@
+package diskutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ReplayHost is a Host that serves a Snapshot
+type ReplayHost struct {
+	snapshot *Snapshot
+}
+
+var _ Host = &ReplayHost{}
+
+// NewReplayHost returns a Host that serves the snapshot
+func NewReplayHost(snapshot *Snapshot) *ReplayHost {
+	return &ReplayHost{snapshot: snapshot}
+}
+
+// NewReplayHostFromPath returns a Host that serves the snapshot stored in a directory or a tarball
+func NewReplayHostFromPath(path string) (*ReplayHost, error) {
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot %s: %w", path, err)
+	}
+	return NewReplayHost(snapshot), nil
+}
+
+// replayCommand returns the recorded output of a command
+type replayCommand struct {
+	output string
+	err    error
+}
+
+func (c replayCommand) CombinedOutput() ([]byte, error) {
+	return []byte(c.output), c.err
+}
+
+// Execute returns the recorded output of the command
+func (h *ReplayHost) Execute(name string, args ...string) Command {
+	key := CommandKey(name, args...)
+	output, ok := h.snapshot.Commands[key]
+	if !ok {
+		return replayCommand{err: fmt.Errorf("command %q not found in snapshot", key)}
+	}
+	return replayCommand{output: output}
+}
+
+// Glob returns the recorded symlinks matching pattern
+func (h *ReplayHost) Glob(pattern string) ([]string, error) {
+	matches := []string{}
+	for link := range h.snapshot.Symlinks {
+		match, err := filepath.Match(pattern, link)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matches = append(matches, link)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// EvalSymlinks returns the recorded resolution of a symlink
+func (h *ReplayHost) EvalSymlinks(path string) (string, error) {
+	target, ok := h.snapshot.Symlinks[filepath.Clean(path)]
+	if !ok {
+		return "", &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+	}
+	return target, nil
+}
+
+// MountInfo returns the recorded mountinfo
+func (h *ReplayHost) MountInfo() ([]byte, error) {
+	return []byte(h.snapshot.MountInfo), nil
+}
+
+// CanOpenExclusively returns false for the devices recorded as busy
+func (h *ReplayHost) CanOpenExclusively(path string) (bool, error) {
+	for _, busy := range h.snapshot.BusyDevices {
+		if busy == path {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ReadDeviceHeader returns the recorded header of a device, truncated to size
+func (h *ReplayHost) ReadDeviceHeader(path string, size int) ([]byte, error) {
+	header := h.snapshot.Headers[path]
+	if len(header) > size {
+		header = header[:size]
+	}
+	return header, nil
+}
+
+// ReadFile returns the recorded content of a file
+func (h *ReplayHost) ReadFile(path string) ([]byte, error) {
+	data, ok := h.snapshot.HostFiles[filepath.Clean(path)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+package diskutils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandKey(t *testing.T) {
+	testcases := []struct {
+		label    string
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			label:    "Case 1: command with flags",
+			name:     "lsblk",
+			args:     []string{"--json", "-b", "-o", "NAME"},
+			expected: "lsblk",
+		},
+		{
+			label:    "Case 2: command with a subcommand",
+			name:     "dmsetup",
+			args:     []string{"table", "--target", "multipath"},
+			expected: "dmsetup_table",
+		},
+		{
+			label:    "Case 3: command without args",
+			name:     "blkid",
+			expected: "blkid",
+		},
+	}
+
+	for _, tc := range testcases {
+		assert.Equalf(t, tc.expected, CommandKey(tc.name, tc.args...), "[%s] invalid command key", tc.label)
+	}
+}
+
+func TestReplayHost(t *testing.T) {
+	host, err := NewReplayHostFromPath("../../test/data/snapshots/mpath-node")
+	assert.NoError(t, err)
+
+	blockDevices, _, err := ListBlockDevices(host, []string{})
+	assert.NoError(t, err)
+	assert.Len(t, blockDevices, 9)
+	assert.Equal(t, "mpath_member", blockDevices[2].FSType)
+
+	// by-id symlinks
+	pathByID, err := blockDevices[0].GetPathByID(host, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/disk/by-id/wwn-0x5000c50015ea7599", pathByID)
+	_, err = host.EvalSymlinks("/dev/disk/by-id/missing")
+	assert.True(t, os.IsNotExist(err))
+
+	// mount points
+	hasBindMounts, mountPoint, err := (&BlockDevice{KName: "sdb4"}).HasBindMounts(host)
+	assert.NoError(t, err)
+	assert.True(t, hasBindMounts)
+	assert.Equal(t, "/", mountPoint)
+
+	// busy devices
+	canOpen, err := host.CanOpenExclusively("/dev/sdb")
+	assert.NoError(t, err)
+	assert.False(t, canOpen)
+	canOpen, err = host.CanOpenExclusively("/dev/sda")
+	assert.NoError(t, err)
+	assert.True(t, canOpen)
+
+	// multipath maps and their paths
+	maps, err := GetMultipathMaps(host)
+	assert.NoError(t, err)
+	assert.Len(t, maps, 3)
+	assert.Equal(t, "mpatha", maps["dm-0"].Name)
+	assert.Equal(t, "sdf", maps["dm-0"].PathGroups[0].Paths[1].Name)
+	assert.Equal(t, "8:0:0:0", maps["dm-0"].PathGroups[0].Paths[1].HCTL)
+
+	// commands that were not captured fail
+	_, err = host.Execute("multipathd", "show", "maps").CombinedOutput()
+	assert.Error(t, err)
+}
+
+func TestGetLocalStorageLinks(t *testing.T) {
+	host := NewReplayHost(&Snapshot{
+		Symlinks: map[string]string{
+			"/mnt/local-storage/local-sc/wwn-0x5000c500155a3457": "/dev/sdb",
+			"/mnt/local-storage/block-sc/dm-name-mpathb":         "/dev/dm-1",
+			"/dev/disk/by-id/wwn-0x5000c500155a3456":             "/dev/sda",
+		},
+	})
+
+	links, err := GetLocalStorageLinks(host)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"sdb":  "/mnt/local-storage/local-sc/wwn-0x5000c500155a3457",
+		"dm-1": "/mnt/local-storage/block-sc/dm-name-mpathb",
+	}, links)
+}
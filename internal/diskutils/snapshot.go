@@ -0,0 +1,279 @@
+package diskutils
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layout of a snapshot directory or tarball
+const (
+	// SnapshotCommandsDir holds the output of each command in <CommandKey>.out
+	SnapshotCommandsDir = "commands"
+	// SnapshotSymlinksFile lists the symlinks of the node, one "<link> -> <resolved path>" per line
+	SnapshotSymlinksFile = "symlinks"
+	// SnapshotMountInfoFile is a copy of MountInfoFile
+	SnapshotMountInfoFile = "mountinfo"
+	// SnapshotBusyFile lists the devices that could not be opened exclusively, one path per line
+	SnapshotBusyFile = "busy"
+	// SnapshotHeadersDir holds the first bytes of the devices in a file named after their kernel name
+	SnapshotHeadersDir = "headers"
+	// SnapshotUdevDir holds the udev properties of the devices in a file named after their kernel name
+	SnapshotUdevDir = "udev"
+	// SnapshotHostFilesDir holds the files read on the node at their path on the node
+	SnapshotHostFilesDir = "files"
+
+	snapshotCommandSuffix = ".out"
+	snapshotSymlinkSep    = " -> "
+)
+
+// Snapshot is what the discovery sees of a node
+type Snapshot struct {
+	// Commands holds the output of the commands keyed by CommandKey
+	Commands map[string]string
+	// Symlinks maps the symlinks of the node to the path they resolve to
+	Symlinks map[string]string
+	// MountInfo is the content of MountInfoFile
+	MountInfo string
+	// BusyDevices are the paths of the devices that could not be opened exclusively
+	BusyDevices []string
+	// Headers holds the first bytes of the devices keyed by device path
+	Headers map[string][]byte
+	// Udev holds the udev properties of the devices keyed by kernel name
+	Udev map[string]string
+	// HostFiles holds the content of the files read on the node keyed by their absolute path
+	HostFiles map[string][]byte
+}
+
+// CommandKey identifies a command in a Snapshot: its name followed by its subcommand, if any.
+// For eg, "lsblk" or "dmsetup_table"
+func CommandKey(name string, args ...string) string {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return name + "_" + args[0]
+	}
+	return name
+}
+
+// NewSnapshot returns an empty Snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		Commands:  map[string]string{},
+		Symlinks:  map[string]string{},
+		Headers:   map[string][]byte{},
+		Udev:      map[string]string{},
+		HostFiles: map[string][]byte{},
+	}
+}
+
+// Files returns the content of the snapshot keyed by the relative path it is stored at
+func (s *Snapshot) Files() map[string][]byte {
+	files := map[string][]byte{}
+	for key, output := range s.Commands {
+		files[path.Join(SnapshotCommandsDir, key+snapshotCommandSuffix)] = []byte(output)
+	}
+
+	links := make([]string, 0, len(s.Symlinks))
+	for link := range s.Symlinks {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	symlinks := &bytes.Buffer{}
+	for _, link := range links {
+		fmt.Fprintf(symlinks, "%s%s%s\n", link, snapshotSymlinkSep, s.Symlinks[link])
+	}
+	files[SnapshotSymlinksFile] = symlinks.Bytes()
+
+	files[SnapshotMountInfoFile] = []byte(s.MountInfo)
+
+	busy := &bytes.Buffer{}
+	for _, device := range s.BusyDevices {
+		fmt.Fprintln(busy, device)
+	}
+	files[SnapshotBusyFile] = busy.Bytes()
+
+	for device, header := range s.Headers {
+		files[path.Join(SnapshotHeadersDir, filepath.Base(device))] = header
+	}
+	for kname, properties := range s.Udev {
+		files[path.Join(SnapshotUdevDir, kname)] = []byte(properties)
+	}
+	for name, data := range s.HostFiles {
+		files[path.Join(SnapshotHostFilesDir, name)] = data
+	}
+
+	return files
+}
+
+// Save writes the snapshot to a directory
+func (s *Snapshot) Save(dir string) error {
+	for name, data := range s.Files() {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTarball writes the snapshot as a gzipped tarball
+func (s *Snapshot) WriteTarball(w io.Writer) error {
+	files := s.Files()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for _, name := range names {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(files[name])),
+			ModTime:  now,
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// LoadSnapshot reads a snapshot from a directory or from a tarball written by WriteTarball
+func LoadSnapshot(path string) (*Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files map[string][]byte
+	if info.IsDir() {
+		files, err = readSnapshotDir(path)
+	} else {
+		files, err = readSnapshotTarball(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseSnapshotFiles(files)
+}
+
+func readSnapshotDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = data
+		return nil
+	})
+	return files, err
+}
+
+func readSnapshotTarball(file string) (map[string][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = data
+	}
+}
+
+// parseSnapshotFiles builds a snapshot from its files keyed by relative path
+func parseSnapshotFiles(files map[string][]byte) (*Snapshot, error) {
+	s := NewSnapshot()
+	for name, data := range files {
+		dir, base := path.Split(name)
+		switch {
+		case dir == SnapshotCommandsDir+"/" && strings.HasSuffix(base, snapshotCommandSuffix):
+			s.Commands[strings.TrimSuffix(base, snapshotCommandSuffix)] = string(data)
+		case dir == SnapshotHeadersDir+"/":
+			s.Headers[path.Join("/dev", base)] = data
+		case dir == SnapshotUdevDir+"/":
+			s.Udev[base] = string(data)
+		case strings.HasPrefix(name, SnapshotHostFilesDir+"/"):
+			s.HostFiles[strings.TrimPrefix(name, SnapshotHostFilesDir)] = data
+		case name == SnapshotMountInfoFile:
+			s.MountInfo = string(data)
+		case name == SnapshotBusyFile:
+			s.BusyDevices = snapshotLines(data)
+		case name == SnapshotSymlinksFile:
+			for _, line := range snapshotLines(data) {
+				link, target, found := strings.Cut(line, snapshotSymlinkSep)
+				if !found {
+					return nil, fmt.Errorf("invalid line %q in %s", line, SnapshotSymlinksFile)
+				}
+				s.Symlinks[strings.TrimSpace(link)] = strings.TrimSpace(target)
+			}
+		}
+	}
+	return s, nil
+}
+
+// snapshotLines returns the non empty lines of a snapshot file
+func snapshotLines(data []byte) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+package diskutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotTarball(t *testing.T) {
+	snapshot := &Snapshot{
+		Commands: map[string]string{
+			"lsblk":         `{"blockdevices": []}`,
+			"dmsetup_table": "No devices found",
+		},
+		Symlinks: map[string]string{
+			"/dev/disk/by-id/wwn-0x5000c50015ea7599": "/dev/sda",
+		},
+		MountInfo:   "22 1 8:20 / / rw,relatime shared:1 - xfs /dev/sdb4 rw\n",
+		BusyDevices: []string{"/dev/sdb"},
+		Headers: map[string][]byte{
+			"/dev/sdd1": {0x4e, 0x53, 0x44, 0x00},
+		},
+		Udev: map[string]string{
+			"sda": "DEVNAME=/dev/sda\nID_BUS=scsi\n",
+		},
+		HostFiles: map[string][]byte{
+			"/sys/class/fc_host/host7/port_name": []byte("0x10000090fa5b1c2d\n"),
+		},
+	}
+
+	dir := t.TempDir()
+	tarball := filepath.Join(dir, "snapshot.tar.gz")
+	f, err := os.Create(tarball)
+	assert.NoError(t, err)
+	assert.NoError(t, snapshot.WriteTarball(f))
+	assert.NoError(t, f.Close())
+
+	loaded, err := LoadSnapshot(tarball)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, loaded)
+
+	// the same snapshot saved as a directory
+	assert.NoError(t, snapshot.Save(filepath.Join(dir, "snapshot")))
+	loaded, err = LoadSnapshot(filepath.Join(dir, "snapshot"))
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, loaded)
+}
+
+func TestRecordingHost(t *testing.T) {
+	live := &MockHost{
+		MockExecute: (&mockCmdExec{stdout: []string{blkIDOutput1, lsblkOutput2}}).Execute,
+		MockGlob: func(pattern string) ([]string, error) {
+			return []string{"/dev/disk/by-id/wwn-0x500a07512b9f5254", "/dev/disk/by-id/broken"}, nil
+		},
+		MockEvalSymlinks: func(path string) (string, error) {
+			if path == "/dev/disk/by-id/wwn-0x500a07512b9f5254" {
+				return "/dev/sdc", nil
+			}
+			return "", &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+		},
+		MockCanOpenExclusively: func(path string) (bool, error) {
+			return path != "/dev/sdc", nil
+		},
+	}
+	recorder := NewRecordingHost(live)
+
+	blockDevices, _, err := ListBlockDevices(recorder, []string{})
+	assert.NoError(t, err)
+	listed := append([]BlockDevice{}, blockDevices...)
+	pathByID, err := blockDevices[0].GetPathByID(recorder, "")
+	assert.NoError(t, err)
+	_, err = recorder.CanOpenExclusively("/dev/sdc")
+	assert.NoError(t, err)
+	_, err = recorder.CanOpenExclusively("/dev/sdc3")
+	assert.NoError(t, err)
+
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, map[string]string{"blkid": blkIDOutput1, "lsblk": lsblkOutput2}, snapshot.Commands)
+	assert.Equal(t, map[string]string{"/dev/disk/by-id/wwn-0x500a07512b9f5254": "/dev/sdc"}, snapshot.Symlinks)
+	assert.Equal(t, []string{"/dev/sdc"}, snapshot.BusyDevices)
+
+	// the replay sees the same node
+	replay := NewReplayHost(snapshot)
+	replayedDevices, _, err := ListBlockDevices(replay, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, listed, replayedDevices)
+	replayedPathByID, err := replayedDevices[0].GetPathByID(replay, "")
+	assert.NoError(t, err)
+	assert.Equal(t, pathByID, replayedPathByID)
+	canOpen, err := replay.CanOpenExclusively("/dev/sdc")
+	assert.NoError(t, err)
+	assert.False(t, canOpen)
+}
@@ -0,0 +1,69 @@
+package diskutils
+
+import (
+	"fmt"
+	"regexp"
+
+	"k8s.io/klog/v2"
+)
+
+// wipeHeaderSize is the amount of data zeroed at the start of a device, it covers the primary GPT
+// and the NSD descriptor that wipefs does not know about
+const wipeHeaderSize = "1M"
+
+// wipefsSignature matches the signature name in the wipefs output, for eg.
+// `/dev/sdb: 4 bytes were erased at offset 0x00000000 (xfs): 58 46 53 42`
+var wipefsSignature = regexp.MustCompile(`\(([^)]+)\):`)
+
+// WipeDevice erases the filesystem, RAID, partition table and NSD signatures of the device and of its
+// partitions. It returns the erased signatures as "<kname>: <signature>".
+func (b BlockDevice) WipeDevice(host Host) ([]string, error) {
+	wiped := []string{}
+	// the partitions go first, the partition table that locates them is erased with the device
+	for _, dev := range append(append([]BlockDevice{}, b.Children...), b) {
+		signatures, err := dev.wipeSignatures(host)
+		wiped = append(wiped, signatures...)
+		if err != nil {
+			return wiped, err
+		}
+	}
+
+	path, err := b.GetDevPath()
+	if err != nil {
+		return wiped, err
+	}
+	// the kernel keeps the old partitions around until the partition table is read again
+	if output, err := executeCmdWithCombinedOutput(host.Execute("blockdev", "--rereadpt", path)); err != nil {
+		klog.Warningf("failed to reread the partition table of %q: %v, output: %s", path, err, output)
+	}
+
+	return wiped, nil
+}
+
+// wipeSignatures erases the signatures found by wipefs and zeroes the start of the device
+func (b BlockDevice) wipeSignatures(host Host) ([]string, error) {
+	path, err := b.GetDevPath()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := executeCmdWithCombinedOutput(host.Execute("wipefs", "--all", "--force", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run wipefs on %q: %v, output: %s", path, err, output)
+	}
+	signatures := []string{}
+	for _, match := range wipefsSignature.FindAllStringSubmatch(output, -1) {
+		signature := fmt.Sprintf("%s: %s", b.KName, match[1])
+		if len(signatures) == 0 || signatures[len(signatures)-1] != signature {
+			signatures = append(signatures, signature)
+		}
+	}
+
+	output, err = executeCmdWithCombinedOutput(host.Execute("dd", "if=/dev/zero", "of="+path,
+		"bs="+wipeHeaderSize, "count=1", "oflag=direct", "conv=fsync"))
+	if err != nil {
+		return signatures, fmt.Errorf("failed to zero the header of %q: %v, output: %s", path, err, output)
+	}
+
+	return signatures, nil
+}
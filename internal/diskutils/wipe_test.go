@@ -0,0 +1,103 @@
+package diskutils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCommand returns a fixed output
+type fakeCommand struct {
+	output string
+	err    error
+}
+
+func (c fakeCommand) CombinedOutput() ([]byte, error) {
+	return []byte(c.output), c.err
+}
+
+func TestWipeDevice(t *testing.T) {
+	device := BlockDevice{
+		Name:  "sdb",
+		KName: "sdb",
+		Type:  "disk",
+		Children: []BlockDevice{
+			{Name: "sdb1", KName: "sdb1", Type: "part"},
+		},
+	}
+	wipefsOut := map[string]string{
+		"/dev/sdb1": "/dev/sdb1: 4 bytes were erased at offset 0x00000000 (xfs): 58 46 53 42",
+		"/dev/sdb": `/dev/sdb: 8 bytes were erased at offset 0x00000200 (gpt): 45 46 49 20 50 41 52 54
+/dev/sdb: 8 bytes were erased at offset 0x3bffffe00 (gpt): 45 46 49 20 50 41 52 54
+/dev/sdb: 2 bytes were erased at offset 0x000001fe (PMBR): 55 aa`,
+	}
+
+	testcases := []struct {
+		label            string
+		failing          string
+		expected         []string
+		expectedCommands []string
+		expectedErr      bool
+	}{
+		{
+			label:    "case 1", // partition and device are wiped
+			expected: []string{"sdb1: xfs", "sdb: gpt", "sdb: PMBR"},
+			expectedCommands: []string{
+				"wipefs --all --force /dev/sdb1",
+				"dd if=/dev/zero of=/dev/sdb1 bs=1M count=1 oflag=direct conv=fsync",
+				"wipefs --all --force /dev/sdb",
+				"dd if=/dev/zero of=/dev/sdb bs=1M count=1 oflag=direct conv=fsync",
+				"blockdev --rereadpt /dev/sdb",
+			},
+		},
+		{
+			label:    "case 2", // wipefs fails on the device, nothing else is run
+			failing:  "wipefs --all --force /dev/sdb",
+			expected: []string{"sdb1: xfs"},
+			expectedCommands: []string{
+				"wipefs --all --force /dev/sdb1",
+				"dd if=/dev/zero of=/dev/sdb1 bs=1M count=1 oflag=direct conv=fsync",
+				"wipefs --all --force /dev/sdb",
+			},
+			expectedErr: true,
+		},
+		{
+			label:    "case 3", // the partition table can not be read again
+			failing:  "blockdev --rereadpt /dev/sdb",
+			expected: []string{"sdb1: xfs", "sdb: gpt", "sdb: PMBR"},
+			expectedCommands: []string{
+				"wipefs --all --force /dev/sdb1",
+				"dd if=/dev/zero of=/dev/sdb1 bs=1M count=1 oflag=direct conv=fsync",
+				"wipefs --all --force /dev/sdb",
+				"dd if=/dev/zero of=/dev/sdb bs=1M count=1 oflag=direct conv=fsync",
+				"blockdev --rereadpt /dev/sdb",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		commands := []string{}
+		host := &MockHost{MockExecute: func(name string, args ...string) Command {
+			command := strings.Join(append([]string{name}, args...), " ")
+			commands = append(commands, command)
+			if command == tc.failing {
+				return fakeCommand{output: "device busy", err: errors.New("exit status 1")}
+			}
+			if name == "wipefs" {
+				return fakeCommand{output: wipefsOut[args[len(args)-1]]}
+			}
+			return fakeCommand{}
+		}}
+
+		wiped, err := device.WipeDevice(host)
+		if tc.expectedErr {
+			assert.Errorf(t, err, "[%s] expected an error", tc.label)
+		} else {
+			assert.NoErrorf(t, err, "[%s] unexpected error", tc.label)
+		}
+		assert.Equalf(t, tc.expected, wiped, "[%s] invalid wiped signatures", tc.label)
+		assert.Equalf(t, tc.expectedCommands, commands, "[%s] invalid commands", tc.label)
+	}
+}
@@ -18,14 +18,24 @@ package utils
 
 import (
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/validatedpatterns/purple-storage-rh-operator/files"
 )
 
-// Taken from https://www.ibm.com/docs/en/scalecontainernative/5.2.2?topic=planning-software-requirements
+// StorageScaleData are the requirements of an IBM Storage Scale Container Native version, as listed in
+// https://www.ibm.com/docs/en/scalecontainernative/5.2.2?topic=planning-software-requirements
 type StorageScaleData struct {
 	CSIVersion                string   `json:"csi_version"`
 	Architecture              []string `json:"architecture"`
@@ -34,32 +44,114 @@ type StorageScaleData struct {
 	OpenShiftLevels           []string `json:"openshift_levels"`
 }
 
-// The dict key is the IBM Storage Scale Container Native version
-var storageScaleTable = map[string]StorageScaleData{
-	"5.1.5.0": {"2.7.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "29.00", []string{"4.9", "4.10", "4.11"}},
-	"5.1.6.0": {"2.8.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "30.00", []string{"4.9", "4.10", "4.11"}},
-	"5.1.7.0": {"2.9.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "31.00", []string{"4.10", "4.11", "4.12"}},
-	"5.1.9.1": {"2.10.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.1.9.3": {"2.10.1", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.1.9.4": {"2.10.2", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.1.9.5": {"2.10.3", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.1.9.6": {"2.10.4", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.1.9.7": {"2.10.5", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "33.00", []string{"4.12", "4.13", "4.14"}},
-	"5.2.0.0": {"2.11.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "34.00", []string{"4.13", "4.14", "4.15"}},
-	"5.2.0.1": {"2.11.1", []string{"x86_64", "ppc64le", "s390x"}, "5.1.3.0+", "34.00", []string{"4.13", "4.14", "4.15"}},
-	"5.2.1.0": {"2.12.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.9.0+", "35.00", []string{"4.14", "4.15", "4.16"}},
-	"5.2.1.1": {"2.12.1", []string{"x86_64", "ppc64le", "s390x"}, "5.1.9.0+", "35.00", []string{"4.14", "4.15", "4.16"}},
-	"5.2.2.0": {"2.13.0", []string{"x86_64", "ppc64le", "s390x"}, "5.1.9.0+", "36.00", []string{"4.15", "4.16", "4.17"}},
+const (
+	// SupportMatrixSchemaVersion is the version of the format of the support.yaml files
+	SupportMatrixSchemaVersion = 1
+	// SupportMatrixFile is the support matrix data shipped next to the install.yaml of each version
+	SupportMatrixFile = "support.yaml"
+	// SupportMatrixConfigMap is the ConfigMap, in the namespace of the PurpleStorage, that overrides the support
+	// matrix. Each key is an IBM CNSA version and its value has the format of the support.yaml files.
+	SupportMatrixConfigMap = "purple-storage-support-matrix"
+)
+
+// supportMatrixData is the content of a support.yaml file
+type supportMatrixData struct {
+	SchemaVersion int `json:"schema_version"`
+	StorageScaleData
+}
+
+// SupportMatrix maps the IBM CNSA versions, without their leading "v", to their requirements
+type SupportMatrix map[string]StorageScaleData
+
+// embeddedSupportMatrix is the support matrix of the versions shipped in files/
+var embeddedSupportMatrix = mustLoadSupportMatrix(files.SupportMatrix)
+
+// ParseSupportMatrixData parses the content of a support.yaml file
+func ParseSupportMatrixData(content []byte) (StorageScaleData, error) {
+	data := supportMatrixData{}
+	if err := yaml.UnmarshalStrict(content, &data); err != nil {
+		return StorageScaleData{}, err
+	}
+	if data.SchemaVersion != SupportMatrixSchemaVersion {
+		return StorageScaleData{}, fmt.Errorf("unsupported schema_version %d, expected %d", data.SchemaVersion, SupportMatrixSchemaVersion)
+	}
+	if data.CSIVersion == "" || len(data.Architecture) == 0 || len(data.OpenShiftLevels) == 0 {
+		return StorageScaleData{}, fmt.Errorf("csi_version, architecture and openshift_levels are required")
+	}
+	for _, level := range data.OpenShiftLevels {
+		if _, err := semver.NewConstraint(fmt.Sprintf("~%s", level)); err != nil {
+			return StorageScaleData{}, fmt.Errorf("invalid OpenShift level %q: %w", level, err)
+		}
+	}
+	return data.StorageScaleData, nil
+}
+
+// LoadSupportMatrix reads the support.yaml of each version directory of fsys
+func LoadSupportMatrix(fsys fs.FS) (SupportMatrix, error) {
+	paths, err := fs.Glob(fsys, path.Join("*", SupportMatrixFile))
+	if err != nil {
+		return nil, err
+	}
+	matrix := SupportMatrix{}
+	for _, p := range paths {
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ParseSupportMatrixData(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+		matrix[strings.TrimPrefix(path.Dir(p), "v")] = data
+	}
+	return matrix, nil
 }
 
-func IsOpenShiftSupported(ibmStorageScaleVersion string, openShiftVersion semver.Version) bool {
-	// Strip the leading "v" from the IBM Storage Scale version
-	ibmVer := ibmStorageScaleVersion
-	if strings.HasPrefix(ibmStorageScaleVersion, "v") {
-		ibmVer = ibmStorageScaleVersion[1:] // Remove the first character
+func mustLoadSupportMatrix(fsys fs.FS) SupportMatrix {
+	matrix, err := LoadSupportMatrix(fsys)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded support matrix: %v", err))
 	}
+	return matrix
+}
+
+// WithOverrides returns a copy of the matrix where the versions of the data of a SupportMatrixConfigMap
+// are added or replaced
+func (m SupportMatrix) WithOverrides(overrides map[string]string) (SupportMatrix, error) {
+	matrix := maps.Clone(m)
+	for version, content := range overrides {
+		data, err := ParseSupportMatrixData([]byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version %s: %w", version, err)
+		}
+		matrix[strings.TrimPrefix(version, "v")] = data
+	}
+	return matrix, nil
+}
 
-	data, exists := storageScaleTable[ibmVer]
+// GetSupportMatrix returns the embedded support matrix with the overrides of the SupportMatrixConfigMap,
+// nil when it does not exist. The embedded matrix is never modified.
+func GetSupportMatrix(configMap *corev1.ConfigMap) (SupportMatrix, error) {
+	if configMap == nil {
+		return embeddedSupportMatrix.WithOverrides(nil)
+	}
+	matrix, err := embeddedSupportMatrix.WithOverrides(configMap.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ConfigMap %s/%s: %w", configMap.Namespace, configMap.Name, err)
+	}
+	return matrix, nil
+}
+
+// GetStorageScaleData returns the requirements of an IBM Storage Scale Container Native version,
+// with or without its leading "v"
+func (m SupportMatrix) GetStorageScaleData(ibmStorageScaleVersion string) (StorageScaleData, bool) {
+	data, exists := m[strings.TrimPrefix(ibmStorageScaleVersion, "v")]
+	return data, exists
+}
+
+// IsOpenShiftSupported returns true if the OpenShift version is in the levels of the IBM Storage Scale version
+func (m SupportMatrix) IsOpenShiftSupported(ibmStorageScaleVersion string, openShiftVersion semver.Version) bool {
+	data, exists := m.GetStorageScaleData(ibmStorageScaleVersion)
 	if !exists {
 		return false
 	}
@@ -77,6 +169,70 @@ func IsOpenShiftSupported(ibmStorageScaleVersion string, openShiftVersion semver
 	return false
 }
 
+// nodeArchitectureNames maps the architectures reported by the nodes to the names of the support matrix
+var nodeArchitectureNames = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+}
+
+// GetInstallManifestPath returns the install.yaml of an IBM CNSA version, looked up relative to the
+// working directory first and then from the root of the operator image
+func GetInstallManifestPath(ibmCnsaVersion string) (string, error) {
+	installPath := fmt.Sprintf("files/%s/install.yaml", ibmCnsaVersion)
+	_, err := os.Stat(installPath)
+	if os.IsNotExist(err) {
+		installPath = fmt.Sprintf("/%s", installPath)
+		_, err = os.Stat(installPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	return installPath, nil
+}
+
+// GetSupportIssues returns the reasons why an IBM CNSA version is not supported on the cluster according to the
+// support matrix: a version without install manifest or support matrix data, an OpenShift version outside of its levels or nodes of an
+// architecture it does not support. nodeArchitectures maps the node names to the architecture of their status.
+// The result is empty when the combination is supported.
+func GetSupportIssues(matrix SupportMatrix, ibmCnsaVersion string, openShiftVersion *semver.Version,
+	nodeArchitectures map[string]string) []string {
+	issues := []string{}
+	if _, err := GetInstallManifestPath(ibmCnsaVersion); err != nil {
+		issues = append(issues, fmt.Sprintf("IBM CNSA version %q has no files/%s/install.yaml", ibmCnsaVersion, ibmCnsaVersion))
+	}
+	data, exists := matrix.GetStorageScaleData(ibmCnsaVersion)
+	if !exists {
+		return append(issues, fmt.Sprintf("IBM CNSA version %q is not in the support matrix", ibmCnsaVersion))
+	}
+
+	if openShiftVersion != nil && !matrix.IsOpenShiftSupported(ibmCnsaVersion, *openShiftVersion) {
+		issues = append(issues, fmt.Sprintf("IBM CNSA version %s does not support OpenShift %s, only %s",
+			ibmCnsaVersion, openShiftVersion, strings.Join(data.OpenShiftLevels, ", ")))
+	}
+
+	unsupported := map[string][]string{}
+	for node, architecture := range nodeArchitectures {
+		if name, ok := nodeArchitectureNames[architecture]; ok {
+			architecture = name
+		}
+		if !slices.Contains(data.Architecture, architecture) {
+			unsupported[architecture] = append(unsupported[architecture], node)
+		}
+	}
+	architectures := make([]string, 0, len(unsupported))
+	for architecture := range unsupported {
+		architectures = append(architectures, architecture)
+	}
+	sort.Strings(architectures)
+	for _, architecture := range architectures {
+		nodes := unsupported[architecture]
+		sort.Strings(nodes)
+		issues = append(issues, fmt.Sprintf("IBM CNSA version %s does not support the %s architecture of nodes %s",
+			ibmCnsaVersion, architecture, strings.Join(nodes, ", ")))
+	}
+	return issues
+}
+
 // status:
 //  history:
 //   - completionTime: null
@@ -17,10 +17,16 @@ limitations under the License.
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/Masterminds/semver/v3"
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -101,12 +107,12 @@ func TestIsOpenShiftSupported(t *testing.T) {
 		ocpVersion string
 		expected   bool
 	}{
-		{"5.1.5.0", "4.9.0", true},        // Expected to be supported
-		{"5.1.5.0", "4.12.1", false},      // Not in the supported list
-		{"5.1.7.0", "4.11.43", true},      // Supported
-		{"5.1.7.0", "4.13.34", false},     // Not supported
-		{"5.1.9.1", "4.12.7", true},       // Supported
-		{"5.1.9.1", "4.15.0", false},      // Not supported
+		{"5.2.1.1", "4.14.0", true},       // Expected to be supported
+		{"5.2.1.1", "4.17.1", false},      // Not in the supported list
+		{"5.2.1.1", "4.16.9", true},       // Supported
+		{"5.2.1.1", "4.13.34", false},     // Not supported
+		{"5.2.2.1", "4.15.2", true},       // Supported
+		{"5.2.2.1", "4.18.0", false},      // Not supported
 		{"5.2.2.0", "4.17.3", true},       // Supported
 		{"5.2.2.0", "4.18.1", false},      // Not supported
 		{"5.2.2.0", "4.15.17", true},      // Supported
@@ -114,9 +120,167 @@ func TestIsOpenShiftSupported(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := IsOpenShiftSupported(tt.ibmVersion, *semver.MustParse(tt.ocpVersion))
+		result := embeddedSupportMatrix.IsOpenShiftSupported(tt.ibmVersion, *semver.MustParse(tt.ocpVersion))
 		if result != tt.expected {
 			t.Errorf("IsOpenShiftSupported(%s, %s) = %v; expected %v", tt.ibmVersion, tt.ocpVersion, result, tt.expected)
 		}
 	}
 }
+
+func TestGetSupportIssues(t *testing.T) {
+	// the install manifests are looked up relative to the working directory
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "files", "v5.2.2.0"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "files", "v5.2.2.0", "install.yaml"), []byte{}, 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "files", "testversion"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "files", "testversion", "install.yaml"), []byte{}, 0o600))
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer func() { _ = os.Chdir(wd) }()
+
+	tests := []struct {
+		ibmVersion    string
+		ocpVersion    string
+		architectures map[string]string
+		expected      []string
+	}{
+		{ // case 1: supported
+			ibmVersion:    "v5.2.2.0",
+			ocpVersion:    "4.17.3",
+			architectures: map[string]string{"worker-0": "amd64", "worker-1": "s390x"},
+			expected:      []string{},
+		},
+		{ // case 2: OpenShift version outside of the levels
+			ibmVersion: "v5.2.2.0",
+			ocpVersion: "4.18.1",
+			expected:   []string{"IBM CNSA version v5.2.2.0 does not support OpenShift 4.18.1, only 4.15, 4.16, 4.17"},
+		},
+		{ // case 3: architecture of the nodes
+			ibmVersion:    "v5.2.2.0",
+			ocpVersion:    "4.16.0",
+			architectures: map[string]string{"worker-1": "arm64", "worker-0": "arm64", "worker-2": "amd64"},
+			expected:      []string{"IBM CNSA version v5.2.2.0 does not support the aarch64 architecture of nodes worker-0, worker-1"},
+		},
+		{ // case 4: version without install manifest
+			ibmVersion: "v5.2.1.1",
+			ocpVersion: "4.16.0",
+			expected:   []string{`IBM CNSA version "v5.2.1.1" has no files/v5.2.1.1/install.yaml`},
+		},
+		{ // case 5: version without support matrix data
+			ibmVersion: "testversion",
+			ocpVersion: "4.16.0",
+			expected:   []string{`IBM CNSA version "testversion" is not in the support matrix`},
+		},
+	}
+	for i, test := range tests {
+		issues := GetSupportIssues(embeddedSupportMatrix, test.ibmVersion, semver.MustParse(test.ocpVersion), test.architectures)
+		assert.Equalf(t, test.expected, issues, "case %d", i+1)
+	}
+}
+
+const testSupportMatrixData = `schema_version: 1
+csi_version: "2.14.0"
+architecture:
+- x86_64
+remote_storage_cluster_level: "5.1.9.0+"
+file_system_version: "37.00"
+openshift_levels:
+- "4.18"
+`
+
+func TestShippedVersionsHaveSupportMatrix(t *testing.T) {
+	// every version shipped in files/ must have its support matrix data
+	installs, err := filepath.Glob(filepath.Join("..", "..", "files", "*", "install.yaml"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, installs)
+	for _, install := range installs {
+		version := filepath.Base(filepath.Dir(install))
+		if version == "testversion" {
+			// not an IBM CNSA release
+			continue
+		}
+		_, exists := embeddedSupportMatrix[version[1:]]
+		assert.Truef(t, exists, "files/%s has no %s", version, SupportMatrixFile)
+	}
+
+	// and the support matrix only covers shipped versions
+	for version := range embeddedSupportMatrix {
+		_, err := os.Stat(filepath.Join("..", "..", "files", "v"+version, "install.yaml"))
+		assert.NoErrorf(t, err, "support matrix of version %s without install.yaml", version)
+	}
+}
+
+func TestLoadSupportMatrix(t *testing.T) {
+	tests := []struct {
+		fsys     fstest.MapFS
+		expected SupportMatrix
+		err      string
+	}{
+		{ // case 1: the versions are the directories without their "v"
+			fsys: fstest.MapFS{
+				"v5.2.3.0/support.yaml": {Data: []byte(testSupportMatrixData)},
+				"v5.2.3.0/install.yaml": {Data: []byte{}},
+			},
+			expected: SupportMatrix{"5.2.3.0": {
+				CSIVersion:                "2.14.0",
+				Architecture:              []string{"x86_64"},
+				RemoteStorageClusterLevel: "5.1.9.0+",
+				FileSystemVersion:         "37.00",
+				OpenShiftLevels:           []string{"4.18"},
+			}},
+		},
+		{ // case 2: unknown schema version
+			fsys: fstest.MapFS{"v5.2.3.0/support.yaml": {Data: []byte("schema_version: 2\n")}},
+			err:  "failed to parse v5.2.3.0/support.yaml: unsupported schema_version 2, expected 1",
+		},
+		{ // case 3: unknown field
+			fsys: fstest.MapFS{"v5.2.3.0/support.yaml": {Data: []byte(testSupportMatrixData + "kernel: 5.14\n")}},
+			err:  `failed to parse v5.2.3.0/support.yaml: error unmarshaling JSON: while decoding JSON: json: unknown field "kernel"`,
+		},
+		{ // case 4: missing OpenShift levels
+			fsys: fstest.MapFS{"v5.2.3.0/support.yaml": {Data: []byte("schema_version: 1\ncsi_version: 2.14.0\narchitecture: [x86_64]\n")}},
+			err:  "failed to parse v5.2.3.0/support.yaml: csi_version, architecture and openshift_levels are required",
+		},
+	}
+	for i, test := range tests {
+		matrix, err := LoadSupportMatrix(test.fsys)
+		if test.err != "" {
+			assert.EqualErrorf(t, err, test.err, "case %d", i+1)
+			continue
+		}
+		assert.NoErrorf(t, err, "case %d", i+1)
+		assert.Equalf(t, test.expected, matrix, "case %d", i+1)
+	}
+}
+
+func TestGetSupportMatrix(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: SupportMatrixConfigMap, Namespace: "purple-storage"},
+		Data:       map[string]string{"v5.2.3.0": testSupportMatrixData, "5.2.2.0": testSupportMatrixData},
+	}
+
+	// versions are added and replaced
+	matrix, err := GetSupportMatrix(configMap)
+	assert.NoError(t, err)
+	assert.True(t, matrix.IsOpenShiftSupported("v5.2.3.0", *semver.MustParse("4.18.2")))
+	assert.True(t, matrix.IsOpenShiftSupported("v5.2.2.0", *semver.MustParse("4.18.2")))
+	assert.False(t, matrix.IsOpenShiftSupported("v5.2.2.0", *semver.MustParse("4.17.3")))
+	assert.True(t, matrix.IsOpenShiftSupported("v5.2.2.1", *semver.MustParse("4.17.3")))
+
+	// the embedded matrix is not modified
+	_, exists := embeddedSupportMatrix.GetStorageScaleData("v5.2.3.0")
+	assert.False(t, exists)
+	assert.True(t, embeddedSupportMatrix.IsOpenShiftSupported("v5.2.2.0", *semver.MustParse("4.17.3")))
+
+	// without ConfigMap it is the embedded matrix
+	matrix, err = GetSupportMatrix(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, embeddedSupportMatrix, matrix)
+
+	// an invalid ConfigMap is an error
+	configMap.Data["v5.2.4.0"] = "schema_version: 1\n"
+	_, err = GetSupportMatrix(configMap)
+	assert.EqualError(t, err, "invalid ConfigMap purple-storage/purple-storage-support-matrix: failed to parse version v5.2.4.0: "+
+		"csi_version, architecture and openshift_levels are required")
+}
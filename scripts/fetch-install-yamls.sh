@@ -16,4 +16,7 @@ for VERSION in "${VERSIONS[@]}"; do
     else
         echo "Failed to download install.yaml for $VERSION"
     fi
+    if [[ ! -f "files/$VERSION/support.yaml" ]]; then
+        echo "files/$VERSION/support.yaml is missing, add the support matrix of $VERSION"
+    fi
 done